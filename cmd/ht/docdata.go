@@ -125,6 +125,37 @@ var typeDoc = map[string]string{
 		"    Condition is a conjunction of tests against a string. Note that Contains and\n" +
 		"    Regexp conditions both use the same Count; most likely one would use either\n" +
 		"    Contains or Regexp but not both.",
+	"contentefficiency": "type ContentEfficiency struct {\n" +
+		"\t// MinCompressSize is the size in bytes from which on compressible\n" +
+		"\t// responses must be compressed. The zero value means 1024 bytes.\n" +
+		"\tMinCompressSize int \n" +
+		"\n" +
+		"\t// MaxWaste is the maximal fraction of a HTML, CSS or JavaScript body\n" +
+		"\t// which could be saved by minification. The zero value means 0.1.\n" +
+		"\tMaxWaste float64 \n" +
+		"\n" +
+		"\t// LargeImage is the size in bytes from which on images must use a\n" +
+		"\t// modern format. The zero value means 50kB.\n" +
+		"\tLargeImage int \n" +
+		"\n" +
+		"\t// Ignore lists aspects not to check, e.g. \"logo\".\n" +
+		"\tIgnore []string \n" +
+		"}\n" +
+		"    ContentEfficiency checks how efficient the content is delivered following\n" +
+		"    https://developers.google.com/web/fundamentals/performance/optimizing-content-efficiency/\n" +
+		"    The following aspects are checked:\n" +
+		"\n" +
+		"        compression    compressible responses (text, JSON, JavaScript, XML,\n" +
+		"                       SVG) are sent gzip or brotli compressed if allowed by\n" +
+		"                       the Accept-Encoding of the request\n" +
+		"        minification   HTML, CSS and JavaScript contain only a small fraction\n" +
+		"                       of comments and superfluous whitespace\n" +
+		"        logo           images with \"logo\" in their filename are SVGs or PNGs\n" +
+		"                       with a palette (PNG-8)\n" +
+		"        image-format   large images are delivered in a modern format like\n" +
+		"                       WebP or AVIF\n" +
+		"\n" +
+		"    Failures report an estimate of the bytes which could be saved.",
 	"contenttype": "type ContentType struct {\n" +
 		"\t// Is is the wanted content type. It may be abrevated, e.g.\n" +
 		"\t// \"json\" would match \"application/json\"\n" +
@@ -144,6 +175,71 @@ var typeDoc = map[string]string{
 		"}\n" +
 		"    CookieExtractor extracts the value of a cookie received in a Set-Cookie\n" +
 		"    header. The value of the first cookie with the given name is extracted.",
+	"cors": "type CORS struct {\n" +
+		"\t// Origin is the origin of the cross-origin request,\n" +
+		"\t// e.g. \"https://app.example.org\".\n" +
+		"\tOrigin string\n" +
+		"\n" +
+		"\t// Method is the method of the actual request announced in the\n" +
+		"\t// preflight. The zero value uses the method of the test.\n" +
+		"\tMethod string \n" +
+		"\n" +
+		"\t// Headers are the non-simple request headers announced in the\n" +
+		"\t// preflight, e.g. [\"Authorization\", \"Content-Type\"].\n" +
+		"\tHeaders []string \n" +
+		"\n" +
+		"\t// Credentials requires that credentials (cookies, authorization)\n" +
+		"\t// are allowed.\n" +
+		"\tCredentials bool \n" +
+		"\n" +
+		"\t// MaxAge is the minimal duration the preflight response may be\n" +
+		"\t// cached as reported in the Access-Control-Max-Age header.\n" +
+		"\tMaxAge time.Duration \n" +
+		"\n" +
+		"\t// Denied inverts the check: The Origin must not be allowed.\n" +
+		"\tDenied bool \n" +
+		"}\n" +
+		"    CORS checks the Cross-Origin Resource Sharing of the test's URL: An OPTIONS\n" +
+		"    preflight request with the headers Origin, Access-Control-Request-Method\n" +
+		"    and Access-Control-Request-Headers is sent and the Access-Control-Allow-*\n" +
+		"    headers of the answer are validated.\n" +
+		"\n" +
+		"    The response to the actual request must allow the Origin too and must\n" +
+		"    contain a \"Vary: Origin\" header unless all origins are allowed with \"*\". If\n" +
+		"    the test's request does not send the Origin header the request is repeated\n" +
+		"    with an Origin header.",
+	"csp": "type CSP struct {\n" +
+		"\t// Required lists directives which must be present, e.g.\n" +
+		"\t// \"default-src\" or \"frame-ancestors\".\n" +
+		"\tRequired []string \n" +
+		"\n" +
+		"\t// Forbidden maps directives to source expressions which must not be\n" +
+		"\t// allowed by the directive, e.g.\n" +
+		"\t//     {\"script-src\": [\"'unsafe-inline'\", \"'unsafe-eval'\", \"data:\"]}\n" +
+		"\t// Fetch directives fall back to default-src if absent.\n" +
+		"\tForbidden map[string][]string \n" +
+		"\n" +
+		"\t// AllowOnly maps directives to the source expressions allowed in\n" +
+		"\t// the directive, e.g.\n" +
+		"\t//     {\"script-src\": [\"'self'\", \"https://cdn.example.org\"]}\n" +
+		"\t// Any other source expression (except nonces and hashes) is reported.\n" +
+		"\tAllowOnly map[string][]string \n" +
+		"\n" +
+		"\t// Resources is a space separated list of tags like in Links.Which:\n" +
+		"\t//     'script', 'link', 'img', 'video', 'audio', 'source' or 'iframe'\n" +
+		"\t// The URLs of these resources are checked against the policy and\n" +
+		"\t// resources a browser would block are reported. Only link elements\n" +
+		"\t// with rel stylesheet, icon, apple-touch-icon or manifest are checked.\n" +
+		"\tResources string \n" +
+		"\n" +
+		"\t// Has unexported fields.\n" +
+		"}\n" +
+		"    CSP checks the Content-Security-Policy of a response. The policies are taken\n" +
+		"    from the Content-Security-Policy headers and, for HTML documents, from <meta\n" +
+		"    http-equiv=\"Content-Security-Policy\"> elements. If several policies are\n" +
+		"    present a resource must be allowed by all of them.\n" +
+		"\n" +
+		"    The zero value checks for the presence of a policy.",
 	"customjs": "type CustomJS struct {\n" +
 		"\t// Script is JavaScript code to be evaluated.\n" +
 		"\t//\n" +
@@ -207,6 +303,53 @@ var typeDoc = map[string]string{
 	"finalurl": "type FinalURL Condition\n" +
 		"    FinalURL checks the last URL after following all redirects. This check is\n" +
 		"    useful only for tests with Request.FollowRedirects=true",
+	"golden": "type Golden struct {\n" +
+		"\t// File is the golden file, typically given relative to the test\n" +
+		"\t// as \"{{TEST_DIR}}/some-name.golden\".\n" +
+		"\tFile string\n" +
+		"\n" +
+		"\t// Normalize the body before comparison and before writing the\n" +
+		"\t// golden file:\n" +
+		"\t//     \"\"      compare the body as is\n" +
+		"\t//     \"json\"  pretty print the JSON body with sorted object keys\n" +
+		"\tNormalize string \n" +
+		"\n" +
+		"\t// Mask lists elements of a JSON body whose values are replaced by\n" +
+		"\t// \"<masked>\". The elements are given in the syntax of the JSON check\n" +
+		"\t// (e.g. \"data.0.id\"), a \"*\" matches any key or index (e.g.\n" +
+		"\t// \"items.*.created\"). Mask requires Normalize \"json\".\n" +
+		"\tMask []string \n" +
+		"\n" +
+		"\t// Sep is the separator in Mask, a zero value is equivalent to \".\".\n" +
+		"\tSep string \n" +
+		"\n" +
+		"\t// MaskRegexp lists regular expressions whose matches in the\n" +
+		"\t// (normalized) body are replaced by \"<masked>\".\n" +
+		"\tMaskRegexp []string \n" +
+		"\n" +
+		"\t// Has unexported fields.\n" +
+		"}\n" +
+		"    Golden compares the response body to the content of a golden file. The body\n" +
+		"    may be normalized and volatile parts like timestamps or session IDs can be\n" +
+		"    masked before comparison. A mismatch is reported as a unified diff between\n" +
+		"    the golden file and the (normalized) body.\n" +
+		"\n" +
+		"    If UpdateGolden is set (e.g. via the -update-golden flag of cmd/ht) the\n" +
+		"    golden file is written from the normalized body and the check passes.",
+	"graphql": "type GraphQL struct {\n" +
+		"\t// AllowErrors does not fail the check on a non-empty errors array,\n" +
+		"\t// e.g. to check partial data or Data of a failed operation.\n" +
+		"\tAllowErrors bool \n" +
+		"\n" +
+		"\t// Data are JSON checks applied to the data object.\n" +
+		"\tData []JSON \n" +
+		"}\n" +
+		"    GraphQL checks the response to a GraphQL request. GraphQL servers typically\n" +
+		"    report errors with a 200 status code in the errors array of the response;\n" +
+		"    the check fails if this array is not empty. The JSON checks in Data are\n" +
+		"    applied to the data object of the response, i.e. their Elements are relative\n" +
+		"    to data: The Element \"order.status\" selects the status of the order in\n" +
+		"    {\"data\": {\"order\": {\"status\": \"OPEN\"}}}.",
 	"htmlcontains": "type HTMLContains struct {\n" +
 		"\t// Selector is the CSS selector of the HTML elements.\n" +
 		"\tSelector string\n" +
@@ -489,6 +632,94 @@ var typeDoc = map[string]string{
 		"    will extract the following string with verbatim spaces in the array:\n" +
 		"\n" +
 		"    \"[ 1 , 2,3]\"",
+	"jsonschema": "type JSONSchema struct {\n" +
+		"\t// Element in the flattened JSON map to validate, see JSON for\n" +
+		"\t// details. An empty Element validates the whole document.\n" +
+		"\tElement string \n" +
+		"\n" +
+		"\t// Sep is the separator in Element, a zero value is equivalent\n" +
+		"\t// to \".\".\n" +
+		"\tSep string \n" +
+		"\n" +
+		"\t// Schema is the JSON Schema document. It may be given inline or\n" +
+		"\t// read from a file with the @file: and @vfile: syntax, e.g.\n" +
+		"\t// \"@file:{{TEST_DIR}}/schema.json\".\n" +
+		"\tSchema string\n" +
+		"\n" +
+		"\t// Has unexported fields.\n" +
+		"}\n" +
+		"    JSONSchema validates a JSON document (or an element of it) against a JSON\n" +
+		"    Schema as described on http://json-schema.org. Draft-07 and 2020-12 schemas\n" +
+		"    are supported including required, enum, const, pattern, format, allOf,\n" +
+		"    anyOf, oneOf, not, if/then/else and local $refs like \"#/definitions/address\"\n" +
+		"    or \"#/$defs/address\". Unsupported are unevaluatedProperties,\n" +
+		"    unevaluatedItems and $refs to other documents.\n" +
+		"\n" +
+		"    The regular expressions in pattern and patternProperties use Go's RE2\n" +
+		"    syntax, not ECMA-262: Lookarounds and backreferences like \"^(?!admin)\" are\n" +
+		"    rejected when preparing the check.\n" +
+		"\n" +
+		"    All violations are reported, each prefixed with the JSON pointer of the\n" +
+		"    offending element, e.g.\n" +
+		"\n" +
+		"        #/items/3/price: got string, want number\n" +
+		"        #/items/5: missing required property \"name\"",
+	"jwt": "type JWT struct {\n" +
+		"\t// JWTSource determines where the token is found.\n" +
+		"\tJWTSource\n" +
+		"\n" +
+		"\t// Key is used to verify the signature: The shared secret for the HS256,\n" +
+		"\t// HS384 and HS512 algorithms or a PEM encoded public key or certificate\n" +
+		"\t// for RS*, PS* and ES*. The key may be read from a file with the\n" +
+		"\t// @file: syntax.\n" +
+		"\tKey string \n" +
+		"\n" +
+		"\t// JWKS is a JSON Web Key Set, typically read from a file like\n" +
+		"\t// \"@file:{{TEST_DIR}}/jwks.json\". The key is selected by the kid\n" +
+		"\t// header parameter of the token.\n" +
+		"\tJWKS string \n" +
+		"\n" +
+		"\t// MinValidity is the minimum time the token must be valid: Its exp\n" +
+		"\t// claim must be at least this far in the future.\n" +
+		"\tMinValidity time.Duration \n" +
+		"\n" +
+		"\t// Audience must be (one of) the aud claim.\n" +
+		"\tAudience string \n" +
+		"\n" +
+		"\t// Scopes must all be contained in the space separated scope claim\n" +
+		"\t// (or the scp claim).\n" +
+		"\tScopes []string \n" +
+		"\n" +
+		"\t// Claims are conditions on the claims of the token.\n" +
+		"\tClaims map[string]Condition \n" +
+		"\n" +
+		"\t// TokenHeader are conditions on the parameters of the token header\n" +
+		"\t// like \"alg\" or \"kid\".\n" +
+		"\tTokenHeader map[string]Condition \n" +
+		"\n" +
+		"\t// Has unexported fields.\n" +
+		"}\n" +
+		"    JWT checks a JSON Web Token (RFC 7519) received in the response. The token\n" +
+		"    is decoded and its signature verified if a Key or a JWKS is given. Unsigned\n" +
+		"    tokens (alg \"none\"), expired tokens and tokens not yet valid due to their\n" +
+		"    nbf claim always fail the check.\n" +
+		"\n" +
+		"    Conditions on claims and header parameters are applied to the values with\n" +
+		"    strings unquoted; other values like numbers, arrays or objects are checked\n" +
+		"    as raw JSON. Nested claims are selected like elements in the JSON check,\n" +
+		"    e.g. the condition {Contains: \"\\\"admin\\\"\"} on the claim \"realm_access.roles\"\n" +
+		"    requires the admin role in a Keycloak token.",
+	"jwtextractor": "type JWTExtractor struct {\n" +
+		"\t// JWTSource determines where the token is found.\n" +
+		"\tJWTSource\n" +
+		"\n" +
+		"\t// Claim is the (nested) claim to extract, e.g. \"sub\" or\n" +
+		"\t// \"realm_access.roles.0\". Strings are unquoted, other values are\n" +
+		"\t// extracted as raw JSON. The zero value extracts the token itself.\n" +
+		"\tClaim string \n" +
+		"}\n" +
+		"    JWTExtractor extracts a claim from a JSON Web Token. The signature is not\n" +
+		"    verified, use the JWT check for this.",
 	"latency": "type Latency struct {\n" +
 		"\t// N is the number if request to measure. It should be much larger\n" +
 		"\t// than Concurrent. Default is 50.\n" +
@@ -620,6 +851,31 @@ var typeDoc = map[string]string{
 		"            {Check: \"Body\", Contains: \"foo\"},\n" +
 		"        ]\n" +
 		"    }",
+	"openapi": "type OpenAPI struct {\n" +
+		"\t// Spec is the OpenAPI 3 document in JSON or YAML. Typically it\n" +
+		"\t// is read from a file with the @file: syntax, e.g.\n" +
+		"\t// \"@file:{{TEST_DIR}}/openapi.yaml\".\n" +
+		"\tSpec string\n" +
+		"\n" +
+		"\t// BasePath is stripped from the request path before looking up\n" +
+		"\t// the operation. If empty the path of the first server URL of\n" +
+		"\t// the spec is used.\n" +
+		"\tBasePath string \n" +
+		"\n" +
+		"\t// Has unexported fields.\n" +
+		"}\n" +
+		"    OpenAPI checks that the response conforms to an OpenAPI 3 spec.\n" +
+		"    The operation is looked up by the method and the path of the request\n" +
+		"    (after following redirects): Its path must match one of the path templates\n" +
+		"    like \"/pets/{petId}\" and the method must be described for this path. The\n" +
+		"    received status code must be documented for this operation (possibly through\n" +
+		"    a range like \"2XX\" or a default response). Headers declared required for\n" +
+		"    the response must be present and their values must validate against their\n" +
+		"    schema. A body must have one of the documented media types and JSON bodies\n" +
+		"    must validate against the schema of this media type.\n" +
+		"\n" +
+		"    An undocumented operation fails with ErrUndocumentedOperation, an\n" +
+		"    undocumented status code with ErrUndocumentedStatus.",
 	"redirect": "type Redirect struct {\n" +
 		"\t// To is matched against the Location header. It may begin with,\n" +
 		"\t// end with or contain three dots \"...\" which indicate that To should\n" +
@@ -647,15 +903,19 @@ var typeDoc = map[string]string{
 		"\t// Any URL may start with, end with or contain three dots \"...\" which\n" +
 		"\t// indicate a suffix, prefix or suffix+prefix match like in the To\n" +
 		"\t// field of Redirect.\n" +
-		"\tVia []string\n" +
+		"\tVia []string \n" +
+		"\n" +
+		"\t// Hops are checks of individual redirect responses of the chain.\n" +
+		"\tHops []RedirectHop \n" +
 		"}\n" +
 		"    RedirectChain checks steps in a redirect chain. The check passes if all\n" +
 		"    stations in Via have been accessed in order; the actual redirect chain may\n" +
-		"    hit additional stations.\n" +
+		"    hit additional stations. The individual redirect responses of the chain can\n" +
+		"    be checked with Hops.\n" +
 		"\n" +
 		"    Note that this check can be used on tests with\n" +
 		"\n" +
-		"    Request.FollowRedirects = true",
+		"        Request.FollowRedirects = true",
 	"renderedhtml": "type RenderedHTML struct {\n" +
 		"\tBrowser\n" +
 		"\n" +
@@ -729,6 +989,11 @@ var typeDoc = map[string]string{
 		"\t// empty if Params are sent as multipart or form-urlencoded.\n" +
 		"\tBody string \n" +
 		"\n" +
+		"\t// GraphQL is a GraphQL operation to send. It is sent as a JSON body\n" +
+		"\t// of a POST request if Method is empty. Body and body or multipart\n" +
+		"\t// Params must not be used together with GraphQL.\n" +
+		"\tGraphQL *GraphQLRequest \n" +
+		"\n" +
 		"\t// FollowRedirects determines if automatic following of\n" +
 		"\t// redirects should be done.\n" +
 		"\tFollowRedirects bool \n" +
@@ -740,6 +1005,10 @@ var typeDoc = map[string]string{
 		"\tBasicAuthUser string \n" +
 		"\tBasicAuthPass string \n" +
 		"\n" +
+		"\t// Auth configures other authentication schemes like OAuth2 and\n" +
+		"\t// request signing.\n" +
+		"\tAuth *Auth \n" +
+		"\n" +
 		"\t// Chunked turns of setting of the Content-Length header resulting\n" +
 		"\t// in chunked transfer encoding of POST bodies.\n" +
 		"\tChunked bool \n" +
@@ -747,6 +1016,10 @@ var typeDoc = map[string]string{
 		"\t// Timeout of this request. If zero use DefaultClientTimeout.\n" +
 		"\tTimeout time.Duration \n" +
 		"\n" +
+		"\t// Client configures the HTTP client used to make this request,\n" +
+		"\t// e.g. the protocol version, keep-alives or DNS overrides.\n" +
+		"\tClient ClientConfig \n" +
+		"\n" +
 		"\tRequest    *http.Request  // the 'real' request\n" +
 		"\tSentBody   string         // the 'real' body\n" +
 		"\tSentParams url.Values     // the 'real' parameters\n" +
@@ -876,6 +1149,38 @@ var typeDoc = map[string]string{
 		"    BasicAuthPass) set this credentials will be sent to all linked resources of\n" +
 		"    the page. Depending on where these resources are located this might be a\n" +
 		"    security issue.",
+	"securityheaders": "type SecurityHeaders struct {\n" +
+		"\t// Level is the policy level, either \"basic\" or \"strict\".\n" +
+		"\t// The zero value is equivalent to \"basic\".\n" +
+		"\tLevel string \n" +
+		"\n" +
+		"\t// Ignore lists aspects not to check, e.g. \"Permissions-Policy\"\n" +
+		"\t// or \"JSON\". See above for the list of aspects.\n" +
+		"\tIgnore []string \n" +
+		"}\n" +
+		"    SecurityHeaders checks the security related headers of a response against a\n" +
+		"    policy level. The following aspects are checked:\n" +
+		"\n" +
+		"        Strict-Transport-Security  on HTTPS only: max-age of at least\n" +
+		"                                   180 days (strict: one year and\n" +
+		"                                   includeSubDomains and preload)\n" +
+		"        Content-Security-Policy    present on HTML pages (strict: without\n" +
+		"                                   'unsafe-inline' and 'unsafe-eval' for\n" +
+		"                                   scripts)\n" +
+		"        X-Content-Type-Options     is \"nosniff\"\n" +
+		"        Referrer-Policy            present and not \"unsafe-url\" (strict:\n" +
+		"                                   no-referrer, same-origin, strict-origin\n" +
+		"                                   or strict-origin-when-cross-origin)\n" +
+		"        Permissions-Policy         strict only: present\n" +
+		"        X-Frame-Options            HTML pages must not be framable: DENY or\n" +
+		"                                   SAMEORIGIN or a CSP frame-ancestors\n" +
+		"        Set-Cookie                 on HTTPS cookies are Secure (strict: and\n" +
+		"                                   HttpOnly with a SameSite attribute)\n" +
+		"        JSON                       JSON bodies are sent as application/json\n" +
+		"                                   and are objects (no top-level arrays or\n" +
+		"                                   primitives)\n" +
+		"\n" +
+		"    See https://httpsecurityreport.com/best_practice.html for background.",
 	"setcookie": "type SetCookie struct {\n" +
 		"\tName   string     // Name is the cookie name.\n" +
 		"\tValue  Condition  // Value is applied to the cookie value\n" +
@@ -899,6 +1204,11 @@ var typeDoc = map[string]string{
 		"\t//   - \"httpOnly\": not accesible from JavaScript\n" +
 		"\t//   - \"exposed\": accesible from JavaScript, Flash, etc.\n" +
 		"\tType string \n" +
+		"\n" +
+		"\t// Hop selects the redirect response of a followed redirect chain\n" +
+		"\t// whose cookies are checked (1 being the response to the original\n" +
+		"\t// request, see Response.Hops). Zero checks the final response.\n" +
+		"\tHop int \n" +
 		"}\n" +
 		"    SetCookie checks for cookies being properly set. Note that the Path and\n" +
 		"    Domain conditions are checked on the received Path and/or Domain and not on\n" +
@@ -996,6 +1306,75 @@ var typeDoc = map[string]string{
 		"}\n" +
 		"    Test is a single logical test which does one HTTP request and checks a\n" +
 		"    number of Checks on the received Response.",
+	"timing": "type Timing struct {\n" +
+		"\t// DNS is the maximal duration of the DNS lookup.\n" +
+		"\tDNS time.Duration \n" +
+		"\n" +
+		"\t// Connect is the maximal duration of the TCP connection setup.\n" +
+		"\tConnect time.Duration \n" +
+		"\n" +
+		"\t// TLS is the maximal duration of the TLS handshake.\n" +
+		"\tTLS time.Duration \n" +
+		"\n" +
+		"\t// TTFB is the maximal time to first byte of the response, measured\n" +
+		"\t// from the start of the request.\n" +
+		"\tTTFB time.Duration \n" +
+		"\n" +
+		"\t// Transfer is the maximal duration to read the body after the first\n" +
+		"\t// byte was received.\n" +
+		"\tTransfer time.Duration \n" +
+		"}\n" +
+		"    Timing checks the durations of the individual phases of the request as\n" +
+		"    recorded in the Timings of the response. A zero limit does not restrict\n" +
+		"    the phase. Phases which did not happen (e.g. the DNS lookup on a reused\n" +
+		"    connection) pass every limit.",
+	"tls": "type TLS struct {\n" +
+		"\t// MinDaysValid is the minimum number of days the server certificate\n" +
+		"\t// must still be valid.\n" +
+		"\tMinDaysValid int \n" +
+		"\n" +
+		"\t// Names lists the host names the certificate must be valid for.\n" +
+		"\t// Subject alternative names (including wildcards) and the common\n" +
+		"\t// name are considered.\n" +
+		"\tNames []string \n" +
+		"\n" +
+		"\t// Subject is applied to the subject of the certificate formatted\n" +
+		"\t// like \"CN=www.example.org,O=Example Corp,C=CH\".\n" +
+		"\tSubject Condition \n" +
+		"\n" +
+		"\t// Issuer is applied to the issuer of the certificate formatted\n" +
+		"\t// like Subject.\n" +
+		"\tIssuer Condition \n" +
+		"\n" +
+		"\t// Chain requests that the certificate chain sent by the server is\n" +
+		"\t// complete, i.e. that the certificate can be verified from the\n" +
+		"\t// intermediates sent by the server up to a trusted root.\n" +
+		"\tChain bool \n" +
+		"\n" +
+		"\t// RootCAs are additional trusted root certificates in PEM format\n" +
+		"\t// used when verifying the Chain. They may be read from a file\n" +
+		"\t// with the @file: syntax, e.g. \"@file:{{TEST_DIR}}/ca.pem\".\n" +
+		"\tRootCAs string \n" +
+		"\n" +
+		"\t// MinVersion is the minimum negotiated protocol version, one of\n" +
+		"\t// \"TLS1.0\", \"TLS1.1\", \"TLS1.2\" and \"TLS1.3\".\n" +
+		"\tMinVersion string \n" +
+		"\n" +
+		"\t// ForbiddenCiphers lists cipher suites which must not be negotiated.\n" +
+		"\t// A cipher suite is forbidden if its name (e.g.\n" +
+		"\t// \"TLS_RSA_WITH_AES_128_CBC_SHA\") contains one of the given strings,\n" +
+		"\t// e.g. \"_CBC_\" or \"RC4\".\n" +
+		"\tForbiddenCiphers []string \n" +
+		"\n" +
+		"\t// Has unexported fields.\n" +
+		"}\n" +
+		"    TLS checks the certificate presented by the server and the parameters of the\n" +
+		"    TLS handshake. The connection state of the response is inspected; if it is\n" +
+		"    not available (e.g. for a test loaded from disk) an own handshake with the\n" +
+		"    host of the request URL is made using the Client configuration of the test.\n" +
+		"    Such a handshake cannot be made through a proxy.\n" +
+		"\n" +
+		"    The zero value checks that the response was received over TLS only.",
 	"utf8encoded": "type UTF8Encoded struct{}\n" +
 		"    UTF8Encoded checks that the response body is valid UTF-8 without BOMs.",
 	"validhtml": "type ValidHTML struct {\n" +
@@ -1152,10 +1531,29 @@ var typeDoc = map[string]string{
 		"\tFile      string\n" +
 		"\tVariables map[string]string\n" +
 		"\tMocks     []string\n" +
+		"\tData      string\n" +
+		"\tIf        string\n" +
+		"\tUnless    string\n" +
+		"\tTags      []string\n" +
 		"\n" +
 		"\tTest map[string]interface{}\n" +
 		"}\n" +
-		"    RawElement represents one test in a RawSuite.",
+		"    RawElement represents one test in a RawSuite.\n" +
+		"\n" +
+		"    If Data names a CSV or JSON file the test is called once for each row of\n" +
+		"    this table with the columns as additional call Variables, see LoadData for\n" +
+		"    the format.\n" +
+		"\n" +
+		"    The test is skipped if the If condition is false or the Unless condition is\n" +
+		"    true. Conditions are expressions like\n" +
+		"\n" +
+		"        \"{{FEATURE_X}}\" == \"off\" || status(\"Login\") != \"Pass\"\n" +
+		"\n" +
+		"    which are evaluated after variable substitution when the test is due.\n" +
+		"    The function status returns the status of an earlier test of the suite given\n" +
+		"    by name or sequence number (e.g. \"Setup-02\").\n" +
+		"\n" +
+		"    Tags are added to the tags of the test.",
 	"rawloadtest": "type RawLoadTest struct {\n" +
 		"\t*File\n" +
 		"\tName        string\n" +
@@ -1188,6 +1586,7 @@ var typeDoc = map[string]string{
 		"\tSetup, Main, Teardown []RawElement\n" +
 		"\tKeepCookies           bool\n" +
 		"\tOmitChecks            bool\n" +
+		"\tParallel              bool\n" +
 		"\tVariables             map[string]string\n" +
 		"\tVerbosity             int\n" +
 		"\n" +
@@ -1198,6 +1597,7 @@ var typeDoc = map[string]string{
 		"\t*File\n" +
 		"\tMixins    []*Mixin          // Mixins of this test.\n" +
 		"\tVariables map[string]string // Variables are the defaults of the variables.\n" +
+		"\tTags      []string          // Tags of the test and its suite element.\n" +
 		"\n" +
 		"\t// Has unexported fields.\n" +
 		"}\n" +
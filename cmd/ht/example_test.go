@@ -332,6 +332,8 @@ var suiteExampleTests = []struct {
 	want ht.Status
 }{
 	{"Suite", ht.Pass},
+	{"Suite.Conditional", ht.Pass},
+	{"Suite.Data", ht.Pass},
 	{"Suite.InlineTest", ht.Pass},
 	{"Suite.Mock", ht.Fail},
 	{"Suite.Variables", ht.Pass},
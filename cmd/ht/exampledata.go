@@ -363,6 +363,7 @@ var RootExample = &Example{
     Description: "Explain the Setup, Main, Teardown and Variables fields."
     KeepCookies: true  // Like a browser does, useful for sessions.
     OmitChecks: false  // No, we want the checks to be executed!
+    Parallel: false    // Main tests may not run concurrently.
 
     // Setup and Main are the set of Tests executed and considered relevant
     // for this suite's success. The difference is how Tests with failures or
@@ -398,6 +399,67 @@ var RootExample = &Example{
 }`,
 			Sub: []*Example{
 				&Example{
+					Name:        "Suite.Conditional",
+					Description: "Conditional execution of Tests in a suite",
+					Data: `// Conditional execution of Tests in a suite
+{
+    Name: "Suite with conditional tests"
+
+    Main: [
+        {File: "Test.HTML"}
+
+        // Execute Test.JSON only if Test.HTML passed. Tests are referenced
+        // by name or by sequence number like "Setup-02" or "Main-01".
+        // Tests whose condition is not met are skipped.
+        {File: "Test.JSON", If: "status(Main-01) == Pass"}
+
+        // Conditions are evaluated after variable substitution.
+        // Quote variables as their value might contain spaces.
+        // Combine conditions with &&, || and ! and use parentheses.
+        {File: "Test.XML", Unless: "'{{FEATURE_XML}}' == off || !{{XML_ENABLED}}"}
+
+        // If and Unless may be combined: Both must allow the execution.
+        {File: "Test.Image", If: "true", Unless: "status(\"Test of a JSON document\") != Pass"}
+    ]
+
+    Variables: {
+        FEATURE_XML: "off"
+        XML_ENABLED: "true"
+    }
+}`,
+				}, &Example{
+					Name:        "Suite.Data",
+					Description: "Data driven Tests, calling a Test once for each row of a table",
+					Data: `// Data driven Tests, calling a Test once for each row of a table
+{
+    Name: "Suite with data driven tests"
+
+    Main: [
+        // The test is called once for each row in accounts.csv: The first
+        // line of the CSV file contains the variable names, each following
+        // line provides the values for one call. The columns dominate the
+        // Variables given here: LOCALE is "en" unless set in the data file.
+        {Test: {
+                   Name: "Login as {{USER}} ({{LOCALE}})"
+                   Request: {
+                       Method: "POST"
+                       URL:    "http://{{HOST}}/post"
+                       Body:   "user={{USER}}&locale={{LOCALE}}"
+                   }
+                   Checks: [
+                       {Check: "StatusCode", Expect: 200}
+                       {Check: "Body", Equals: "user={{USER}}&locale={{LOCALE}}"}
+                   ]
+               }
+         Data: "accounts.csv"
+         Variables: { LOCALE: "en" }
+        }
+
+        // Any other data file must contain a JSON array of objects.
+        {File: "Test.JSON", Data: "locales.json"}
+    ]
+}`,
+				}, &Example{
 					Name:        "Suite.InlineTest",
 					Description: "Inline Tests in a suite",
 					Data: `// Inline Tests in a suite
@@ -491,6 +553,9 @@ var RootExample = &Example{
         provide same background information on this test.
     '''
 
+    // Tags allow to select tests via 'ht exec -tags smoke ...'.
+    Tags: [ "smoke", "homepage" ]
+
     // Details of the Request follow:
     Request: {
         // The HTTP method. Defaults to GET.
@@ -896,6 +961,22 @@ var RootExample = &Example{
             }'''
         }
 
+        // Validate against a real JSON Schema (draft-07 or 2020-12).
+        // The schema may be read from a file: Schema: "@file:schema.json"
+        {Check: "JSONSchema", Schema: '''
+            {
+               "type": "object",
+               "required": ["Date", "Numbers", "Finished"],
+               "properties": {
+                   "Date":     {"type": "string", "format": "date"},
+                   "Numbers":  {"type": "array", "items": {"type": "integer", "minimum": 0}},
+                   "Finished": {"type": "boolean"},
+                   "Raw":      {"type": "string"}
+               }
+            }'''
+        }
+        {Check: "JSONSchema", Element: "Numbers", Schema: "{\"maxItems\": 6, \"uniqueItems\": true}"}
+
         // Interpret and check strings which contain embedded JSON:
         {Check: "JSON", Element: "Raw", Embedded: {Element: "coord.1", Equals: "-1"}}
         {Check: "JSON", Element: "Raw", Embedded: {Element: "label", Equals: "\"X\""}}
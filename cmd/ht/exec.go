@@ -6,6 +6,7 @@ package main
 
 import (
 	"bufio"
+	"bytes"
 	"encoding/json"
 	"flag"
 	"fmt"
@@ -19,6 +20,7 @@ import (
 	"runtime"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/vdobler/ht/cookiejar"
@@ -60,28 +62,59 @@ The TestIDs used in the -only and -skip flags have the format <suite>.<test>
 with <suite> and <test> the sequential numbers of the suite and the test
 inside the suite.  <test> maybe a single number like "3" or a range like
 "3-7" and the "<suite>." part can be omitted if only one suite is executed.
+
+Tests can be selected by their Tags too: The -tags and -exclude-tags flags
+take a tag expression like 'smoke && !slow' combining tags with &&, || and !
+and parentheses. Only Main tests are selected by tags, Setup and Teardown
+tests are executed unless no Main test of the suite is selected. Tags are
+set in the test file or in the suite element calling the test:
+    Tags: [ "smoke", "slow" ]
+
+With -parallel N up to N suites are executed concurrently. The suites are
+reported in the given order and results, reports and exit code are the same
+as for a sequential execution (unless the suites depend on each other e.g.
+via a common cookie jar). Suites using mocks are not executed concurrently
+with other suites as their mocks might listen on the same ports. The COUNTER
+variable is shared between all suites: Its values are unique but a suite
+sees gaps in the sequence if other suites run concurrently. The -carry flag
+cannot be combined with -parallel.
+Main tests of a single suite can be executed concurrently by setting
+Parallel: true in the suite file.
+
+With -update-golden the golden files of all Golden checks are written from
+the current responses instead of being compared to them.
 `,
 }
 
-var carryVars bool
+var (
+	carryVars      bool
+	parallelSuites int
+)
 
 func init() {
 	addOnlyFlag(cmdExec.Flag)
 	addSkipFlag(cmdExec.Flag)
+	addTagsFlag(cmdExec.Flag)
 
 	addTestFlags(cmdExec.Flag)
 	addOutputFlag(cmdExec.Flag)
 	addShowFlag(cmdExec.Flag)
+	addUpdateGoldenFlag(cmdExec.Flag)
 
 	cmdExec.Flag.BoolVar(&carryVars, "carry", false,
 		"carry variables from finished suite to next suite")
-
+	cmdExec.Flag.IntVar(&parallelSuites, "parallel", 1,
+		"execute up to `N` suites in parallel")
 }
 
 func runExecute(cmd *Command, suites []*suite.RawSuite) {
 	if ssilent {
 		silent = true
 	}
+	if parallelSuites > 1 && carryVars {
+		fmt.Fprintln(os.Stderr, "Cannot combine -carry with -parallel")
+		os.Exit(9)
+	}
 	prepareHT()
 	jar := loadCookies()
 
@@ -112,11 +145,17 @@ func prepareHT() {
 		fmt.Printf("Using %q as PhantomJS executable.\n", phantomjs)
 		fmt.Printf("Default client timeout is %s.\n", timeout)
 	}
+	if updateGolden {
+		if !silent {
+			fmt.Println("Updating golden files of Golden checks.")
+		}
+		ht.UpdateGolden = true
+	}
 	if skipTLSVerify {
 		if !silent {
 			fmt.Println("Skipping verification of TLS certificates presented by any server.")
 		}
-		ht.Transport.TLSClientConfig.InsecureSkipVerify = true
+		ht.SkipTLSVerify = true
 	}
 	if _, ok := variablesFlag["CWD"]; !ok {
 		cwd, err := os.Getwd()
@@ -177,13 +216,25 @@ func executeSuites(suites []*suite.RawSuite, variables map[string]string, jar *c
 		errors = errors.Append(err)
 	}
 
+	var outcomes []chan parallelOutcome
+	if parallelSuites > 1 {
+		outcomes = executeParallel(suites, variables, jar)
+	}
+
 	accum := newAccumulator()
 	multipleSuites := len(suites) > 1
 	for i, s := range suites {
-		if !ssilent {
-			logger.Println("Starting Suite", i+1, s.Name, s.File.Name)
+		var outcome *suite.Suite
+		if outcomes != nil {
+			result := <-outcomes[i]
+			bufferedStdout.Write(result.log.Bytes())
+			outcome = result.suite
+		} else {
+			if !ssilent {
+				logger.Println("Starting Suite", i+1, s.Name, s.File.Name)
+			}
+			outcome = s.Execute(variables, jar, logger)
 		}
-		outcome := s.Execute(variables, jar, logger)
 		bufferedStdout.Flush()
 
 		accum.update(outcome)
@@ -210,12 +261,58 @@ func executeSuites(suites []*suite.RawSuite, variables map[string]string, jar *c
 	return accum, errors.AsError()
 }
 
+// parallelOutcome is the result of a suite executed by executeParallel.
+type parallelOutcome struct {
+	suite *suite.Suite
+	log   *bytes.Buffer // the log output produced during execution
+}
+
+// executeParallel starts executing the suites in order with up to
+// parallelSuites suites running concurrently. Suites with mocks run
+// exclusively. The outcome of the i'th suite is delivered on the i'th
+// channel once the suite is done.
+func executeParallel(suites []*suite.RawSuite, variables map[string]string, jar *cookiejar.Jar) []chan parallelOutcome {
+	outcomes := make([]chan parallelOutcome, len(suites))
+	for i := range outcomes {
+		outcomes[i] = make(chan parallelOutcome, 1)
+	}
+
+	semaphore := make(chan bool, parallelSuites)
+	var exclusive sync.RWMutex
+	go func() {
+		for i, s := range suites {
+			semaphore <- true
+			lock, unlock := exclusive.RLock, exclusive.RUnlock
+			if s.HasMocks() {
+				lock, unlock = exclusive.Lock, exclusive.Unlock
+			}
+			lock()
+			go func(i int, s *suite.RawSuite) {
+				defer func() { <-semaphore }()
+				defer unlock()
+				// Each suite logs to its own buffer to keep the
+				// output of concurrent suites apart.
+				buf := &bytes.Buffer{}
+				logger := log.New(buf, "", 0)
+				if !ssilent {
+					logger.Println("Starting Suite", i+1, s.Name, s.File.Name)
+				}
+				outcome := s.Execute(variables, jar, logger)
+				outcomes[i] <- parallelOutcome{suite: outcome, log: buf}
+			}(i, s)
+		}
+	}()
+
+	return outcomes
+}
+
 // ----------------------------------------------------------------------------
 // Reporting functions
 
 // saveSingle takes care of dumping the suite s into a subfolder of
 // outputdir. It will produce:
 //     _Report_.html  with accomaning files for the response bodies
+//     _Report_.har   the requests and responses as a HTTP Archive
 //     junit-report.xml
 //     result.txt
 //     variables.json
@@ -0,0 +1,74 @@
+// Copyright 2018 Volker Dobler.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/vdobler/ht/ht"
+	"github.com/vdobler/ht/suite"
+)
+
+func TestExecuteParallelMocks(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := l.Addr().(*net.TCPAddr).Port
+	l.Close()
+
+	// Both suites start a mock on the same port and keep it running
+	// for a while.
+	txt := fmt.Sprintf(`
+# mock.suite
+{
+    Name: "Suite with mock"
+    Main: [
+        { File: "test.ht", Mocks: [ "hello.mock" ] }
+    ]
+}
+
+# test.ht
+{
+    Name: "Call mock"
+    Request: { URL: "http://127.0.0.1:%d/hello" }
+    Execution: { PostSleep: "200ms" }
+    Checks: [ {Check: "Body", Equals: "Hello"} ]
+}
+
+# hello.mock
+{
+    Name: "Hello mock"
+    Method: GET
+    URL: "http://127.0.0.1:%d/hello"
+    Response: { StatusCode: 200, Body: "Hello" }
+}`, port, port)
+	fs, err := suite.NewFileSystem(txt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var suites []*suite.RawSuite
+	for i := 0; i < 2; i++ {
+		rs, err := suite.LoadRawSuite("mock.suite", fs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !rs.HasMocks() {
+			t.Fatal("Suite has no mocks")
+		}
+		suites = append(suites, rs)
+	}
+
+	defer func(n int) { parallelSuites = n }(parallelSuites)
+	parallelSuites = 2
+	for i, outcome := range executeParallel(suites, nil, nil) {
+		s := (<-outcome).suite
+		if s.Status != ht.Pass {
+			t.Errorf("%d. suite: got %s %v", i, s.Status, s.Error)
+		}
+	}
+}
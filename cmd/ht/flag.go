@@ -33,6 +33,8 @@ var (
 	variablesFile    string          // -Dfile
 	onlyFlag         string          // flag -only
 	skipFlag         string          // flag -skip
+	tagsFlag         string          // flag -tags
+	excludeTagsFlag  string          // flag -exclude-tags
 	verbosity        int             // flag -verbosity
 	outputDir        string          // flag -output
 	randomSeed       int64           // flag -seed
@@ -48,6 +50,7 @@ var (
 	port             string          // flag -port
 	timeout          time.Duration   // flag -timeout
 	showBrowser      bool            // flag -show
+	updateGolden     bool            // flag -update-golden
 )
 
 func addVarsFlags(fs *flag.FlagSet) {
@@ -92,6 +95,11 @@ func addCounterFlag(fs *flag.FlagSet) {
 		"use `num` as start value for COUNTER variables")
 }
 
+func addUpdateGoldenFlag(fs *flag.FlagSet) {
+	fs.BoolVar(&updateGolden, "update-golden", false,
+		"rewrite golden files of Golden checks from the responses")
+}
+
 func addSkiptlsverifyFlag(fs *flag.FlagSet) {
 	fs.BoolVar(&skipTLSVerify, "skiptlsverify", false,
 		"do not verify TLS certificate chain of servers")
@@ -114,6 +122,13 @@ func addSkipFlag(fs *flag.FlagSet) {
 	fs.StringVar(&skipFlag, "skip", "", "skip tests identified by `testID`")
 }
 
+func addTagsFlag(fs *flag.FlagSet) {
+	fs.StringVar(&tagsFlag, "tags", "",
+		"run only Main tests selected by tag expression `expr`")
+	fs.StringVar(&excludeTagsFlag, "exclude-tags", "",
+		"skip Main tests selected by tag expression `expr`")
+}
+
 func addVerbosityFlag(fs *flag.FlagSet) {
 	fs.IntVar(&verbosity, "verbosity", -99, "set verbosity to `level`")
 	fs.BoolVar(&v, "v", false, "increase verbosity by 1")
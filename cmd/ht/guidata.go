@@ -57,6 +57,44 @@ func init() {
 				Doc: "Time checks whether the string is a valid time if parsed with Time as the layout\nstring.\n",
 			}}})
 
+	gui.RegisterType(ht.CORS{}, gui.Typeinfo{
+		Doc: "CORS checks the Cross-Origin Resource Sharing of the test's URL: An OPTIONS\npreflight request with the headers Origin, Access-Control-Request-Method and\nAccess-Control-Request-Headers is sent and the Access-Control-Allow-* headers of\nthe answer are validated.\n\nThe response to the actual request must allow the Origin too and must contain a\n\"Vary: Origin\" header unless all origins are allowed with \"*\". If the test's\nrequest does not send the Origin header the request is repeated with an Origin\nheader.\n",
+		Field: map[string]gui.Fieldinfo{
+			"Credentials": gui.Fieldinfo{
+				Doc: "Credentials requires that credentials (cookies, authorization) are allowed.\n",
+			},
+			"Denied": gui.Fieldinfo{
+				Doc: "Denied inverts the check: The Origin must not be allowed.\n",
+			},
+			"Headers": gui.Fieldinfo{
+				Doc: "Headers are the non-simple request headers announced in the preflight, e.g.\n[\"Authorization\", \"Content-Type\"].\n",
+			},
+			"MaxAge": gui.Fieldinfo{
+				Doc: "MaxAge is the minimal duration the preflight response may be cached as reported\nin the Access-Control-Max-Age header.\n",
+			},
+			"Method": gui.Fieldinfo{
+				Doc: "Method is the method of the actual request announced in the preflight. The zero\nvalue uses the method of the test.\n",
+			},
+			"Origin": gui.Fieldinfo{
+				Doc: "Origin is the origin of the cross-origin request, e.g.\n\"https://app.example.org\".\n",
+			}}})
+
+	gui.RegisterType(ht.CSP{}, gui.Typeinfo{
+		Doc: "CSP checks the Content-Security-Policy of a response. The policies are taken\nfrom the Content-Security-Policy headers and, for HTML documents, from <meta\nhttp-equiv=\"Content-Security-Policy\"> elements. If several policies are present\na resource must be allowed by all of them.\n\nThe zero value checks for the presence of a policy.\n",
+		Field: map[string]gui.Fieldinfo{
+			"AllowOnly": gui.Fieldinfo{
+				Doc: "AllowOnly maps directives to the source expressions allowed in the directive,\ne.g.\n\n    {\"script-src\": [\"'self'\", \"https://cdn.example.org\"]}\n\nAny other source expression (except nonces and hashes) is reported.\n",
+			},
+			"Forbidden": gui.Fieldinfo{
+				Doc: "Forbidden maps directives to source expressions which must not be allowed by the\ndirective, e.g.\n\n    {\"script-src\": [\"'unsafe-inline'\", \"'unsafe-eval'\", \"data:\"]}\n\nFetch directives fall back to default-src if absent.\n",
+			},
+			"Required": gui.Fieldinfo{
+				Doc: "Required lists directives which must be present, e.g. \"default-src\" or\n\"frame-ancestors\".\n",
+			},
+			"Resources": gui.Fieldinfo{
+				Doc: "Resources is a space separated list of tags like in Links.Which:\n\n    'script', 'link', 'img', 'video', 'audio', 'source' or 'iframe'\n\nThe URLs of these resources are checked against the policy and resources a\nbrowser would block are reported. Only link elements with rel stylesheet, icon,\napple-touch-icon or manifest are checked.\n",
+			}}})
+
 	gui.RegisterType(ht.Cache{}, gui.Typeinfo{
 		Doc: "Cache allows to test for HTTP Cache-Control headers. The zero value checks for\nthe existence of a Cache-Control header only. Note that not all combinations are\nsensible.\n",
 		Field: map[string]gui.Fieldinfo{
@@ -76,6 +114,22 @@ func init() {
 				Doc: "Private checks for the \"private\" directive\n",
 			}}})
 
+	gui.RegisterType(ht.ContentEfficiency{}, gui.Typeinfo{
+		Doc: "ContentEfficiency checks how efficient the content is delivered following\nhttps://developers.google.com/web/fundamentals/performance/optimizing-content-efficiency/\nThe following aspects are checked:\n\n    compression    compressible responses (text, JSON, JavaScript, XML,\n                   SVG) are sent gzip or brotli compressed if allowed by\n                   the Accept-Encoding of the request\n    minification   HTML, CSS and JavaScript contain only a small fraction\n                   of comments and superfluous whitespace\n    logo           images with \"logo\" in their filename are SVGs or PNGs\n                   with a palette (PNG-8)\n    image-format   large images are delivered in a modern format like\n                   WebP or AVIF\n\nFailures report an estimate of the bytes which could be saved.\n",
+		Field: map[string]gui.Fieldinfo{
+			"Ignore": gui.Fieldinfo{
+				Doc: "Ignore lists aspects not to check, e.g. \"logo\".\n",
+			},
+			"LargeImage": gui.Fieldinfo{
+				Doc: "LargeImage is the size in bytes from which on images must use a modern format.\nThe zero value means 50kB.\n",
+			},
+			"MaxWaste": gui.Fieldinfo{
+				Doc: "MaxWaste is the maximal fraction of a HTML, CSS or JavaScript body which could\nbe saved by minification. The zero value means 0.1.\n",
+			},
+			"MinCompressSize": gui.Fieldinfo{
+				Doc: "MinCompressSize is the size in bytes from which on compressible responses must\nbe compressed. The zero value means 1024 bytes.\n",
+			}}})
+
 	gui.RegisterType(ht.ContentType{}, gui.Typeinfo{
 		Doc: "ContentType checks the Content-Type header.\n",
 		Field: map[string]gui.Fieldinfo{
@@ -153,6 +207,35 @@ func init() {
 				Doc: "Time checks whether the string is a valid time if parsed with Time as the layout\nstring.\n",
 			}}})
 
+	gui.RegisterType(ht.Golden{}, gui.Typeinfo{
+		Doc: "Golden compares the response body to the content of a golden file. The body may\nbe normalized and volatile parts like timestamps or session IDs can be masked\nbefore comparison. A mismatch is reported as a unified diff between the golden\nfile and the (normalized) body.\n\nIf UpdateGolden is set (e.g. via the -update-golden flag of cmd/ht) the golden\nfile is written from the normalized body and the check passes.\n",
+		Field: map[string]gui.Fieldinfo{
+			"File": gui.Fieldinfo{
+				Doc: "File is the golden file, typically given relative to the test as\n\"{{TEST_DIR}}/some-name.golden\".\n",
+			},
+			"Mask": gui.Fieldinfo{
+				Doc: "Mask lists elements of a JSON body whose values are replaced by \"<masked>\". The\nelements are given in the syntax of the JSON check (e.g. \"data.0.id\"), a \"*\"\nmatches any key or index (e.g. \"items.*.created\"). Mask requires Normalize\n\"json\".\n",
+			},
+			"MaskRegexp": gui.Fieldinfo{
+				Doc: "MaskRegexp lists regular expressions whose matches in the (normalized) body are\nreplaced by \"<masked>\".\n",
+			},
+			"Normalize": gui.Fieldinfo{
+				Doc: "Normalize the body before comparison and before writing the golden file:\n\n    \"\"      compare the body as is\n    \"json\"  pretty print the JSON body with sorted object keys\n",
+			},
+			"Sep": gui.Fieldinfo{
+				Doc: "Sep is the separator in Mask, a zero value is equivalent to \".\".\n",
+			}}})
+
+	gui.RegisterType(ht.GraphQL{}, gui.Typeinfo{
+		Doc: "GraphQL checks the response to a GraphQL request. GraphQL servers typically\nreport errors with a 200 status code in the errors array of the response; the\ncheck fails if this array is not empty. The JSON checks in Data are applied to\nthe data object of the response, i.e. their Elements are relative to data: The\nElement \"order.status\" selects the status of the order in {\"data\": {\"order\":\n{\"status\": \"OPEN\"}}}.\n",
+		Field: map[string]gui.Fieldinfo{
+			"AllowErrors": gui.Fieldinfo{
+				Doc: "AllowErrors does not fail the check on a non-empty errors array, e.g. to check\npartial data or Data of a failed operation.\n",
+			},
+			"Data": gui.Fieldinfo{
+				Doc: "Data are JSON checks applied to the data object.\n",
+			}}})
+
 	gui.RegisterType(ht.HTMLContains{}, gui.Typeinfo{
 		Doc: "HTMLContains checks the text content (and optionally the order) of HTML elements\nselected by a CSS rule.\n\nThe text content found in the HTML document is normalized by roughly the\nfollowing procedure:\n\n    1.  Newlines are inserted around HTML block elements\n        (i.e. any non-inline element)\n    2.  Newlines and tabs are replaced by spaces.\n    3.  Multiple spaces are replaced by one space.\n    4.  Leading and trailing spaces are trimmed of.\n\nAs an example consider the following HTML:\n\n    <html><body>\n      <ul class=\"fancy\"><li>One</li><li>S<strong>econ</strong>d</li>\n         <li> Three </li></ul>\n    </body></html>\n\nThe normalized text selected by a Selector of \"ul.fancy\" would be\n\n    \"One Second Three\"\n",
 		Field: map[string]gui.Fieldinfo{
@@ -222,7 +305,7 @@ func init() {
 			}}})
 
 	gui.RegisterType(ht.JSON{}, gui.Typeinfo{
-		Doc: "JSON allow to check an element in a JSON document against a Condition and to\nvalidate the structur of the document against a schema.\n\nThe element of the JSON document is selected by its \"path\". Example: In the JSON\ndocument\n\n    {\n      \"foo\": 5,\n      \"bar\": [ 1, \"qux\" ,3 ],\n      \"waz\": true,\n      \"maa\": { \"muh\": 3.141, \"mee\": 0 },\n      \"nil\": null\n    }\n\nthe following table shows several element paths and their value:\n\n    foo       5\n    bar       [ 1, \"qux\" ,3 ]\n    bar.0     1\n    bar.1     \"qux\"\n    bar.2     3\n    waz       true\n    maa       { \"muh\": 3.141, \"mee\": 0 }\n    maa.muh   3.141\n    maa.mee   0\n    nil       null\n\nNote that the value for \"bar\" is the raw string and contains the original white\nspace characters as present in the original JSON document.\n\nA schema is an example JSON document with the same structure where each leave\nelement just determines the expected type. The JSON document from above would\nconform to the schema:\n\n    {\n      \"foo\": 0, \"bar\": [0,\"\",1], \"waz\": false,\n      \"maa\": { \"muh\": 0.0, \"mee\": 0 },\n    }\n\nContrary to standard JSON this check allows to distinguish floats from ints with\nthe rule that an integer is a valid value for a float in a schema. So any string\nin a schema forces a string value, any int in a schema forces an integer value,\nany float in a schema forces either an int or a float. Null values in schemas\nact as wildcards: any value (int, bool, float, string or null) is valid. This is\nuseful if you want to skip validation of e.g. the first two array elements.\n\nIt is typically not useful to combine schema validation with checking a\ncondition.\n",
+		Doc: "JSON allow to check an element in a JSON document against a Condition and to\nvalidate the structur of the document against a schema.\n\nThe element of the JSON document is selected by its \"path\". Example: In the JSON\ndocument\n\n    {\n      \"foo\": 5,\n      \"bar\": [ 1, \"qux\" ,3 ],\n      \"waz\": true,\n      \"maa\": { \"muh\": 3.141, \"mee\": 0 },\n      \"nil\": null\n    }\n\nthe following table shows several element paths and their value:\n\n    foo       5\n    bar       [ 1, \"qux\" ,3 ]\n    bar.0     1\n    bar.1     \"qux\"\n    bar.2     3\n    waz       true\n    maa       { \"muh\": 3.141, \"mee\": 0 }\n    maa.muh   3.141\n    maa.mee   0\n    nil       null\n\nNote that the value for \"bar\" is the raw string and contains the original white\nspace characters as present in the original JSON document.\n\nA schema is an example JSON document with the same structure where each leave\nelement just determines the expected type. The JSON document from above would\nconform to the schema:\n\n    {\n      \"foo\": 0, \"bar\": [0,\"\",1], \"waz\": false,\n      \"maa\": { \"muh\": 0.0, \"mee\": 0 },\n    }\n\nContrary to standard JSON this check allows to distinguish floats from ints with\nthe rule that an integer is a valid value for a float in a schema. So any string\nin a schema forces a string value, any int in a schema forces an integer value,\nany float in a schema forces either an int or a float. Null values in schemas\nact as wildcards: any value (int, bool, float, string or null) is valid. This is\nuseful if you want to skip validation of e.g. the first two array elements.\n\nIt is typically not useful to combine schema validation with checking a\ncondition.\n\nThe schema here is not a JSON Schema, use the JSONSchema check to validate\nagainst a real JSON Schema.\n",
 		Field: map[string]gui.Fieldinfo{
 			"Condition": gui.Fieldinfo{
 				Doc: "Condition to apply to the value selected by Element. If Condition is the zero\nvalue then only the existence of a JSON element selected by Element is checked.\nNote that Condition is checked against the actual raw value of the JSON document\nand will contain quotation marks for strings.\n",
@@ -247,6 +330,47 @@ func init() {
 				Doc: "Expression is a boolean gojee expression which must evaluate to true for the\ncheck to pass.\n",
 			}}})
 
+	gui.RegisterType(ht.JSONSchema{}, gui.Typeinfo{
+		Doc: "JSONSchema validates a JSON document (or an element of it) against a JSON Schema\nas described on http://json-schema.org. Draft-07 and 2020-12 schemas are\nsupported including required, enum, const, pattern, format, allOf, anyOf, oneOf,\nnot, if/then/else and local $refs like \"#/definitions/address\" or\n\"#/$defs/address\". Unsupported are unevaluatedProperties, unevaluatedItems and\n$refs to other documents.\n\nThe regular expressions in pattern and patternProperties use Go's RE2 syntax,\nnot ECMA-262: Lookarounds and backreferences like \"^(?!admin)\" are rejected when\npreparing the check.\n\nAll violations are reported, each prefixed with the JSON pointer of the\noffending element, e.g.\n\n    #/items/3/price: got string, want number\n    #/items/5: missing required property \"name\"\n",
+		Field: map[string]gui.Fieldinfo{
+			"Element": gui.Fieldinfo{
+				Doc: "Element in the flattened JSON map to validate, see JSON for details. An empty\nElement validates the whole document.\n",
+			},
+			"Schema": gui.Fieldinfo{
+				Doc: "Schema is the JSON Schema document. It may be given inline or read from a file\nwith the @file: and @vfile: syntax, e.g. \"@file:{{TEST_DIR}}/schema.json\".\n",
+			},
+			"Sep": gui.Fieldinfo{
+				Doc: "Sep is the separator in Element, a zero value is equivalent to \".\".\n",
+			}}})
+
+	gui.RegisterType(ht.JWT{}, gui.Typeinfo{
+		Doc: "JWT checks a JSON Web Token (RFC 7519) received in the response. The token is\ndecoded and its signature verified if a Key or a JWKS is given. Unsigned tokens\n(alg \"none\"), expired tokens and tokens not yet valid due to their nbf claim\nalways fail the check.\n\nConditions on claims and header parameters are applied to the values with\nstrings unquoted; other values like numbers, arrays or objects are checked as\nraw JSON. Nested claims are selected like elements in the JSON check, e.g. the\ncondition {Contains: \"\\\"admin\\\"\"} on the claim \"realm_access.roles\" requires the\nadmin role in a Keycloak token.\n",
+		Field: map[string]gui.Fieldinfo{
+			"Audience": gui.Fieldinfo{
+				Doc: "Audience must be (one of) the aud claim.\n",
+			},
+			"Claims": gui.Fieldinfo{
+				Doc: "Claims are conditions on the claims of the token.\n",
+			},
+			"JWKS": gui.Fieldinfo{
+				Doc: "JWKS is a JSON Web Key Set, typically read from a file like\n\"@file:{{TEST_DIR}}/jwks.json\". The key is selected by the kid header parameter\nof the token.\n",
+			},
+			"JWTSource": gui.Fieldinfo{
+				Doc: "JWTSource determines where the token is found.\n",
+			},
+			"Key": gui.Fieldinfo{
+				Doc: "Key is used to verify the signature: The shared secret for the HS256, HS384 and\nHS512 algorithms or a PEM encoded public key or certificate for RS*, PS* and\nES*. The key may be read from a file with the @file: syntax.\n",
+			},
+			"MinValidity": gui.Fieldinfo{
+				Doc: "MinValidity is the minimum time the token must be valid: Its exp claim must be\nat least this far in the future.\n",
+			},
+			"Scopes": gui.Fieldinfo{
+				Doc: "Scopes must all be contained in the space separated scope claim (or the scp\nclaim).\n",
+			},
+			"TokenHeader": gui.Fieldinfo{
+				Doc: "TokenHeader are conditions on the parameters of the token header like \"alg\" or\n\"kid\".\n",
+			}}})
+
 	gui.RegisterType(ht.Latency{}, gui.Typeinfo{
 		Doc: "Latency provides checks against percentils of the response time latency.\n",
 		Field: map[string]gui.Fieldinfo{
@@ -324,6 +448,16 @@ func init() {
 				Doc: "Of is the list of checks to execute.\n",
 			}}})
 
+	gui.RegisterType(ht.OpenAPI{}, gui.Typeinfo{
+		Doc: "OpenAPI checks that the response conforms to an OpenAPI 3 spec. The operation is\nlooked up by the method and the path of the request (after following redirects):\nIts path must match one of the path templates like \"/pets/{petId}\" and the\nmethod must be described for this path. The received status code must be\ndocumented for this operation (possibly through a range like \"2XX\" or a default\nresponse). Headers declared required for the response must be present and their\nvalues must validate against their schema. A body must have one of the\ndocumented media types and JSON bodies must validate against the schema of this\nmedia type.\n\nAn undocumented operation fails with ErrUndocumentedOperation, an undocumented\nstatus code with ErrUndocumentedStatus.\n",
+		Field: map[string]gui.Fieldinfo{
+			"BasePath": gui.Fieldinfo{
+				Doc: "BasePath is stripped from the request path before looking up the operation. If\nempty the path of the first server URL of the spec is used.\n",
+			},
+			"Spec": gui.Fieldinfo{
+				Doc: "Spec is the OpenAPI 3 document in JSON or YAML. Typically it is read from a file\nwith the @file: syntax, e.g. \"@file:{{TEST_DIR}}/openapi.yaml\".\n",
+			}}})
+
 	gui.RegisterType(ht.Redirect{}, gui.Typeinfo{
 		Doc: "Redirect checks for a singe HTTP redirection.\n\nNote that this check cannot be used on tests with\n\n    Request.FollowRedirects = true\n\nas Redirect checks only the final response which will not be a redirection if\nredirections are followed automatically.\n",
 		Field: map[string]gui.Fieldinfo{
@@ -335,8 +469,11 @@ func init() {
 			}}})
 
 	gui.RegisterType(ht.RedirectChain{}, gui.Typeinfo{
-		Doc: "RedirectChain checks steps in a redirect chain. The check passes if all stations\nin Via have been accessed in order; the actual redirect chain may hit additional\nstations.\n\nNote that this check can be used on tests with\n\n    Request.FollowRedirects = true\n",
+		Doc: "RedirectChain checks steps in a redirect chain. The check passes if all stations\nin Via have been accessed in order; the actual redirect chain may hit additional\nstations. The individual redirect responses of the chain can be checked with\nHops.\n\nNote that this check can be used on tests with\n\n    Request.FollowRedirects = true\n",
 		Field: map[string]gui.Fieldinfo{
+			"Hops": gui.Fieldinfo{
+				Doc: "Hops are checks of individual redirect responses of the chain.\n",
+			},
 			"Via": gui.Fieldinfo{
 				Doc: "Via contains the necessary URLs accessed during a redirect chain.\n\nAny URL may start with, end with or contain three dots \"...\" which indicate a\nsuffix, prefix or suffix+prefix match like in the To field of Redirect.\n",
 			}}})
@@ -418,6 +555,16 @@ func init() {
 				Doc: "IgnoreRegion is a list of regions which are ignored during comparing the actual\nscreenshot to the golden record. The entries are specify rectangles in the form\nof the Geometry (with ignored zoom factor).\n",
 			}}})
 
+	gui.RegisterType(ht.SecurityHeaders{}, gui.Typeinfo{
+		Doc: "SecurityHeaders checks the security related headers of a response against a\npolicy level. The following aspects are checked:\n\n    Strict-Transport-Security  on HTTPS only: max-age of at least\n                               180 days (strict: one year and\n                               includeSubDomains and preload)\n    Content-Security-Policy    present on HTML pages (strict: without\n                               'unsafe-inline' and 'unsafe-eval' for\n                               scripts)\n    X-Content-Type-Options     is \"nosniff\"\n    Referrer-Policy            present and not \"unsafe-url\" (strict:\n                               no-referrer, same-origin, strict-origin\n                               or strict-origin-when-cross-origin)\n    Permissions-Policy         strict only: present\n    X-Frame-Options            HTML pages must not be framable: DENY or\n                               SAMEORIGIN or a CSP frame-ancestors\n    Set-Cookie                 on HTTPS cookies are Secure (strict: and\n                               HttpOnly with a SameSite attribute)\n    JSON                       JSON bodies are sent as application/json\n                               and are objects (no top-level arrays or\n                               primitives)\n\nSee https://httpsecurityreport.com/best_practice.html for background.\n",
+		Field: map[string]gui.Fieldinfo{
+			"Ignore": gui.Fieldinfo{
+				Doc: "Ignore lists aspects not to check, e.g. \"Permissions-Policy\" or \"JSON\". See\nabove for the list of aspects.\n",
+			},
+			"Level": gui.Fieldinfo{
+				Doc: "Level is the policy level, either \"basic\" or \"strict\". The zero value is\nequivalent to \"basic\".\n",
+			}}})
+
 	gui.RegisterType(ht.SetCookie{}, gui.Typeinfo{
 		Doc: "SetCookie checks for cookies being properly set. Note that the Path and Domain\nconditions are checked on the received Path and/or Domain and not on the\ninterpreted values according to RFC 6265.\n",
 		Field: map[string]gui.Fieldinfo{
@@ -427,6 +574,9 @@ func init() {
 			"Domain": gui.Fieldinfo{
 				Doc: "// Domain is applied to the domain value",
 			},
+			"Hop": gui.Fieldinfo{
+				Doc: "Hop selects the redirect response of a followed redirect chain whose cookies are\nchecked (1 being the response to the original request, see Response.Hops). Zero\nchecks the final response.\n",
+			},
 			"MinLifetime": gui.Fieldinfo{
 				Doc: "MinLifetime is the expectetd minimum lifetime of the cookie. A positive value\nenforces a persistent cookie. Negative values are illegal (use DelteCookie\ninstead).\n",
 			},
@@ -460,6 +610,53 @@ func init() {
 				Doc: "Expect is the value to expect, e.g. 302.\n\nIf Expect <= 9 it matches a whole range of status codes, e.g. with Expect==4 any\nof the 4xx status codes would fulfill this check.\n",
 			}}})
 
+	gui.RegisterType(ht.TLS{}, gui.Typeinfo{
+		Doc: "TLS checks the certificate presented by the server and the parameters of the TLS\nhandshake. The connection state of the response is inspected; if it is not\navailable (e.g. for a test loaded from disk) an own handshake with the host of\nthe request URL is made using the Client configuration of the test. Such a\nhandshake cannot be made through a proxy.\n\nThe zero value checks that the response was received over TLS only.\n",
+		Field: map[string]gui.Fieldinfo{
+			"Chain": gui.Fieldinfo{
+				Doc: "Chain requests that the certificate chain sent by the server is complete, i.e.\nthat the certificate can be verified from the intermediates sent by the server\nup to a trusted root.\n",
+			},
+			"ForbiddenCiphers": gui.Fieldinfo{
+				Doc: "ForbiddenCiphers lists cipher suites which must not be negotiated. A cipher\nsuite is forbidden if its name (e.g. \"TLS_RSA_WITH_AES_128_CBC_SHA\") contains\none of the given strings, e.g. \"_CBC_\" or \"RC4\".\n",
+			},
+			"Issuer": gui.Fieldinfo{
+				Doc: "Issuer is applied to the issuer of the certificate formatted like Subject.\n",
+			},
+			"MinDaysValid": gui.Fieldinfo{
+				Doc: "MinDaysValid is the minimum number of days the server certificate must still be\nvalid.\n",
+			},
+			"MinVersion": gui.Fieldinfo{
+				Doc: "MinVersion is the minimum negotiated protocol version, one of \"TLS1.0\",\n\"TLS1.1\", \"TLS1.2\" and \"TLS1.3\".\n",
+			},
+			"Names": gui.Fieldinfo{
+				Doc: "Names lists the host names the certificate must be valid for. Subject\nalternative names (including wildcards) and the common name are considered.\n",
+			},
+			"RootCAs": gui.Fieldinfo{
+				Doc: "RootCAs are additional trusted root certificates in PEM format used when\nverifying the Chain. They may be read from a file with the @file: syntax, e.g.\n\"@file:{{TEST_DIR}}/ca.pem\".\n",
+			},
+			"Subject": gui.Fieldinfo{
+				Doc: "Subject is applied to the subject of the certificate formatted like\n\"CN=www.example.org,O=Example Corp,C=CH\".\n",
+			}}})
+
+	gui.RegisterType(ht.Timing{}, gui.Typeinfo{
+		Doc: "Timing checks the durations of the individual phases of the request as recorded\nin the Timings of the response. A zero limit does not restrict the phase. Phases\nwhich did not happen (e.g. the DNS lookup on a reused connection) pass every\nlimit.\n",
+		Field: map[string]gui.Fieldinfo{
+			"Connect": gui.Fieldinfo{
+				Doc: "Connect is the maximal duration of the TCP connection setup.\n",
+			},
+			"DNS": gui.Fieldinfo{
+				Doc: "DNS is the maximal duration of the DNS lookup.\n",
+			},
+			"TLS": gui.Fieldinfo{
+				Doc: "TLS is the maximal duration of the TLS handshake.\n",
+			},
+			"TTFB": gui.Fieldinfo{
+				Doc: "TTFB is the maximal time to first byte of the response, measured from the start\nof the request.\n",
+			},
+			"Transfer": gui.Fieldinfo{
+				Doc: "Transfer is the maximal duration to read the body after the first byte was\nreceived.\n",
+			}}})
+
 	gui.RegisterType(ht.UTF8Encoded{}, gui.Typeinfo{
 		Doc:   "UTF8Encoded checks that the response body is valid UTF-8 without BOMs.\n",
 		Field: map[string]gui.Fieldinfo{}})
@@ -518,6 +715,13 @@ func init() {
 				Doc: "Selector is the CSS selector of an element, e.g.\n\n    head meta[name=\"_csrf\"]   or\n    form#login input[name=\"tok\"]\n    div.token span\n",
 			}}})
 
+	gui.RegisterType(ht.HeaderExtractor{}, gui.Typeinfo{
+		Doc: "HeaderExtractor extracts the value of a header. The value of the first header\nwith the given name is extracted.\n",
+		Field: map[string]gui.Fieldinfo{
+			"Name": gui.Fieldinfo{
+				Doc: "// Name is the name of the header.",
+			}}})
+
 	gui.RegisterType(ht.JSExtractor{}, gui.Typeinfo{
 		Doc: "JSExtractor extracts arbitrary stuff via custom JavaScript code.\n\nThe current Test is present in the JavaScript VM via binding the name \"Test\" at\ntop-level to the current Test being checked.\n\nThe Script is evaluated and the final expression is the value extracted with the\nfollowing exceptions:\n\n    - undefined or null is treated as an error\n    - Objects and Arrays are treated as errors. The error message is reported\n      in the field 'errmsg' of the object or the index 0 of the array.\n    - Strings, Numbers and Bools are treated as properly extracted values\n      which are returned.\n    - Other types result in undefined behaviour.\n\nThe JavaScript code is interpreted by otto. See the documentation at\nhttps://godoc.org/github.com/robertkrimen/otto for details.\n",
 		Field: map[string]gui.Fieldinfo{
@@ -538,6 +742,16 @@ func init() {
 				Doc: "Sep is the separator in the element path. A zero value is equivalent to \".\"\n",
 			}}})
 
+	gui.RegisterType(ht.JWTExtractor{}, gui.Typeinfo{
+		Doc: "JWTExtractor extracts a claim from a JSON Web Token. The signature is not\nverified, use the JWT check for this.\n",
+		Field: map[string]gui.Fieldinfo{
+			"Claim": gui.Fieldinfo{
+				Doc: "Claim is the (nested) claim to extract, e.g. \"sub\" or \"realm_access.roles.0\".\nStrings are unquoted, other values are extracted as raw JSON. The zero value\nextracts the token itself.\n",
+			},
+			"JWTSource": gui.Fieldinfo{
+				Doc: "JWTSource determines where the token is found.\n",
+			}}})
+
 	gui.RegisterType(ht.SetTimestamp{}, gui.Typeinfo{
 		Doc: "SetTimestamp allows to progmatically extract the current time optionaly offset\nby a certain duration in a user selected layout. To round the extracted\ntimestamp e.g. to multiple of hours use a format like \"2006-01-02 15:00:00\" with\nfixed minutes and seconds.\n\nThe test and the response are ignored.\n",
 		Field: map[string]gui.Fieldinfo{
@@ -597,6 +811,9 @@ func init() {
 			"Result": gui.Fieldinfo{
 				Doc: "Result contains details of a test run. It is filled by the Run method and\nExecuteChecks.\n",
 			},
+			"Tokens": gui.Fieldinfo{
+				Doc: "Tokens caches the OAuth2 access tokens. It is typically shared by all tests of a\nsuite.\n",
+			},
 			"Variables": gui.Fieldinfo{
 				Doc: "Variables contains name/value-pairs used for variable substitution in files read\nin, e.g. for Request.Body = \"@vfile:/path/to/file\".\n",
 			}}})
@@ -604,6 +821,9 @@ func init() {
 	gui.RegisterType(ht.Request{}, gui.Typeinfo{
 		Doc: "Request is a HTTP request.\n",
 		Field: map[string]gui.Fieldinfo{
+			"Auth": gui.Fieldinfo{
+				Doc: "Auth configures other authentication schemes like OAuth2 and request signing.\n",
+			},
 			"BasicAuthPass": gui.Fieldinfo{
 				Doc: "",
 			},
@@ -616,12 +836,18 @@ func init() {
 			"Chunked": gui.Fieldinfo{
 				Doc: "Chunked turns of setting of the Content-Length header resulting in chunked\ntransfer encoding of POST bodies.\n",
 			},
+			"Client": gui.Fieldinfo{
+				Doc: "Client configures the HTTP client used to make this request, e.g. the protocol\nversion, keep-alives or DNS overrides.\n",
+			},
 			"Cookies": gui.Fieldinfo{
 				Doc: "Cookies contains the cookies to send in the request.\n",
 			},
 			"FollowRedirects": gui.Fieldinfo{
 				Doc: "FollowRedirects determines if automatic following of redirects should be done.\n",
 			},
+			"GraphQL": gui.Fieldinfo{
+				Doc: "GraphQL is a GraphQL operation to send. It is sent as a JSON body of a POST\nrequest if Method is empty. Body and body or multipart Params must not be used\ntogether with GraphQL.\n",
+			},
 			"Header": gui.Fieldinfo{
 				Doc: "Header contains the specific http headers to be sent in this request. User-Agent\nand Accept headers are set automaticaly to the global default values if not set\nexplicitly.\n",
 			},
@@ -659,14 +885,29 @@ func init() {
 			"BodyStr": gui.Fieldinfo{
 				Doc: "The received body and the error got while reading it.\n",
 			},
+			"Challenge": gui.Fieldinfo{
+				Doc: "Challenge is the 401 response to the first request carrying the Digest challenge\nwhich was answered by resending the request with credentials (see Auth.Digest).\n",
+			},
+			"DecodedSize": gui.Fieldinfo{
+				Doc: "DecodedSize is the size of the decoded body in BodyStr.\n",
+			},
 			"Duration": gui.Fieldinfo{
 				Doc: "Duration to receive response and read the whole body.\n",
 			},
+			"Hops": gui.Fieldinfo{
+				Doc: "Hops are the redirect responses received while following a redirect chain,\nHops[0] being the response to the original request.\n",
+			},
 			"Redirections": gui.Fieldinfo{
 				Doc: "Redirections records the URLs of automatic GET requests due to redirects.\n",
 			},
 			"Response": gui.Fieldinfo{
 				Doc: "Response is the received HTTP response. Its body has bean read and closed\nalready.\n",
+			},
+			"Timings": gui.Fieldinfo{
+				Doc: "Timings breaks Duration down into the phases of the request.\n",
+			},
+			"WireSize": gui.Fieldinfo{
+				Doc: "WireSize is the number of body bytes received, i.e. before decoding the\nContent-Encoding.\n",
 			}}})
 
 	gui.RegisterType(ht.CheckList{}, gui.Typeinfo{
@@ -0,0 +1,128 @@
+// Copyright 2018 Volker Dobler.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/vdobler/ht/importer"
+)
+
+var cmdImport = &Command{
+	RunArgs:     runImport,
+	Usage:       "import [flags] <format> <input>",
+	Description: "generate tests from foreign formats",
+	Flag:        flag.NewFlagSet("import", flag.ContinueOnError),
+	Help: `Import generates tests and a suite from a foreign description of requests.
+
+The <input> file is read and converted to one test file per request
+plus a suite file executing these tests. An <input> of "-" reads stdin.
+The generated files are written to the directory given by -output
+(default: current directory).
+
+The following formats are recognised:
+
+    openapi   An OpenAPI 3 specification in JSON or YAML. One test is
+              generated for each operation with StatusCode, ContentType
+              and JSONSchema checks derived from the documented 2xx
+              response. Path parameters become test variables, the
+              server URL becomes the suite variable BASE_URL.
+
+    har       A HTTP Archive (HAR 1.2) e.g. exported from the network
+              panel of a browser. One test is generated for each entry
+              with StatusCode and ContentType checks for the recorded
+              response. The origin of the first entry becomes the suite
+              variable BASE_URL.
+
+    curl      One or more curl commands as copied with "Copy as cURL"
+              from the browser. Instead of a file the curl command may
+              be given directly after "--":
+                  ht import curl -- curl -X POST -d 'a=1' http://host/path
+              The options -X, -H, -d, --data-urlencode, -F, -u, -b, -L,
+              -k and --compressed (and some more) are understood.
+
+Executed suites are written as HAR files too: See the _Report_.har file
+next to the _Report_.html file of 'ht exec' and 'ht run'.
+
+The generated tests are skeletons: Review them and fill in variables
+without a sensible default.
+`,
+}
+
+func init() {
+	addOutputFlag(cmdImport.Flag)
+}
+
+// importers maps the format names to the functions doing the import.
+var importers = map[string]func([]byte) (*importer.Collection, error){
+	"openapi": importer.OpenAPI,
+	"har":     importer.HAR,
+	"curl":    importer.Curl,
+}
+
+func runImport(cmd *Command, args []string) {
+	if len(args) > 1 && args[0] == "curl" && (args[1] == "--" || args[1] == "curl") {
+		// The curl command itself given as arguments.
+		if args[1] == "--" {
+			args = args[1:]
+		}
+		collection, err := importer.CurlArgs(args[1:])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Cannot import curl command: %s\n", err)
+			os.Exit(8)
+		}
+		writeImport(collection)
+	}
+
+	if len(args) != 2 {
+		fmt.Fprintln(os.Stderr, "Wrong number of arguments to import")
+		fmt.Fprintf(os.Stderr, "Usage: %s\n", cmd.Usage)
+		os.Exit(9)
+	}
+	convert, ok := importers[args[0]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Unknown import format %q\n", args[0])
+		os.Exit(9)
+	}
+
+	var data []byte
+	var err error
+	if args[1] == "-" {
+		data, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		data, err = ioutil.ReadFile(args[1])
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Cannot read file %q: %s\n", args[1], err)
+		os.Exit(9)
+	}
+	collection, err := convert(data)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Cannot import %q: %s\n", args[1], err)
+		os.Exit(8)
+	}
+	writeImport(collection)
+}
+
+// writeImport writes the imported collection to the output directory
+// and exits.
+func writeImport(collection *importer.Collection) {
+	dir := outputDir
+	if dir == "" {
+		dir = "."
+	}
+	err := collection.Write(dir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Cannot write tests: %s\n", err)
+		os.Exit(8)
+	}
+	fmt.Printf("Wrote %d tests and suite %s to %s\n",
+		len(collection.Tests), collection.SuiteFilename(), dir)
+
+	os.Exit(0)
+}
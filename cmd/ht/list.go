@@ -7,6 +7,7 @@ package main
 import (
 	"flag"
 	"fmt"
+	"strings"
 
 	"github.com/vdobler/ht/ht"
 	"github.com/vdobler/ht/scope"
@@ -20,7 +21,9 @@ var cmdList = &Command{
 	Flag:        flag.NewFlagSet("run", flag.ContinueOnError),
 	Help: `List the tests in a suite.
 
-List recognises the /... syntax described in exec.
+List recognises the /... syntax described in exec. With -tags and
+-exclude-tags only the selected tests are listed, see exec for details.
+The tags of a test are shown in brackets.
 	`,
 }
 
@@ -30,6 +33,7 @@ var (
 
 func init() {
 	addVarsFlags(cmdList.Flag)
+	addTagsFlag(cmdList.Flag)
 	cmdList.Flag.BoolVar(&fullFlag, "full", false,
 		"print more details")
 }
@@ -41,6 +45,9 @@ func runList(cmd *Command, suites []*suite.RawSuite) {
 		stitle := fmt.Sprintf("Suite %d: %s (%s)", sNo+1, s.Name, s.File.Name)
 		fmt.Printf("%s\n", ht.Underline(stitle, "-", ""))
 		for tNo, test := range s.RawTests() {
+			if !test.IsEnabled() {
+				continue
+			}
 			typ := "Main"
 			if tNo < len(s.Setup) {
 				typ = "Setup"
@@ -55,6 +62,9 @@ func runList(cmd *Command, suites []*suite.RawSuite) {
 
 func displayTest(id string, test *suite.RawTest) {
 	fmt.Printf("%-6s %s", id, test.File.Name)
+	if len(test.Tags) > 0 {
+		fmt.Printf(" [%s]", strings.Join(test.Tags, " "))
+	}
 	if fullFlag {
 		ht, err := test.ToTest(scope.Variables(variablesFlag))
 		if err != nil {
@@ -65,6 +65,7 @@ func init() {
 		cmdDoc,
 		cmdExample,
 		cmdRecord,
+		cmdImport,
 		cmdList,
 		cmdQuick,
 		cmdRun,
@@ -256,6 +257,14 @@ func loadSuites(args []string) ([]*suite.RawSuite, error) {
 		}
 	}
 
+	// Disable tests based on the -tags and -exclude-tags flags.
+	for _, s := range suites {
+		err := s.SelectByTags(tagsFlag, excludeTagsFlag)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	// Propagate verbosity from command line to suite/test.
 	for _, s := range suites {
 		setVerbosity(s)
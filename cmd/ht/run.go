@@ -6,6 +6,7 @@ package main
 
 import (
 	"flag"
+	"fmt"
 	"log"
 	"os"
 
@@ -20,13 +21,16 @@ var cmdRun = &Command{
 	Help: `Run a single test.
 
 Run packs the given tests into an autogenerated suite and executes this suite.
-See exec for a more detailed description of suite execution.
+See exec for a more detailed description of suite execution and for the
+selection of tests with -tags and -exclude-tags.
 	`,
 }
 
 func init() {
 	addOutputFlag(cmdRun.Flag)
 	addTestFlags(cmdRun.Flag)
+	addTagsFlag(cmdRun.Flag)
+	addUpdateGoldenFlag(cmdRun.Flag)
 	addShowFlag(cmdRun.Flag)
 }
 
@@ -48,6 +52,12 @@ func runRun(cmd *Command, tests []*suite.RawTest) {
 		os.Exit(3)
 	}
 
+	err = s.SelectByTags(tagsFlag, excludeTagsFlag)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(9)
+	}
+
 	// Propagate verbosity from command line to suite/test.
 	setVerbosity(s)
 
@@ -0,0 +1,317 @@
+// Copyright 2018 Volker Dobler.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// auth.go provides authentication schemes beyond HTTP Basic authentication.
+
+package ht
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Auth configures the authentication of a request beyond HTTP Basic
+// authentication (see Request.BasicAuthUser).
+type Auth struct {
+	// OAuth2 acquires an access token which is sent as a Bearer token
+	// in the Authorization header.
+	OAuth2 *OAuth2 `json:",omitempty"`
+
+	// AWS signs the request with AWS Signature Version 4.
+	AWS *AWSSigV4 `json:",omitempty"`
+
+	// HMAC signs the request with a generic HMAC scheme.
+	HMAC *HMACSigner `json:",omitempty"`
+
+	// Digest answers a 401 Digest challenge with the given credentials.
+	Digest *Digest `json:",omitempty"`
+}
+
+// validate the configuration of a.
+func (a *Auth) validate() error {
+	if a.OAuth2 != nil {
+		if err := a.OAuth2.validate(); err != nil {
+			return fmt.Errorf("bad OAuth2 configuration: %s", err)
+		}
+		if a.AWS != nil {
+			return errors.New("bad Auth configuration: cannot combine OAuth2 and AWS")
+		}
+	}
+	if a.AWS != nil {
+		if err := a.AWS.validate(); err != nil {
+			return fmt.Errorf("bad AWS configuration: %s", err)
+		}
+	}
+	if a.HMAC != nil {
+		if err := a.HMAC.validate(); err != nil {
+			return fmt.Errorf("bad HMAC configuration: %s", err)
+		}
+	}
+	if a.Digest != nil {
+		if err := a.Digest.validate(); err != nil {
+			return fmt.Errorf("bad Digest configuration: %s", err)
+		}
+		if a.OAuth2 != nil || a.AWS != nil {
+			return errors.New("bad Auth configuration: cannot combine Digest with OAuth2 or AWS")
+		}
+	}
+	return nil
+}
+
+// OAuth2 describes how to acquire an OAuth2 access token from a token
+// endpoint. Tokens are cached in the TokenCache of the test (which is
+// shared by all tests of a suite) and re-acquired once they expire.
+// Expired tokens are renewed with the refresh token if the token
+// endpoint issued one.
+type OAuth2 struct {
+	// Flow is the OAuth2 grant type used to acquire the token:
+	//     "client_credentials"  the client authenticates as itself
+	//     "password"            resource owner Username and Password
+	//     "refresh_token"       exchange RefreshToken for a new token
+	Flow string
+
+	// TokenURL is the URL of the token endpoint.
+	TokenURL string
+
+	// ClientID and ClientSecret authenticate the client.
+	ClientID     string `json:",omitempty"`
+	ClientSecret string `json:",omitempty"`
+
+	// ClientAuth determines how the client credentials are sent to
+	// the token endpoint: "basic" (the default) sends them in a HTTP
+	// Basic Authorization header, "body" as form parameters.
+	ClientAuth string `json:",omitempty"`
+
+	// Username and Password are the resource owner credentials used in
+	// the "password" flow.
+	Username string `json:",omitempty"`
+	Password string `json:",omitempty"`
+
+	// RefreshToken is the refresh token used in the "refresh_token" flow.
+	RefreshToken string `json:",omitempty"`
+
+	// Scopes are the requested scopes.
+	Scopes []string `json:",omitempty"`
+
+	// Params are additional parameters sent to the token endpoint,
+	// e.g. "audience" or "resource".
+	Params url.Values `json:",omitempty"`
+}
+
+// tokenExpiryDelta is the time before its expiry at which a token is
+// considered expired to prevent it expiring during the request.
+const tokenExpiryDelta = 10 * time.Second
+
+// oauth2Token is a token issued by a token endpoint.
+type oauth2Token struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int    `json:"expires_in"`
+
+	Expiry time.Time `json:"-"`
+}
+
+// valid reports whether tok can still be used.
+func (tok *oauth2Token) valid() bool {
+	return tok.Expiry.IsZero() || time.Now().Add(tokenExpiryDelta).Before(tok.Expiry)
+}
+
+// TokenCache caches OAuth2 access tokens. It is safe for concurrent use.
+type TokenCache struct {
+	mu     sync.Mutex
+	tokens map[string]*oauth2Token
+}
+
+// NewTokenCache returns an empty token cache.
+func NewTokenCache() *TokenCache {
+	return &TokenCache{tokens: make(map[string]*oauth2Token)}
+}
+
+func (tc *TokenCache) get(key string) *oauth2Token {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	return tc.tokens[key]
+}
+
+func (tc *TokenCache) put(key string, tok *oauth2Token) {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	tc.tokens[key] = tok
+}
+
+// validate the configuration of o.
+func (o *OAuth2) validate() error {
+	if o.TokenURL == "" {
+		return errors.New("missing TokenURL")
+	}
+	switch o.Flow {
+	case "client_credentials":
+	case "password":
+		if o.Username == "" {
+			return errors.New("missing Username for password flow")
+		}
+	case "refresh_token":
+		if o.RefreshToken == "" {
+			return errors.New("missing RefreshToken for refresh_token flow")
+		}
+	default:
+		return fmt.Errorf("unknown OAuth2 flow %q", o.Flow)
+	}
+	switch o.ClientAuth {
+	case "", "basic", "body":
+	default:
+		return fmt.Errorf("unknown ClientAuth %q", o.ClientAuth)
+	}
+	return nil
+}
+
+// authorize adds the authentication configured in t.Request.Auth
+// to the request. Signatures are computed last over the final request
+// and t.Request.SentBody.
+func (t *Test) authorize() error {
+	auth := t.Request.Auth
+	if auth == nil {
+		return nil
+	}
+	req := t.Request.Request
+	if auth.OAuth2 != nil {
+		tok, err := t.oauth2Token(auth.OAuth2)
+		if err != nil {
+			return fmt.Errorf("OAuth2: %s", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+tok.AccessToken)
+	}
+	now := time.Now()
+	if auth.AWS != nil {
+		auth.AWS.sign(req, t.Request.SentBody, now)
+	}
+	if auth.HMAC != nil {
+		auth.HMAC.sign(req, t.Request.SentBody, now)
+	}
+	return nil
+}
+
+// oauth2Token returns a valid token for o, either from the cache or
+// freshly acquired.
+func (t *Test) oauth2Token(o *OAuth2) (*oauth2Token, error) {
+	if t.Tokens == nil {
+		t.Tokens = NewTokenCache()
+	}
+	key, err := json.Marshal(o)
+	if err != nil {
+		return nil, err
+	}
+
+	tok := t.Tokens.get(string(key))
+	if tok != nil && tok.valid() {
+		return tok, nil
+	}
+	if tok != nil && tok.RefreshToken != "" {
+		t.debugf("Refreshing expired OAuth2 token")
+		refresh := *o
+		refresh.Flow, refresh.RefreshToken = "refresh_token", tok.RefreshToken
+		fresh, err := t.requestToken(&refresh)
+		if err == nil {
+			t.Tokens.put(string(key), fresh)
+			return fresh, nil
+		}
+		t.debugf("Refreshing OAuth2 token failed: %s", err)
+	}
+
+	tok, err = t.requestToken(o)
+	if err != nil {
+		return nil, err
+	}
+	t.Tokens.put(string(key), tok)
+	return tok, nil
+}
+
+// requestToken acquires a new token from the token endpoint of o.
+func (t *Test) requestToken(o *OAuth2) (*oauth2Token, error) {
+	t.debugf("Requesting OAuth2 token (%s) from %s", o.Flow, o.TokenURL)
+	form := url.Values{"grant_type": {o.Flow}}
+	switch o.Flow {
+	case "password":
+		form.Set("username", o.Username)
+		form.Set("password", o.Password)
+	case "refresh_token":
+		form.Set("refresh_token", o.RefreshToken)
+	}
+	if len(o.Scopes) > 0 {
+		form.Set("scope", strings.Join(o.Scopes, " "))
+	}
+	for name, values := range o.Params {
+		form[name] = append(form[name], values...)
+	}
+	if o.ClientAuth == "body" {
+		form.Set("client_id", o.ClientID)
+		if o.ClientSecret != "" {
+			form.Set("client_secret", o.ClientSecret)
+		}
+	}
+
+	req, err := http.NewRequest(http.MethodPost, o.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if o.ClientAuth != "body" && o.ClientID != "" {
+		req.SetBasicAuth(url.QueryEscape(o.ClientID), url.QueryEscape(o.ClientSecret))
+	}
+
+	transport, err := t.Request.Client.transport(t.Variables)
+	if err != nil {
+		return nil, err
+	}
+	client := &http.Client{Transport: transport, Timeout: t.Request.Timeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var oe struct {
+			Error       string `json:"error"`
+			Description string `json:"error_description"`
+		}
+		if json.Unmarshal(body, &oe) == nil && oe.Error != "" {
+			return nil, fmt.Errorf("token endpoint returned %s: %s %s",
+				resp.Status, oe.Error, oe.Description)
+		}
+		return nil, fmt.Errorf("token endpoint returned %s", resp.Status)
+	}
+
+	tok := &oauth2Token{}
+	if err := json.Unmarshal(body, tok); err != nil {
+		return nil, fmt.Errorf("bad token response: %s", err)
+	}
+	if tok.AccessToken == "" {
+		return nil, errors.New("token response without access_token")
+	}
+	if tok.TokenType != "" && !strings.EqualFold(tok.TokenType, "bearer") {
+		return nil, fmt.Errorf("unsupported token type %q", tok.TokenType)
+	}
+	if tok.ExpiresIn > 0 {
+		tok.Expiry = time.Now().Add(time.Duration(tok.ExpiresIn) * time.Second)
+	}
+	if tok.RefreshToken == "" && o.Flow == "refresh_token" {
+		// Keep on using the refresh token if no new one was issued.
+		tok.RefreshToken = o.RefreshToken
+	}
+	return tok, nil
+}
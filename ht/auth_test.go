@@ -0,0 +1,179 @@
+// Copyright 2018 Volker Dobler.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ht
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// tokenServer is a OAuth2 token endpoint issuing tokens which expire
+// after expiresIn seconds. Each token is issued after delay.
+type tokenServer struct {
+	issued    int32
+	expiresIn int
+	delay     time.Duration
+}
+
+func (ts *tokenServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	time.Sleep(ts.delay)
+	r.ParseForm()
+	user, pass, _ := r.BasicAuth()
+	if r.Form.Get("client_id") != "" {
+		user, pass = r.Form.Get("client_id"), r.Form.Get("client_secret")
+	}
+	if user != "ht" || pass != "s3cr3t" {
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprint(w, `{"error": "invalid_client"}`)
+		return
+	}
+
+	grant := r.Form.Get("grant_type")
+	switch grant {
+	case "client_credentials":
+	case "password":
+		if r.Form.Get("username") != "john" || r.Form.Get("password") != "doe" {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"error": "invalid_grant", "error_description": "bad password"}`)
+			return
+		}
+	case "refresh_token":
+		if !strings.HasPrefix(r.Form.Get("refresh_token"), "refresh-") {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"error": "invalid_grant"}`)
+			return
+		}
+	}
+
+	n := atomic.AddInt32(&ts.issued, 1)
+	w.Header().Set("Content-Type", "application/json")
+	fmt.Fprintf(w, `{"access_token": "%s-%s-%d", "token_type": "bearer",
+"expires_in": %d, "refresh_token": "refresh-%d"}`,
+		grant, r.Form.Get("scope"), n, ts.expiresIn, n)
+}
+
+func bearerHandler(w http.ResponseWriter, r *http.Request) {
+	w.Write([]byte(r.Header.Get("Authorization")))
+}
+
+func TestOAuth2(t *testing.T) {
+	tokens := &tokenServer{expiresIn: 3600}
+	tokenServer := httptest.NewServer(tokens)
+	defer tokenServer.Close()
+	api := httptest.NewServer(http.HandlerFunc(bearerHandler))
+	defer api.Close()
+
+	for i, tc := range []struct {
+		oauth2 OAuth2
+		want   string
+	}{
+		{OAuth2{Flow: "client_credentials", ClientID: "ht", ClientSecret: "s3cr3t",
+			Scopes: []string{"read", "write"}}, "Bearer client_credentials-read write-1"},
+		{OAuth2{Flow: "client_credentials", ClientID: "ht", ClientSecret: "s3cr3t",
+			ClientAuth: "body"}, "Bearer client_credentials--2"},
+		{OAuth2{Flow: "password", ClientID: "ht", ClientSecret: "s3cr3t",
+			Username: "john", Password: "doe"}, "Bearer password--3"},
+		{OAuth2{Flow: "refresh_token", ClientID: "ht", ClientSecret: "s3cr3t",
+			RefreshToken: "refresh-abc"}, "Bearer refresh_token--4"},
+		{OAuth2{Flow: "client_credentials", ClientID: "ht", ClientSecret: "wrong"},
+			"OAuth2: token endpoint returned 401 Unauthorized: invalid_client "},
+		{OAuth2{Flow: "password", ClientID: "ht", ClientSecret: "s3cr3t",
+			Username: "john", Password: "wrong"},
+			"OAuth2: token endpoint returned 400 Bad Request: invalid_grant bad password"},
+		{OAuth2{Flow: "implicit"}, "bad OAuth2 configuration: unknown OAuth2 flow \"implicit\""},
+	} {
+		tc.oauth2.TokenURL = tokenServer.URL
+		test := &Test{
+			Request: Request{
+				URL:  api.URL,
+				Auth: &Auth{OAuth2: &tc.oauth2},
+			},
+			Checks: CheckList{&Body{Equals: tc.want}},
+		}
+		test.Run()
+		got := test.Result.Status.String()
+		if test.Result.Error != nil && test.Result.Status != Fail {
+			got = test.Result.Error.Error()
+		}
+		if want := tc.want; strings.HasPrefix(want, "Bearer ") {
+			if got != "Pass" {
+				t.Errorf("%d. got %s %v", i, got, test.Result.Error)
+			}
+		} else if got != want {
+			t.Errorf("%d. got %q, want %q", i, got, want)
+		}
+	}
+}
+
+func TestOAuth2TokenCache(t *testing.T) {
+	tokens := &tokenServer{expiresIn: 3600}
+	tokenServer := httptest.NewServer(tokens)
+	defer tokenServer.Close()
+	api := httptest.NewServer(http.HandlerFunc(bearerHandler))
+	defer api.Close()
+
+	cache := NewTokenCache()
+	run := func(want string) {
+		test := &Test{
+			Request: Request{
+				URL: api.URL,
+				Auth: &Auth{OAuth2: &OAuth2{Flow: "client_credentials",
+					TokenURL: tokenServer.URL, ClientID: "ht", ClientSecret: "s3cr3t"}},
+			},
+			Checks: CheckList{&Body{Equals: want}},
+			Tokens: cache,
+		}
+		test.Run()
+		if test.Result.Status != Pass {
+			t.Errorf("Got %s %v", test.Result.Status, test.Result.Error)
+		}
+	}
+
+	// The second test reuses the cached token.
+	run("Bearer client_credentials--1")
+	run("Bearer client_credentials--1")
+	if tokens.issued != 1 {
+		t.Errorf("Issued %d tokens, want 1", tokens.issued)
+	}
+
+	// Expired tokens are refreshed with the refresh token.
+	for _, tok := range cache.tokens {
+		tok.Expiry = tok.Expiry.Add(-3595 * time.Second)
+	}
+	run("Bearer refresh_token--2")
+	run("Bearer refresh_token--2")
+	if tokens.issued != 2 {
+		t.Errorf("Issued %d tokens, want 2", tokens.issued)
+	}
+}
+
+func TestOAuth2NotTimed(t *testing.T) {
+	tokens := &tokenServer{expiresIn: 3600, delay: 300 * time.Millisecond}
+	tokenServer := httptest.NewServer(tokens)
+	defer tokenServer.Close()
+	api := httptest.NewServer(http.HandlerFunc(bearerHandler))
+	defer api.Close()
+
+	test := &Test{
+		Request: Request{
+			URL: api.URL,
+			Auth: &Auth{OAuth2: &OAuth2{Flow: "client_credentials",
+				TokenURL: tokenServer.URL, ClientID: "ht", ClientSecret: "s3cr3t"}},
+		},
+		Checks: CheckList{&Body{Equals: "Bearer client_credentials--1"}},
+	}
+	test.Run()
+	if test.Result.Status != Pass {
+		t.Fatalf("Got %s %v", test.Result.Status, test.Result.Error)
+	}
+	if d := test.Response.Duration; d >= tokens.delay {
+		t.Errorf("Duration %s includes the token request", d)
+	}
+}
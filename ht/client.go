@@ -0,0 +1,345 @@
+// Copyright 2018 Volker Dobler.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// client.go provides configurable and pooled HTTP transports.
+
+package ht
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+// SkipTLSVerify turns off verification of the certificates presented by
+// any server.
+var SkipTLSVerify = false
+
+// ClientConfig configures the HTTP client used to make a request.
+// The zero value is a sensible default: Connections are kept alive and
+// shared between all tests with the same configuration, HTTP/2 is used if
+// the server supports it and proxies are taken from the environment.
+type ClientConfig struct {
+	// DisableKeepAlives uses a new connection for each request.
+	DisableKeepAlives bool `json:",omitempty"`
+
+	// HTTP forces the protocol version: "1.1" or "2". HTTP/2 is spoken
+	// over TLS on https and without TLS (h2c with prior knowledge) on
+	// http URLs. The zero value negotiates HTTP/2 on https and uses
+	// HTTP/1.1 on http.
+	HTTP string `json:",omitempty"`
+
+	// MaxConnsPerHost limits the number of connections per host.
+	// Zero means no limit.
+	MaxConnsPerHost int `json:",omitempty"`
+
+	// LocalAddr is the local IP address outgoing connections are bound to.
+	LocalAddr string `json:",omitempty"`
+
+	// Resolve maps host names to the address to connect to like the
+	// --resolve option of curl. Keys are of the form "host:port" or
+	// just "host" for all ports, values are "ip:port" or just "ip" to
+	// keep the port. The Host header and the TLS server name are not
+	// changed, so this allows to hit individual backend nodes behind a
+	// load balancer, e.g.
+	//     {"www.example.org": "10.2.3.4"}
+	Resolve map[string]string `json:",omitempty"`
+
+	// Proxy is the URL of the proxy to use. The zero value takes the
+	// proxy from the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment
+	// variables, the value "direct" uses no proxy at all.
+	Proxy string `json:",omitempty"`
+
+	// ClientCert and ClientKey are the PEM encoded certificate (chain)
+	// and private key presented to servers requesting a client
+	// certificate (mutual TLS). Both may be read from a file with the
+	// @file: syntax, e.g. "@file:{{TEST_DIR}}/client.pem".
+	ClientCert string `json:",omitempty"`
+	ClientKey  string `json:",omitempty"`
+
+	// PKCS12 is a PKCS#12 archive containing the client certificate and
+	// its private key. It is an alternative to ClientCert and ClientKey
+	// and typically read from a file like "@file:{{TEST_DIR}}/client.p12".
+	// PKCS12Password is the password protecting the archive.
+	PKCS12         string `json:",omitempty"`
+	PKCS12Password string `json:",omitempty"`
+
+	// RootCAs are PEM encoded certificates of the CAs used to verify the
+	// certificates of the servers instead of the system roots. They may
+	// be read from a file with the @file: syntax.
+	RootCAs string `json:",omitempty"`
+}
+
+// merge o into c: Flags are or-ed, limits maxed, strings and resolve
+// entries may not conflict.
+func (c *ClientConfig) merge(o ClientConfig) error {
+	c.DisableKeepAlives = c.DisableKeepAlives || o.DisableKeepAlives
+	if o.MaxConnsPerHost > c.MaxConnsPerHost {
+		c.MaxConnsPerHost = o.MaxConnsPerHost
+	}
+	for _, s := range []struct {
+		m *string
+		s string
+	}{
+		{&c.HTTP, o.HTTP},
+		{&c.LocalAddr, o.LocalAddr},
+		{&c.Proxy, o.Proxy},
+		{&c.ClientCert, o.ClientCert},
+		{&c.ClientKey, o.ClientKey},
+		{&c.PKCS12, o.PKCS12},
+		{&c.PKCS12Password, o.PKCS12Password},
+		{&c.RootCAs, o.RootCAs},
+	} {
+		if s.s == "" {
+			continue
+		}
+		if *s.m != "" && *s.m != s.s {
+			return fmt.Errorf("Cannot merge %q into %q", s.s, *s.m)
+		}
+		*s.m = s.s
+	}
+	for host, addr := range o.Resolve {
+		if c.Resolve == nil {
+			c.Resolve = make(map[string]string)
+		}
+		if a, ok := c.Resolve[host]; ok && a != addr {
+			return fmt.Errorf("Cannot merge resolve %s to %s into %s", host, addr, a)
+		}
+		c.Resolve[host] = addr
+	}
+	return nil
+}
+
+// The pool of transports, one per configuration. Configurations differing
+// only in the content of the files they reference get different transports.
+// Once the pool holds maxTransports transports the least recently used one
+// is evicted.
+var (
+	transportPool   = make(map[string]*pooledTransport)
+	transportClock  uint64 // incremented on each use of the pool
+	transportPoolMu sync.Mutex
+)
+
+type pooledTransport struct {
+	tr   *http.Transport
+	used uint64 // transportClock at the last use
+}
+
+const maxTransports = 64
+
+// transport returns the (shared) transport for configuration c. Files
+// referenced in c are read with FileData and the given variables.
+func (c ClientConfig) transport(variables map[string]string) (*http.Transport, error) {
+	resolved, err := c.resolve(variables)
+	if err != nil {
+		return nil, err
+	}
+	key, err := json.Marshal(struct {
+		ClientConfig
+		PKCS12 []byte // binary, would be mangled as a JSON string
+		Skip   bool
+	}{resolved, []byte(resolved.PKCS12), SkipTLSVerify})
+	if err != nil {
+		return nil, err
+	}
+
+	transportPoolMu.Lock()
+	defer transportPoolMu.Unlock()
+	transportClock++
+	if p, ok := transportPool[string(key)]; ok {
+		p.used = transportClock
+		return p.tr, nil
+	}
+	tr, err := resolved.newTransport()
+	if err != nil {
+		return nil, err
+	}
+	if len(transportPool) >= maxTransports {
+		lru := ""
+		for k, p := range transportPool {
+			if lru == "" || p.used < transportPool[lru].used {
+				lru = k
+			}
+		}
+		// Requests still using the evicted transport complete normally,
+		// only its idle connections are dropped.
+		transportPool[lru].tr.CloseIdleConnections()
+		delete(transportPool, lru)
+	}
+	transportPool[string(key)] = &pooledTransport{tr: tr, used: transportClock}
+	return tr, nil
+}
+
+// resolve returns a copy of c where the certificates and keys are replaced
+// by the content of the files they reference.
+func (c ClientConfig) resolve(variables map[string]string) (ClientConfig, error) {
+	for _, s := range []*string{&c.ClientCert, &c.ClientKey, &c.PKCS12, &c.RootCAs} {
+		if *s == "" {
+			continue
+		}
+		data, _, err := FileData(*s, variables)
+		if err != nil {
+			return c, err
+		}
+		*s = data
+	}
+	return c, nil
+}
+
+// newTransport creates a transport for c which must have been resolved.
+func (c ClientConfig) newTransport() (*http.Transport, error) {
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}
+	if c.LocalAddr != "" {
+		ip := net.ParseIP(c.LocalAddr)
+		if ip == nil {
+			return nil, fmt.Errorf("bad local address %q", c.LocalAddr)
+		}
+		dialer.LocalAddr = &net.TCPAddr{IP: ip}
+	}
+	for host, addr := range c.Resolve {
+		if _, err := resolvedAddr(addr, "0"); err != nil {
+			return nil, fmt.Errorf("bad resolve address %q for %s", addr, host)
+		}
+	}
+	tlsConfig, err := c.tlsConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	tr := &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           c.dialContext(dialer),
+		TLSClientConfig:       tlsConfig,
+		ForceAttemptHTTP2:     true,
+		DisableKeepAlives:     c.DisableKeepAlives,
+		MaxIdleConns:          100,
+		MaxConnsPerHost:       c.MaxConnsPerHost,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+
+	switch c.Proxy {
+	case "":
+	case "direct":
+		tr.Proxy = nil
+	default:
+		u, err := url.Parse(c.Proxy)
+		if err != nil {
+			return nil, err
+		}
+		tr.Proxy = http.ProxyURL(u)
+	}
+
+	switch c.HTTP {
+	case "":
+	case "1.1":
+		tr.Protocols = new(http.Protocols)
+		tr.Protocols.SetHTTP1(true)
+	case "2":
+		tr.Protocols = new(http.Protocols)
+		tr.Protocols.SetHTTP2(true)
+		tr.Protocols.SetUnencryptedHTTP2(true)
+	default:
+		return nil, fmt.Errorf("unknown HTTP version %q", c.HTTP)
+	}
+
+	return tr, nil
+}
+
+// tlsConfig returns the TLS configuration with the client certificate and
+// the root CAs of the resolved c.
+func (c ClientConfig) tlsConfig() (*tls.Config, error) {
+	config := &tls.Config{
+		InsecureSkipVerify: SkipTLSVerify,
+	}
+
+	switch {
+	case c.PKCS12 != "":
+		if c.ClientCert != "" || c.ClientKey != "" {
+			return nil, errors.New("PKCS12 cannot be combined with ClientCert and ClientKey")
+		}
+		key, cert, chain, err := pkcs12.DecodeChain([]byte(c.PKCS12), c.PKCS12Password)
+		if err != nil {
+			return nil, fmt.Errorf("bad PKCS12: %s", err)
+		}
+		tc := tls.Certificate{
+			Certificate: [][]byte{cert.Raw},
+			PrivateKey:  key,
+			Leaf:        cert,
+		}
+		for _, ca := range chain {
+			tc.Certificate = append(tc.Certificate, ca.Raw)
+		}
+		config.Certificates = []tls.Certificate{tc}
+	case c.ClientCert != "" || c.ClientKey != "":
+		tc, err := tls.X509KeyPair([]byte(c.ClientCert), []byte(c.ClientKey))
+		if err != nil {
+			return nil, fmt.Errorf("bad client certificate: %s", err)
+		}
+		config.Certificates = []tls.Certificate{tc}
+	}
+
+	if c.RootCAs != "" {
+		config.RootCAs = x509.NewCertPool()
+		if !config.RootCAs.AppendCertsFromPEM([]byte(c.RootCAs)) {
+			return nil, errors.New("no certificate found in RootCAs")
+		}
+	}
+
+	return config, nil
+}
+
+// dialContext dials with dialer honouring c.Resolve.
+func (c ClientConfig) dialContext(dialer *net.Dialer) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	if len(c.Resolve) == 0 {
+		return dialer.DialContext
+	}
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, err
+		}
+		to, ok := c.Resolve[addr]
+		if !ok {
+			to, ok = c.Resolve[host]
+		}
+		if ok {
+			addr, err = resolvedAddr(to, port)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return dialer.DialContext(ctx, network, addr)
+	}
+}
+
+// resolvedAddr returns the "ip:port" address for to which is either an
+// IP or an "ip:port" combination.
+func resolvedAddr(to string, port string) (string, error) {
+	if ip := net.ParseIP(to); ip != nil {
+		return net.JoinHostPort(to, port), nil
+	}
+	host, port, err := net.SplitHostPort(to)
+	if err != nil {
+		return "", err
+	}
+	if net.ParseIP(host) == nil {
+		return "", fmt.Errorf("%q is not an IP address", host)
+	}
+	return net.JoinHostPort(host, port), nil
+}
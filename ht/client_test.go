@@ -0,0 +1,273 @@
+// Copyright 2018 Volker Dobler.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ht
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+func protoHandler(w http.ResponseWriter, r *http.Request) {
+	info := fmt.Sprintf("%s %s %s", r.Proto, r.Host, r.Header.Get("Connection"))
+	w.Write([]byte(strings.TrimSpace(info)))
+}
+
+func TestClientConfig(t *testing.T) {
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(protoHandler))
+	ts.EnableHTTP2 = true
+	ts.StartTLS()
+	defer ts.Close()
+	SkipTLSVerify = true
+	defer func() { SkipTLSVerify = false }()
+
+	tsu, _ := url.Parse(ts.URL)
+	port := tsu.Port()
+
+	for i, tc := range []struct {
+		url    string
+		client ClientConfig
+		want   string
+	}{
+		{ts.URL, ClientConfig{}, "HTTP/2.0 127.0.0.1:" + port},
+		{ts.URL, ClientConfig{HTTP: "2"}, "HTTP/2.0 127.0.0.1:" + port},
+		{ts.URL, ClientConfig{HTTP: "1.1"}, "HTTP/1.1 127.0.0.1:" + port},
+		{ts.URL, ClientConfig{HTTP: "1.1", DisableKeepAlives: true},
+			"HTTP/1.1 127.0.0.1:" + port + " close"},
+		{ts.URL, ClientConfig{LocalAddr: "127.0.0.1"}, "HTTP/2.0 127.0.0.1:" + port},
+		{"https://node1.example.org:" + port,
+			ClientConfig{Resolve: map[string]string{"node1.example.org": "127.0.0.1"}},
+			"HTTP/2.0 node1.example.org:" + port},
+		{"https://node2.example.org",
+			ClientConfig{Resolve: map[string]string{"node2.example.org:443": tsu.Host}},
+			"HTTP/2.0 node2.example.org"},
+	} {
+		test := &Test{
+			Request: Request{URL: tc.url, Client: tc.client},
+			Checks:  CheckList{&Body{Equals: tc.want}},
+		}
+		test.Run()
+		if test.Result.Status != Pass {
+			t.Errorf("%d. %s: %s", i, test.Result.Status, test.Result.Error)
+		}
+	}
+}
+
+func TestClientConfigProxy(t *testing.T) {
+	proxy := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, "Proxied %s", r.URL)
+		}))
+	defer proxy.Close()
+
+	test := &Test{
+		Request: Request{
+			URL:    "http://www.example.org/foo",
+			Client: ClientConfig{Proxy: proxy.URL},
+		},
+		Checks: CheckList{&Body{Equals: "Proxied http://www.example.org/foo"}},
+	}
+	test.Run()
+	if test.Result.Status != Pass {
+		t.Errorf("%s: %s", test.Result.Status, test.Result.Error)
+	}
+}
+
+func TestClientConfigErrors(t *testing.T) {
+	for i, tc := range []struct {
+		client ClientConfig
+		want   string
+	}{
+		{ClientConfig{HTTP: "3"}, `unknown HTTP version "3"`},
+		{ClientConfig{LocalAddr: "localhost"}, `bad local address "localhost"`},
+		{ClientConfig{Resolve: map[string]string{"example.org": "example.net"}},
+			`bad resolve address "example.net" for example.org`},
+	} {
+		test := &Test{
+			Request: Request{URL: "http://www.example.org", Client: tc.client},
+		}
+		test.Run()
+		if test.Result.Status != Bogus || test.Result.Error == nil ||
+			!strings.HasSuffix(test.Result.Error.Error(), tc.want) {
+			t.Errorf("%d. Got %s %v", i, test.Result.Status, test.Result.Error)
+		}
+	}
+}
+
+func TestMergeClientConfig(t *testing.T) {
+	a := &Test{Request: Request{Client: ClientConfig{HTTP: "1.1",
+		Resolve: map[string]string{"a": "1.2.3.4"}}}}
+	b := &Test{Request: Request{Client: ClientConfig{MaxConnsPerHost: 4,
+		Resolve: map[string]string{"b": "5.6.7.8"}}}}
+	m, err := Merge(a, b)
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	if c := m.Request.Client; c.HTTP != "1.1" || c.MaxConnsPerHost != 4 ||
+		len(c.Resolve) != 2 {
+		t.Errorf("Got %+v", c)
+	}
+
+	b.Request.Client.HTTP = "2"
+	if _, err := Merge(a, b); err == nil {
+		t.Errorf("Missing error merging conflicting HTTP versions")
+	}
+}
+
+// testCert creates a certificate for name signed by parent (self-signed if
+// nil) and returns it with its key.
+func testCert(t *testing.T, name string, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth,
+			x509.ExtKeyUsageClientAuth},
+		IPAddresses: []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	if parent == nil {
+		template.IsCA, template.BasicConstraintsValid = true, true
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert, key
+}
+
+func certPEM(cert *x509.Certificate) string {
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}))
+}
+
+func TestClientCertificates(t *testing.T) {
+	ca, caKey := testCert(t, "Test CA", nil, nil)
+	server, serverKey := testCert(t, "127.0.0.1", ca, caKey)
+	client, clientKey := testCert(t, "Test Client", ca, caKey)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(ca)
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, "Hello %s", r.TLS.PeerCertificates[0].Subject.CommonName)
+		}))
+	ts.TLS = &tls.Config{
+		Certificates: []tls.Certificate{{
+			Certificate: [][]byte{server.Raw},
+			PrivateKey:  serverKey,
+		}},
+		ClientAuth: tls.RequireAndVerifyClientCert,
+		ClientCAs:  pool,
+	}
+	ts.StartTLS()
+	defer ts.Close()
+
+	der, _ := x509.MarshalECPrivateKey(clientKey)
+	keyPEM := string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}))
+	p12, err := pkcs12.Modern.Encode(clientKey, client, []*x509.Certificate{ca}, "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, tc := range []struct {
+		client ClientConfig
+		want   Status
+	}{
+		{ClientConfig{RootCAs: certPEM(ca), ClientCert: certPEM(client),
+			ClientKey: keyPEM}, Pass},
+		{ClientConfig{RootCAs: certPEM(ca), PKCS12: "@file:@client.p12:" + string(p12),
+			PKCS12Password: "secret"}, Pass},
+		{ClientConfig{RootCAs: certPEM(ca)}, Error},                           // no client cert
+		{ClientConfig{ClientCert: certPEM(client), ClientKey: keyPEM}, Error}, // unknown CA
+		{ClientConfig{RootCAs: certPEM(ca), PKCS12: string(p12),
+			PKCS12Password: "wrong"}, Bogus},
+		{ClientConfig{ClientCert: certPEM(client), ClientKey: certPEM(ca)}, Bogus},
+		{ClientConfig{RootCAs: "no pem"}, Bogus},
+	} {
+		test := &Test{
+			Request: Request{URL: ts.URL, Client: tc.client},
+			Checks:  CheckList{&Body{Equals: "Hello Test Client"}},
+		}
+		test.Run()
+		if test.Result.Status != tc.want {
+			t.Errorf("%d. Got %s %v, want %s", i, test.Result.Status,
+				test.Result.Error, tc.want)
+		}
+	}
+}
+
+func TestTransportPool(t *testing.T) {
+	ca1, _ := testCert(t, "CA 1", nil, nil)
+	ca2, _ := testCert(t, "CA 2", nil, nil)
+	dir, err := ioutil.TempDir("", "transport-pool")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "ca.pem")
+	if err := ioutil.WriteFile(file, []byte("{{CA}}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	c := ClientConfig{RootCAs: "@vfile:" + file}
+	transport := func(ca *x509.Certificate) *http.Transport {
+		tr, err := c.transport(map[string]string{"CA": certPEM(ca)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tr
+	}
+	tr1, tr2 := transport(ca1), transport(ca2)
+	if tr1 == tr2 {
+		t.Errorf("Same transport for different RootCAs")
+	}
+	if transport(ca1) != tr1 {
+		t.Errorf("Transport not reused")
+	}
+
+	// Only the least recently used transport is evicted.
+	for i := 1; i <= maxTransports+1; i++ {
+		if _, err := (ClientConfig{MaxConnsPerHost: i}).transport(nil); err != nil {
+			t.Fatal(err)
+		}
+		transport(ca1)
+	}
+	transportPoolMu.Lock()
+	n := len(transportPool)
+	transportPoolMu.Unlock()
+	if n != maxTransports {
+		t.Errorf("Pool holds %d transports", n)
+	}
+	if transport(ca1) != tr1 {
+		t.Errorf("Recently used transport evicted")
+	}
+}
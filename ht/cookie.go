@@ -56,12 +56,25 @@ type SetCookie struct {
 	//   - "httpOnly": not accesible from JavaScript
 	//   - "exposed": accesible from JavaScript, Flash, etc.
 	Type string `json:",omitempty"`
+
+	// Hop selects the redirect response of a followed redirect chain
+	// whose cookies are checked (1 being the response to the original
+	// request, see Response.Hops). Zero checks the final response.
+	Hop int `json:",omitempty"`
 }
 
 // Execute implements Check's Execute method.
 func (c SetCookie) Execute(t *Test) error {
+	cookies := t.Response.Response.Cookies()
+	if c.Hop > 0 {
+		if c.Hop > len(t.Response.Hops) {
+			return fmt.Errorf("No hop %d, got only %d hops", c.Hop, len(t.Response.Hops))
+		}
+		cookies = t.Response.Hops[c.Hop-1].Cookies()
+	}
+
 	var cookie *http.Cookie
-	for _, cp := range t.Response.Response.Cookies() {
+	for _, cp := range cookies {
 		if cp.Name == c.Name {
 			cookie = cp
 			break
@@ -151,6 +164,9 @@ func (c *SetCookie) Prepare(*Test) error {
 	if c.MinLifetime < 0 {
 		return fmt.Errorf("illegal negative MinLifetime")
 	}
+	if c.Hop < 0 {
+		return fmt.Errorf("illegal negative Hop")
+	}
 
 	c.Type = strings.ToLower(c.Type)
 	x := strings.Replace(c.Type, ",", " ", -1)
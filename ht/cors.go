@@ -0,0 +1,251 @@
+// Copyright 2018 Volker Dobler.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// cors.go provides a check of Cross-Origin Resource Sharing.
+
+package ht
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vdobler/ht/errorlist"
+)
+
+func init() {
+	RegisterCheck(&CORS{})
+}
+
+var (
+	errMissingOrigin   = errors.New("missing Origin")
+	errPreflightFailed = errors.New("preflight request failed")
+)
+
+// ----------------------------------------------------------------------------
+// CORS
+
+// CORS checks the Cross-Origin Resource Sharing of the test's URL: An
+// OPTIONS preflight request with the headers Origin,
+// Access-Control-Request-Method and Access-Control-Request-Headers is sent
+// and the Access-Control-Allow-* headers of the answer are validated.
+//
+// The response to the actual request must allow the Origin too and must
+// contain a "Vary: Origin" header unless all origins are allowed with "*".
+// If the test's request does not send the Origin header the request is
+// repeated with an Origin header.
+type CORS struct {
+	// Origin is the origin of the cross-origin request,
+	// e.g. "https://app.example.org".
+	Origin string
+
+	// Method is the method of the actual request announced in the
+	// preflight. The zero value uses the method of the test.
+	Method string `json:",omitempty"`
+
+	// Headers are the non-simple request headers announced in the
+	// preflight, e.g. ["Authorization", "Content-Type"].
+	Headers []string `json:",omitempty"`
+
+	// Credentials requires that credentials (cookies, authorization)
+	// are allowed.
+	Credentials bool `json:",omitempty"`
+
+	// MaxAge is the minimal duration the preflight response may be
+	// cached as reported in the Access-Control-Max-Age header.
+	MaxAge time.Duration `json:",omitempty"`
+
+	// Denied inverts the check: The Origin must not be allowed.
+	Denied bool `json:",omitempty"`
+}
+
+// Prepare implements Check's Prepare method.
+func (c *CORS) Prepare(*Test) error {
+	if c.Origin == "" {
+		return MalformedCheck{errMissingOrigin}
+	}
+	return nil
+}
+
+var _ Preparable = &CORS{}
+
+// Execute implements Check's Execute method.
+func (c *CORS) Execute(t *Test) error {
+	method := c.Method
+	if method == "" {
+		method = t.Request.Method
+	}
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	// The preflight request.
+	preflight, err := Merge(t) // Preflight is a copy of the original t.
+	if err != nil {
+		return err
+	}
+	preflight.Name = "CORS preflight"
+	preflight.Request.Method = http.MethodOptions
+	preflight.Request.Params = nil
+	preflight.Request.Body = ""
+	preflight.Request.GraphQL = nil
+	// Browsers never send credentials with a preflight.
+	preflight.Request.Header.Del("Authorization")
+	preflight.Request.Header.Del("Cookie")
+	preflight.Request.Cookies = nil
+	preflight.Request.BasicAuthUser = ""
+	preflight.Request.BasicAuthPass = ""
+	preflight.Request.Auth = nil
+	preflight.Request.Header.Set("Origin", c.Origin)
+	preflight.Request.Header.Set("Access-Control-Request-Method", method)
+	if len(c.Headers) > 0 {
+		preflight.Request.Header.Set("Access-Control-Request-Headers",
+			strings.ToLower(strings.Join(c.Headers, ",")))
+	}
+	preflight.Checks = nil
+	preflight.Run()
+	if preflight.Result.Status != Pass {
+		return fmt.Errorf("%s: %s", errPreflightFailed, preflight.Result.Error)
+	}
+
+	// The response to the actual request.
+	actual := t.Response.Response
+	if t.Request.Request == nil || t.Request.Request.Header.Get("Origin") != c.Origin {
+		second, err := Merge(t) // Second is a copy of the original t.
+		if err != nil {
+			return err
+		}
+		second.Request.Header.Set("Origin", c.Origin)
+		second.Checks = nil
+		second.Run()
+		if second.Result.Status != Pass {
+			return second.Result.Error
+		}
+		actual = second.Response.Response
+	}
+
+	if c.Denied {
+		if c.allowsOrigin(preflight.Response.Response.Header) == nil {
+			return fmt.Errorf("preflight allows origin %s", c.Origin)
+		}
+		if c.allowsOrigin(actual.Header) == nil {
+			return fmt.Errorf("response allows origin %s", c.Origin)
+		}
+		return nil
+	}
+
+	errs := errorlist.List{}
+	for _, err := range c.checkPreflight(preflight.Response.Response, method) {
+		errs = append(errs, fmt.Errorf("preflight: %s", err))
+	}
+	for _, err := range c.checkActual(actual.Header) {
+		errs = append(errs, fmt.Errorf("response: %s", err))
+	}
+	return errs.AsError()
+}
+
+// checkPreflight validates the response to the preflight request.
+func (c *CORS) checkPreflight(resp *http.Response, method string) []error {
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return []error{fmt.Errorf("got status %d", resp.StatusCode)}
+	}
+
+	header := resp.Header
+	errs := []error{}
+	if err := c.allowsOrigin(header); err != nil {
+		errs = append(errs, err)
+	}
+	if err := c.allowsCredentials(header); err != nil {
+		errs = append(errs, err)
+	}
+
+	methods := headerList(header, "Access-Control-Allow-Methods")
+	if !corsSafelisted(method) && !methods[method] && (c.Credentials || !methods["*"]) {
+		errs = append(errs, fmt.Errorf("method %s not allowed", method))
+	}
+
+	allowed := headerList(header, "Access-Control-Allow-Headers")
+	for _, h := range c.Headers {
+		if !allowed[strings.ToLower(h)] && (c.Credentials || !allowed["*"]) {
+			errs = append(errs, fmt.Errorf("header %s not allowed", h))
+		}
+	}
+
+	if c.MaxAge > 0 {
+		seconds, err := strconv.Atoi(header.Get("Access-Control-Max-Age"))
+		if got := time.Duration(seconds) * time.Second; err != nil || got < c.MaxAge {
+			errs = append(errs, fmt.Errorf("max age %s, want at least %s", got, c.MaxAge))
+		}
+	}
+
+	return errs
+}
+
+// checkActual validates the response to the actual request.
+func (c *CORS) checkActual(header http.Header) []error {
+	errs := []error{}
+	if err := c.allowsOrigin(header); err != nil {
+		errs = append(errs, err)
+	}
+	if err := c.allowsCredentials(header); err != nil {
+		errs = append(errs, err)
+	}
+	if header.Get("Access-Control-Allow-Origin") != "*" &&
+		!headerList(header, "Vary")["origin"] {
+		errs = append(errs, errors.New("missing Vary: Origin"))
+	}
+	return errs
+}
+
+// allowsOrigin checks the Access-Control-Allow-Origin header.
+func (c *CORS) allowsOrigin(header http.Header) error {
+	switch origin := header.Get("Access-Control-Allow-Origin"); origin {
+	case c.Origin:
+		return nil
+	case "":
+		return errors.New("missing Access-Control-Allow-Origin")
+	case "*":
+		if !c.Credentials {
+			return nil
+		}
+		return errors.New("wildcard origin * not allowed with credentials")
+	default:
+		return fmt.Errorf("origin %s not allowed, got %s", c.Origin, origin)
+	}
+}
+
+// allowsCredentials checks the Access-Control-Allow-Credentials header.
+func (c *CORS) allowsCredentials(header http.Header) error {
+	if c.Credentials && header.Get("Access-Control-Allow-Credentials") != "true" {
+		return errors.New("credentials not allowed")
+	}
+	return nil
+}
+
+// headerList returns the elements of the comma separated list in the
+// header field name. Elements are lowercased except methods.
+func headerList(header http.Header, name string) map[string]bool {
+	list := make(map[string]bool)
+	for _, value := range header[http.CanonicalHeaderKey(name)] {
+		for _, elem := range strings.Split(value, ",") {
+			elem = strings.TrimSpace(elem)
+			if name != "Access-Control-Allow-Methods" {
+				elem = strings.ToLower(elem)
+			}
+			if elem != "" {
+				list[elem] = true
+			}
+		}
+	}
+	return list
+}
+
+// corsSafelisted reports whether method needs not be allowed explicitly.
+func corsSafelisted(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead ||
+		method == http.MethodPost
+}
@@ -0,0 +1,137 @@
+// Copyright 2018 Volker Dobler.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ht
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+var corsTests = []struct {
+	path  string
+	check CORS
+	err   string // errors separated by "|"
+}{
+	{"/okay", CORS{Origin: "https://app.example.org"}, ""},
+	{"/okay", CORS{Origin: "https://app.example.org", Method: "PUT",
+		Headers: []string{"Authorization", "X-Custom"}, Credentials: true,
+		MaxAge: time.Hour}, ""},
+	{"/okay", CORS{Origin: "https://evil.example.com", Denied: true}, ""},
+	{"/okay", CORS{Origin: "https://app.example.org", Denied: true},
+		"preflight allows origin https://app.example.org"},
+	{"/okay", CORS{Origin: "https://evil.example.com"},
+		"preflight: missing Access-Control-Allow-Origin|response: missing Access-Control-Allow-Origin"},
+	{"/okay", CORS{Origin: "https://app.example.org", Method: "DELETE",
+		Headers: []string{"X-Other"}, MaxAge: 2 * time.Hour},
+		"preflight: method DELETE not allowed|preflight: header X-Other not allowed|preflight: max age 1h0m0s, want at least 2h0m0s"},
+	{"/wildcard", CORS{Origin: "https://app.example.org", Method: "DELETE",
+		Headers: []string{"X-Other"}}, ""},
+	{"/wildcard", CORS{Origin: "https://app.example.org", Credentials: true},
+		"preflight: wildcard origin * not allowed with credentials|preflight: credentials not allowed|response: wildcard origin * not allowed with credentials|response: credentials not allowed"},
+	{"/novary", CORS{Origin: "https://app.example.org"},
+		"response: missing Vary: Origin"},
+	{"/nopreflight", CORS{Origin: "https://app.example.org"},
+		"preflight: got status 405"},
+}
+
+func TestCORS(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(corsHandler))
+	defer ts.Close()
+
+	for i, tc := range corsTests {
+		check := tc.check
+		test := Test{
+			Name: "Original Test.",
+			Request: Request{
+				Method: "GET",
+				URL:    ts.URL + tc.path,
+			},
+			Checks: []Check{&check},
+		}
+
+		test.Run()
+		want := ""
+		if tc.err != "" {
+			want = "Check CORS: " + strings.Replace(tc.err, "|", "; \u2029Check CORS: ", -1)
+		}
+		got := ""
+		if test.Result.Error != nil {
+			got = test.Result.Error.Error()
+		}
+
+		if got != want {
+			t.Errorf("%d %s: got error %q, want %q", i, tc.path, got, want)
+		}
+	}
+}
+
+func TestCORSPreflightCredentials(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(corsHandler))
+	defer ts.Close()
+
+	for i, req := range []Request{
+		{Header: http.Header{"Authorization": {"Bearer token"}}},
+		{Header: http.Header{"Cookie": {"session=123"}}},
+		{Cookies: []Cookie{{Name: "session", Value: "123"}}},
+		{BasicAuthUser: "user", BasicAuthPass: "pass"},
+		{Auth: &Auth{AWS: &AWSSigV4{AccessKey: "AKID", SecretKey: "secret",
+			Region: "us-east-1", Service: "execute-api"}}},
+	} {
+		req.URL = ts.URL + "/okay"
+		test := Test{
+			Request: req,
+			Checks:  []Check{&CORS{Origin: "https://app.example.org"}},
+		}
+		test.Run()
+		if test.Result.Status != Pass {
+			t.Errorf("%d. Got %s %s", i, test.Result.Status, test.Result.Error)
+		}
+	}
+}
+
+func corsHandler(w http.ResponseWriter, r *http.Request) {
+	origin := r.Header.Get("Origin")
+	h := w.Header()
+	if r.Method == http.MethodOptions &&
+		(r.Header.Get("Authorization") != "" || r.Header.Get("Cookie") != "") {
+		http.Error(w, "Credentials in preflight", http.StatusBadRequest)
+		return
+	}
+	switch r.URL.Path {
+	case "/okay", "/novary":
+		if origin == "https://app.example.org" {
+			h.Set("Access-Control-Allow-Origin", origin)
+			h.Set("Access-Control-Allow-Credentials", "true")
+		}
+		if r.URL.Path == "/okay" {
+			h.Set("Vary", "Accept-Encoding, Origin")
+		}
+		if r.Method == http.MethodOptions {
+			h.Set("Access-Control-Allow-Methods", "GET, PUT")
+			h.Set("Access-Control-Allow-Headers", "Authorization, X-Custom")
+			h.Set("Access-Control-Max-Age", "3600")
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+	case "/wildcard":
+		h.Set("Access-Control-Allow-Origin", "*")
+		if r.Method == http.MethodOptions {
+			h.Set("Access-Control-Allow-Methods", "*")
+			h.Set("Access-Control-Allow-Headers", "*")
+			return
+		}
+	case "/nopreflight":
+		if r.Method == http.MethodOptions {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		h.Set("Access-Control-Allow-Origin", origin)
+		h.Set("Vary", "Origin")
+	}
+	w.Write([]byte("Hello"))
+}
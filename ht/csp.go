@@ -0,0 +1,493 @@
+// Copyright 2018 Volker Dobler.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// csp.go provides a check of the Content-Security-Policy.
+
+package ht
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/andybalholm/cascadia"
+	"github.com/vdobler/ht/errorlist"
+	"golang.org/x/net/html"
+)
+
+func init() {
+	RegisterCheck(&CSP{})
+}
+
+var errNoCSP = errors.New("no Content-Security-Policy")
+
+// cspDirectives maps the tags of CSP.Resources to the directives governing
+// them, most specific first. The directives for link depend on its rel
+// attribute and are given by cspLinkRels.
+var cspDirectives = map[string][]string{
+	"script": {"script-src", "default-src"},
+	"link":   nil,
+	"img":    {"img-src", "default-src"},
+	"video":  {"media-src", "default-src"},
+	"audio":  {"media-src", "default-src"},
+	"source": {"media-src", "default-src"},
+	"iframe": {"frame-src", "child-src", "default-src"},
+}
+
+// cspLinkRels maps the rel keywords of <link> elements which load a
+// resource to the directives governing them. Links with other rels like
+// canonical, alternate or preconnect fetch nothing and are not checked.
+var cspLinkRels = map[string][]string{
+	"stylesheet":       {"style-src", "default-src"},
+	"icon":             {"img-src", "default-src"},
+	"apple-touch-icon": {"img-src", "default-src"},
+	"manifest":         {"manifest-src", "default-src"},
+}
+
+// cspFallback lists the directives a fetch directive falls back to.
+var cspFallback = map[string][]string{
+	"frame-src":  {"child-src", "default-src"},
+	"worker-src": {"child-src", "script-src", "default-src"},
+}
+
+var cspMetaSel = cascadia.MustCompile("meta[http-equiv]")
+
+// ----------------------------------------------------------------------------
+// CSP
+
+// CSP checks the Content-Security-Policy of a response. The policies are
+// taken from the Content-Security-Policy headers and, for HTML documents,
+// from <meta http-equiv="Content-Security-Policy"> elements. If several
+// policies are present a resource must be allowed by all of them.
+//
+// The zero value checks for the presence of a policy.
+type CSP struct {
+	// Required lists directives which must be present, e.g.
+	// "default-src" or "frame-ancestors".
+	Required []string `json:",omitempty"`
+
+	// Forbidden maps directives to source expressions which must not be
+	// allowed by the directive, e.g.
+	//     {"script-src": ["'unsafe-inline'", "'unsafe-eval'", "data:"]}
+	// Fetch directives fall back to default-src if absent.
+	Forbidden map[string][]string `json:",omitempty"`
+
+	// AllowOnly maps directives to the source expressions allowed in
+	// the directive, e.g.
+	//     {"script-src": ["'self'", "https://cdn.example.org"]}
+	// Any other source expression (except nonces and hashes) is reported.
+	AllowOnly map[string][]string `json:",omitempty"`
+
+	// Resources is a space separated list of tags like in Links.Which:
+	//     'script', 'link', 'img', 'video', 'audio', 'source' or 'iframe'
+	// The URLs of these resources are checked against the policy and
+	// resources a browser would block are reported. Only link elements
+	// with rel stylesheet, icon, apple-touch-icon or manifest are checked.
+	Resources string `json:",omitempty"`
+
+	links []*Links
+}
+
+// Prepare implements Check's Prepare method.
+func (c *CSP) Prepare(t *Test) error {
+	c.links = nil
+	for _, tag := range strings.Fields(c.Resources) {
+		if _, ok := cspDirectives[tag]; !ok {
+			return MalformedCheck{fmt.Errorf("unknown resource tag %q", tag)}
+		}
+		links := &Links{Which: tag}
+		if err := links.Prepare(t); err != nil {
+			return MalformedCheck{err}
+		}
+		c.links = append(c.links, links)
+	}
+	return nil
+}
+
+var _ Preparable = &CSP{}
+
+// Execute implements Check's Execute method.
+func (c *CSP) Execute(t *Test) error {
+	if t.Response.Response == nil {
+		return errors.New("no response available")
+	}
+	policies := []cspPolicy{}
+	for _, h := range t.Response.Response.Header["Content-Security-Policy"] {
+		policies = append(policies, parseCSP(h))
+	}
+	if t.Response.BodyErr == nil {
+		if doc, err := html.Parse(t.Response.Body()); err == nil {
+			for _, meta := range cspMetaSel.MatchAll(doc) {
+				if !strings.EqualFold(attrValue(meta, "http-equiv"), "Content-Security-Policy") {
+					continue
+				}
+				policies = append(policies, parseCSP(attrValue(meta, "content")))
+			}
+		}
+	}
+	if len(policies) == 0 {
+		return errNoCSP
+	}
+
+	errs := errorlist.List{}
+outer:
+	for _, required := range c.Required {
+		for _, p := range policies {
+			if _, ok := p[strings.ToLower(required)]; ok {
+				continue outer
+			}
+		}
+		errs = append(errs, fmt.Errorf("missing directive %s", required))
+	}
+
+	for _, directive := range sortedDirectives(c.Forbidden) {
+		for _, src := range c.Forbidden[directive] {
+			if allowsSource(policies, strings.ToLower(directive), strings.ToLower(src)) {
+				errs = append(errs, fmt.Errorf("%s allows %s", directive, src))
+			}
+		}
+	}
+
+	for _, directive := range sortedDirectives(c.AllowOnly) {
+		if err := allowsOnly(policies, strings.ToLower(directive), c.AllowOnly[directive]); err != nil {
+			errs = append(errs, fmt.Errorf("%s %s", directive, err))
+		}
+	}
+
+	if len(c.links) > 0 {
+		if t.Request.Request == nil {
+			return errors.New("no request available")
+		}
+		self := t.Request.Request.URL
+		for _, links := range c.links {
+			refs, err := cspResources(t, links)
+			if err != nil {
+				return err
+			}
+			tag := links.Which
+			urls := make([]string, 0, len(refs))
+			for ref := range refs {
+				urls = append(urls, ref)
+			}
+			sort.Strings(urls)
+			for _, ref := range urls {
+				u, err := url.Parse(ref)
+				if err != nil {
+					continue
+				}
+				for _, p := range policies {
+					if directive, ok := p.allows(refs[ref], u, self); !ok {
+						errs = append(errs, fmt.Errorf("%s %s blocked by %s", tag, ref, directive))
+						break
+					}
+				}
+			}
+		}
+	}
+
+	return errs.AsError()
+}
+
+// cspResources returns the URLs of the resources loaded via the tag of
+// links together with the directives governing them.
+func cspResources(t *Test, links *Links) (map[string][]string, error) {
+	resources := make(map[string][]string)
+	if links.Which != "link" {
+		refs, err := links.collectURLs(t)
+		if err != nil {
+			return nil, err
+		}
+		for ref := range refs {
+			if !strings.HasPrefix(ref, "Error: ") {
+				resources[ref] = cspDirectives[links.Which]
+			}
+		}
+		return resources, nil
+	}
+
+	if t.Response.BodyErr != nil {
+		return nil, ErrBadBody
+	}
+	doc, err := html.Parse(t.Response.Body())
+	if err != nil {
+		return nil, CantCheck{err}
+	}
+	for _, n := range linkURLattr["link"].sel.MatchAll(doc) {
+		var directives []string
+		for _, rel := range strings.Fields(strings.ToLower(attrValue(n, "rel"))) {
+			if d, ok := cspLinkRels[rel]; ok {
+				directives = d
+				break
+			}
+		}
+		href := attrValue(n, "href")
+		if directives == nil || href == "" {
+			continue
+		}
+		u, err := t.Request.Request.URL.Parse(href)
+		if err != nil {
+			continue
+		}
+		u.Fragment = ""
+		resources[u.String()] = directives
+	}
+	return resources, nil
+}
+
+// attrValue returns the value of the attribute key of node n.
+func attrValue(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// sortedDirectives returns the keys of m in sorted order.
+func sortedDirectives(m map[string][]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// allowsSource reports whether all policies allow the source expression
+// src for directive. A policy without the (effective) directive allows
+// everything.
+func allowsSource(policies []cspPolicy, directive string, src string) bool {
+	for _, p := range policies {
+		sources, ok := p.sources(directive)
+		if !ok {
+			continue
+		}
+		if !p.listed(sources, src) {
+			return false
+		}
+	}
+	return true
+}
+
+// allowsOnly checks that at least one of the policies restricts directive
+// to the allowed source expressions.
+func allowsOnly(policies []cspPolicy, directive string, allowed []string) error {
+	var err error
+	for _, p := range policies {
+		sources, ok := p.sources(directive)
+		if !ok {
+			if err == nil {
+				err = errors.New("not restricted")
+			}
+			continue
+		}
+		extra := []string{}
+	outer:
+		for _, src := range sources {
+			if cspNonceOrHash(src) {
+				continue
+			}
+			for _, a := range allowed {
+				if strings.EqualFold(src, a) {
+					continue outer
+				}
+			}
+			extra = append(extra, src)
+		}
+		if len(extra) == 0 {
+			return nil
+		}
+		err = fmt.Errorf("allows %s", strings.Join(extra, " "))
+	}
+	return err
+}
+
+// ----------------------------------------------------------------------------
+// Policy parsing and source matching
+
+// cspPolicy maps directive names to their source lists.
+type cspPolicy map[string][]string
+
+// parseCSP splits the Content-Security-Policy value into directives and
+// their source lists. Directive names and source expressions are
+// lowercased except nonces and hashes.
+func parseCSP(policy string) cspPolicy {
+	directives := make(cspPolicy)
+	for _, directive := range strings.Split(policy, ";") {
+		fields := strings.Fields(directive)
+		if len(fields) == 0 {
+			continue
+		}
+		name := strings.ToLower(fields[0])
+		if _, seen := directives[name]; seen {
+			continue // Browsers ignore repeated directives.
+		}
+		values := fields[1:]
+		for i, v := range values {
+			if !cspNonceOrHash(v) {
+				values[i] = strings.ToLower(v)
+			}
+		}
+		directives[name] = values
+	}
+	return directives
+}
+
+// cspNonceOrHash reports whether src is a nonce or hash source expression.
+func cspNonceOrHash(src string) bool {
+	src = strings.ToLower(src)
+	return strings.HasPrefix(src, "'nonce-") || strings.HasPrefix(src, "'sha256-") ||
+		strings.HasPrefix(src, "'sha384-") || strings.HasPrefix(src, "'sha512-")
+}
+
+// sources returns the source list effective for directive, honouring the
+// fallback to default-src.
+func (p cspPolicy) sources(directive string) ([]string, bool) {
+	if sources, ok := p[directive]; ok {
+		return sources, true
+	}
+	fallback, ok := cspFallback[directive]
+	if !ok {
+		if !strings.HasSuffix(directive, "-src") {
+			return nil, false // Not a fetch directive.
+		}
+		fallback = []string{"default-src"}
+	}
+	for _, d := range fallback {
+		if sources, ok := p[d]; ok {
+			return sources, true
+		}
+	}
+	return nil, false
+}
+
+// listed reports whether src is (effectively) part of sources. A
+// 'unsafe-inline' is ignored by browsers if nonces or hashes are present.
+func (p cspPolicy) listed(sources []string, src string) bool {
+	found, nonceOrHash := false, false
+	for _, s := range sources {
+		if s == src {
+			found = true
+		}
+		if cspNonceOrHash(s) {
+			nonceOrHash = true
+		}
+	}
+	if src == "'unsafe-inline'" && nonceOrHash {
+		return false
+	}
+	return found
+}
+
+// allows checks whether the resource u governed by directives and loaded
+// from the page self is allowed by p. If not the blocking directive is
+// returned.
+func (p cspPolicy) allows(directives []string, u *url.URL, self *url.URL) (string, bool) {
+	for _, directive := range directives {
+		sources, ok := p[directive]
+		if !ok {
+			continue
+		}
+		for _, src := range sources {
+			if cspMatches(src, u, self) {
+				return directive, true
+			}
+		}
+		return directive, false
+	}
+	return "", true
+}
+
+// cspMatches reports whether the source expression src matches u.
+// This is a simplified version of the matching algorithm of CSP Level 3.
+func cspMatches(src string, u *url.URL, self *url.URL) bool {
+	scheme := strings.ToLower(u.Scheme)
+	switch {
+	case src == "*":
+		return scheme == "http" || scheme == "https" || scheme == "ws" ||
+			scheme == "wss" || scheme == self.Scheme
+	case src == "'self'":
+		return schemeMatches(self.Scheme, scheme) &&
+			strings.EqualFold(u.Hostname(), self.Hostname()) &&
+			effectivePort(u) == effectivePort(self)
+	case strings.HasPrefix(src, "'"):
+		return false // keywords, nonces and hashes
+	case strings.HasSuffix(src, ":") && !strings.Contains(src, "/"):
+		return schemeMatches(strings.TrimSuffix(src, ":"), scheme)
+	}
+
+	// A host-source: [scheme://]host[:port][/path]
+	srcScheme := ""
+	if i := strings.Index(src, "://"); i >= 0 {
+		srcScheme, src = src[:i], src[i+3:]
+	}
+	path := ""
+	if i := strings.Index(src, "/"); i >= 0 {
+		src, path = src[:i], src[i:]
+	}
+	host, port := src, ""
+	if i := strings.LastIndex(src, ":"); i >= 0 {
+		host, port = src[:i], src[i+1:]
+	}
+
+	if srcScheme == "" {
+		srcScheme = self.Scheme
+	}
+	if !schemeMatches(srcScheme, scheme) {
+		return false
+	}
+	uhost := strings.ToLower(u.Hostname())
+	if strings.HasPrefix(host, "*.") {
+		if !strings.HasSuffix(uhost, host[1:]) {
+			return false
+		}
+	} else if host != uhost {
+		return false
+	}
+	switch port {
+	case "*":
+	case "":
+		if effectivePort(u) != defaultPorts[scheme] {
+			return false
+		}
+	default:
+		if port != effectivePort(u) {
+			return false
+		}
+	}
+	if path != "" {
+		if strings.HasSuffix(path, "/") {
+			return strings.HasPrefix(u.Path, path)
+		}
+		return u.Path == path
+	}
+	return true
+}
+
+var defaultPorts = map[string]string{
+	"http":  "80",
+	"https": "443",
+	"ws":    "80",
+	"wss":   "443",
+}
+
+// effectivePort returns the port of u, defaulting to the scheme's port.
+func effectivePort(u *url.URL) string {
+	if p := u.Port(); p != "" {
+		return p
+	}
+	return defaultPorts[strings.ToLower(u.Scheme)]
+}
+
+// schemeMatches reports whether the scheme of a resource matches the
+// scheme of a source expression, allowing upgrades to secure schemes.
+func schemeMatches(srcScheme, scheme string) bool {
+	srcScheme = strings.ToLower(srcScheme)
+	return srcScheme == scheme ||
+		(srcScheme == "http" && scheme == "https") ||
+		(srcScheme == "ws" && scheme == "wss")
+}
@@ -0,0 +1,136 @@
+// Copyright 2018 Volker Dobler.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ht
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/vdobler/ht/errorlist"
+)
+
+var cspMatchesTests = []struct {
+	src, u string
+	want   bool
+}{
+	{"*", "https://cdn.example.org/a.js", true},
+	{"*", "data:image/png;base64,AAAA", false},
+	{"'self'", "https://www.example.org/a.js", true},
+	{"'self'", "https://www.example.org:8443/a.js", false},
+	{"'self'", "https://cdn.example.org/a.js", false},
+	{"'none'", "https://www.example.org/a.js", false},
+	{"https:", "https://evil.example.com/a.js", true},
+	{"data:", "data:image/png;base64,AAAA", true},
+	{"http:", "https://cdn.example.org/a.js", true},
+	{"cdn.example.org", "https://cdn.example.org/a.js", true},
+	{"cdn.example.org", "http://cdn.example.org/a.js", false},
+	{"http://cdn.example.org", "https://cdn.example.org/a.js", true},
+	{"*.example.org", "https://cdn.example.org/a.js", true},
+	{"*.example.org", "https://example.org/a.js", false},
+	{"cdn.example.org:*", "https://cdn.example.org:8080/a.js", true},
+	{"cdn.example.org:8080", "https://cdn.example.org:8080/a.js", true},
+	{"cdn.example.org", "https://cdn.example.org:8080/a.js", false},
+	{"cdn.example.org/js/", "https://cdn.example.org/js/a.js", true},
+	{"cdn.example.org/js/", "https://cdn.example.org/css/a.css", false},
+	{"cdn.example.org/js/a.js", "https://cdn.example.org/js/a.js", true},
+	{"cdn.example.org/js/a.js", "https://cdn.example.org/js/b.js", false},
+}
+
+func TestCSPMatches(t *testing.T) {
+	self, _ := url.Parse("https://www.example.org/index.html")
+	for i, tc := range cspMatchesTests {
+		u, err := url.Parse(tc.u)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := cspMatches(tc.src, u, self); got != tc.want {
+			t.Errorf("%d. cspMatches(%q, %q) = %t, want %t",
+				i, tc.src, tc.u, got, tc.want)
+		}
+	}
+}
+
+const cspBody = `<!doctype html>
+<html><head>
+  <meta http-equiv="Content-Security-Policy" content="img-src 'self' data:">
+  <script src="/js/app.js"></script>
+  <script src="https://cdn.example.org/lib.js"></script>
+  <script src="https://evil.example.com/x.js"></script>
+  <link rel="stylesheet" href="/css/main.css">
+  <link rel="canonical" href="https://www.example.com/index.html">
+  <link rel="icon" href="https://static.example.net/favicon.ico">
+</head><body>
+  <img src="/logo.png">
+  <img src="https://tracker.example.com/pixel.gif">
+</body></html>`
+
+func cspResponse(policy string) Response {
+	header := http.Header{"Content-Type": {"text/html"}}
+	if policy != "" {
+		header.Set("Content-Security-Policy", policy)
+	}
+	return Response{
+		Response: &http.Response{Header: header},
+		BodyStr:  cspBody,
+	}
+}
+
+func TestCSP(t *testing.T) {
+	policy := "default-src 'self'; script-src 'self' https://cdn.example.org 'unsafe-inline' 'nonce-AbC'"
+	for i, tc := range []TC{
+		{cspResponse(policy), &CSP{}, nil},
+		{Response{Response: &http.Response{}}, &CSP{}, errNoCSP},
+		{cspResponse(policy), &CSP{Required: []string{"default-src", "img-src"}}, nil},
+		{cspResponse(policy), &CSP{Required: []string{"frame-ancestors"}},
+			errors.New("missing directive frame-ancestors")},
+		{cspResponse(policy), &CSP{Forbidden: map[string][]string{
+			"script-src": {"'unsafe-inline'", "'unsafe-eval'"}}}, nil}, // nonce
+		{cspResponse("script-src 'unsafe-inline'"), &CSP{Forbidden: map[string][]string{
+			"script-src": {"'unsafe-inline'", "'unsafe-eval'"}}},
+			errors.New("script-src allows 'unsafe-inline'")},
+		{cspResponse("default-src * 'unsafe-eval'"), &CSP{Forbidden: map[string][]string{
+			"script-src": {"'unsafe-eval'"}}},
+			errors.New("script-src allows 'unsafe-eval'")},
+		{cspResponse(policy), &CSP{AllowOnly: map[string][]string{
+			"script-src": {"'self'", "https://cdn.example.org", "'unsafe-inline'"}}}, nil},
+		{cspResponse(policy), &CSP{AllowOnly: map[string][]string{
+			"script-src": {"'self'"}}},
+			errors.New("script-src allows https://cdn.example.org 'unsafe-inline'")},
+		{cspResponse(policy), &CSP{AllowOnly: map[string][]string{
+			"frame-ancestors": {"'none'"}}},
+			errors.New("frame-ancestors not restricted")},
+		{cspResponse(policy), &CSP{Resources: "script img link"},
+			errorlist.List{
+				errors.New("script https://evil.example.com/x.js blocked by script-src"),
+				errors.New("img https://tracker.example.com/pixel.gif blocked by default-src"),
+				errors.New("link https://static.example.net/favicon.ico blocked by default-src"),
+			}},
+		{cspResponse("default-src *; style-src 'self'; img-src 'self'"), &CSP{Resources: "link"},
+			errors.New("link https://static.example.net/favicon.ico blocked by img-src")},
+		{cspResponse(""), &CSP{Resources: "script img"},
+			errors.New("img https://tracker.example.com/pixel.gif blocked by img-src")},
+		{cspResponse(policy), &CSP{Resources: "a"}, errDuringPrepare},
+	} {
+		fakeTest := Test{Response: tc.r}
+		fakeTest.Request.Request, _ = http.NewRequest("GET", "https://www.example.org/index.html", nil)
+		if err := tc.c.(*CSP).Prepare(&fakeTest); err != nil {
+			if tc.e != errDuringPrepare {
+				t.Errorf("%d. Unexpected error during Prepare: %s", i, err)
+			}
+			continue
+		}
+		err := tc.c.Execute(&fakeTest)
+		switch {
+		case tc.e == nil && err != nil:
+			t.Errorf("%d. Unexpected error %s", i, err)
+		case tc.e != nil && err == nil:
+			t.Errorf("%d. Missing error, want %s", i, tc.e)
+		case tc.e != nil && err.Error() != tc.e.Error():
+			t.Errorf("%d. Got error\n%s\nwant\n%s", i, err, tc.e)
+		}
+	}
+}
@@ -0,0 +1,246 @@
+// Copyright 2018 Volker Dobler.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// digest.go implements HTTP Digest access authentication (RFC 7616).
+
+package ht
+
+import (
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// Digest configures HTTP Digest access authentication as described in
+// RFC 7616. The request is sent without credentials first; a 401 response
+// carrying a Digest challenge is answered transparently by resending the
+// request with an Authorization header computed from the challenge.
+// The 401 response is kept in Response.Challenge.
+//
+// The algorithms MD5, MD5-sess, SHA-256 and SHA-256-sess are supported,
+// SHA-256 is preferred if the server offers several challenges.
+type Digest struct {
+	// Username and Password are the credentials.
+	Username string
+	Password string
+
+	// QOP is the preferred quality of protection: "auth" (the default)
+	// or "auth-int" which protects the request body too. The other
+	// one is used if the server does not offer the preferred one.
+	QOP string `json:",omitempty"`
+}
+
+func (d *Digest) validate() error {
+	if d.Username == "" {
+		return errors.New("missing Username")
+	}
+	switch d.QOP {
+	case "", "auth", "auth-int":
+	default:
+		return fmt.Errorf("unknown QOP %q", d.QOP)
+	}
+	return nil
+}
+
+// digestChallenge is a parsed Digest challenge from a WWW-Authenticate
+// header.
+type digestChallenge struct {
+	Realm     string
+	Nonce     string
+	Opaque    string
+	Algorithm string
+	QOP       []string
+	Userhash  bool
+}
+
+// hash returns the hash function of the challenge's algorithm or nil if
+// the algorithm is not supported.
+func (c digestChallenge) hash() func() hash.Hash {
+	switch strings.TrimSuffix(strings.ToUpper(c.Algorithm), "-SESS") {
+	case "", "MD5":
+		return md5.New
+	case "SHA-256":
+		return sha256.New
+	}
+	return nil
+}
+
+// parseDigestChallenges returns the supported Digest challenges found in
+// header, the strongest first.
+func parseDigestChallenges(header http.Header) []digestChallenge {
+	var md5s, sha256s []digestChallenge
+	for _, h := range header["Www-Authenticate"] {
+		if len(h) < 7 || !strings.EqualFold(h[:7], "Digest ") {
+			continue
+		}
+		params := parseAuthParams(h[7:])
+		c := digestChallenge{
+			Realm:     params["realm"],
+			Nonce:     params["nonce"],
+			Opaque:    params["opaque"],
+			Algorithm: params["algorithm"],
+			Userhash:  strings.EqualFold(params["userhash"], "true"),
+		}
+		for _, qop := range strings.Split(params["qop"], ",") {
+			if qop = strings.TrimSpace(qop); qop != "" {
+				c.QOP = append(c.QOP, qop)
+			}
+		}
+		if c.Nonce == "" || c.hash() == nil {
+			continue
+		}
+		if c.hash()().Size() == sha256.Size {
+			sha256s = append(sha256s, c)
+		} else {
+			md5s = append(md5s, c)
+		}
+	}
+	return append(sha256s, md5s...)
+}
+
+// parseAuthParams parses the comma separated list of name=value pairs
+// of an authentication challenge. Values may be quoted strings.
+func parseAuthParams(s string) map[string]string {
+	params := make(map[string]string)
+	for {
+		s = strings.TrimLeft(s, " \t,")
+		eq := strings.Index(s, "=")
+		if eq < 0 {
+			return params
+		}
+		name := strings.ToLower(strings.TrimSpace(s[:eq]))
+		s = strings.TrimLeft(s[eq+1:], " \t")
+		value := ""
+		if strings.HasPrefix(s, `"`) {
+			i := 1
+			for ; i < len(s) && s[i] != '"'; i++ {
+				if s[i] == '\\' && i+1 < len(s) {
+					i++
+				}
+				value += string(s[i])
+			}
+			s = s[min(i+1, len(s)):]
+		} else {
+			end := strings.IndexAny(s, ", \t")
+			if end < 0 {
+				end = len(s)
+			}
+			value, s = s[:end], s[end:]
+		}
+		params[name] = value
+	}
+}
+
+// authorization computes the value of the Authorization header answering
+// challenge c for a request with the given method, uri and body.
+func (d *Digest) authorization(c digestChallenge, method, uri, body, cnonce string) string {
+	h := func(s string) string {
+		hh := c.hash()()
+		hh.Write([]byte(s))
+		return hex.EncodeToString(hh.Sum(nil))
+	}
+
+	qop := ""
+	if len(c.QOP) > 0 {
+		qop = c.QOP[0]
+		for _, q := range c.QOP {
+			if q == d.QOP || (d.QOP == "" && q == "auth") {
+				qop = q
+				break
+			}
+		}
+	}
+
+	const nc = "00000001"
+	ha1 := h(d.Username + ":" + c.Realm + ":" + d.Password)
+	if strings.HasSuffix(strings.ToUpper(c.Algorithm), "-SESS") {
+		ha1 = h(ha1 + ":" + c.Nonce + ":" + cnonce)
+	}
+	ha2 := h(method + ":" + uri)
+	if qop == "auth-int" {
+		ha2 = h(method + ":" + uri + ":" + h(body))
+	}
+	var response string
+	if qop == "" {
+		response = h(ha1 + ":" + c.Nonce + ":" + ha2)
+	} else {
+		response = h(ha1 + ":" + c.Nonce + ":" + nc + ":" + cnonce + ":" + qop + ":" + ha2)
+	}
+
+	username := d.Username
+	if c.Userhash {
+		username = h(d.Username + ":" + c.Realm)
+	}
+	quote := strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace
+	auth := fmt.Sprintf(`Digest username="%s", realm="%s", uri="%s", nonce="%s", response="%s"`,
+		quote(username), quote(c.Realm), quote(uri), quote(c.Nonce), response)
+	if c.Algorithm != "" {
+		auth += ", algorithm=" + c.Algorithm
+	}
+	if qop != "" {
+		auth += fmt.Sprintf(`, qop=%s, nc=%s, cnonce="%s"`, qop, nc, cnonce)
+	}
+	if c.Opaque != "" {
+		auth += fmt.Sprintf(`, opaque="%s"`, quote(c.Opaque))
+	}
+	if c.Userhash {
+		auth += ", userhash=true"
+	}
+	return auth
+}
+
+// answerChallenge answers the Digest challenge in the 401 response resp
+// to req by resending the request which produced resp with credentials.
+// After followed redirects this is the last request of the redirect chain,
+// not req. The response is returned unchanged if no Digest authentication
+// is configured or resp contains no supported challenge.
+func (t *Test) answerChallenge(req *http.Request, resp *http.Response) (*http.Response, error) {
+	auth := t.Request.Auth
+	if auth == nil || auth.Digest == nil {
+		return resp, nil
+	}
+	challenges := parseDigestChallenges(resp.Header)
+	if len(challenges) == 0 {
+		t.debugf("No supported Digest challenge in 401 response")
+		return resp, nil
+	}
+	challenge := challenges[0]
+
+	hop := newHop(resp)
+	resp.Body.Close()
+	t.Response.Challenge = &hop
+
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	// The http.Client follows only redirects which drop the body as
+	// requests are built without GetBody. The context of resp.Request
+	// is canceled once the client returned, so use the one of req.
+	retry := resp.Request.Clone(req.Context())
+	body := ""
+	if len(t.Response.Redirections) == 0 {
+		body = t.Request.SentBody
+	}
+	authorization := auth.Digest.authorization(challenge, retry.Method,
+		retry.URL.RequestURI(), body, hex.EncodeToString(nonce))
+	t.debugf("Answering Digest challenge of realm %q (%s)", challenge.Realm,
+		challenge.Algorithm)
+	retry.Header.Set("Authorization", authorization)
+	if body != "" {
+		retry.Body = ioutil.NopCloser(strings.NewReader(body))
+	}
+
+	// The retry records its own redirects (if any).
+	t.Response.Redirections = nil
+	t.Response.Hops = nil
+	return t.client.Do(retry)
+}
@@ -0,0 +1,176 @@
+// Copyright 2018 Volker Dobler.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ht
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// The examples from RFC 7616 section 3.9.1.
+func TestDigestAuthorization(t *testing.T) {
+	d := &Digest{Username: "Mufasa", Password: "Circle of Life"}
+	for _, tc := range []struct {
+		algorithm, response string
+	}{
+		{"MD5", "8ca523f5e9506fed4657c9700eebdbec"},
+		{"SHA-256", "753927fa0e85d155564e2e272a28d1802ca10daf4496794697cf8db5856cb6c1"},
+	} {
+		challenge := digestChallenge{
+			Realm:     "http-auth@example.org",
+			Nonce:     "7ypf/xlj9XXwfDPEoM4URrv/xwf94BcCAzFZH4GiTo0v",
+			Opaque:    "FQhe/qaU925kfnzjCev0ciny7QMkPqMAFRtzCUYo5tdS",
+			Algorithm: tc.algorithm,
+			QOP:       []string{"auth", "auth-int"},
+		}
+		got := d.authorization(challenge, "GET", "/dir/index.html", "",
+			"f2/wE4q74E6zIJEtWaHKaf5wv/H5QzzpXusqGemxURZJ")
+		if !strings.Contains(got, `response="`+tc.response+`"`) {
+			t.Errorf("%s: Got %s", tc.algorithm, got)
+		}
+	}
+}
+
+func TestParseDigestChallenges(t *testing.T) {
+	header := http.Header{"Www-Authenticate": {
+		`Basic realm="foo"`,
+		`Digest realm="a, b", qop="auth,auth-int", algorithm=MD5, nonce="n1"`,
+		`Digest realm="x", algorithm=SHA-512-256, nonce="n2"`,
+		`Digest realm="y", qop="auth", algorithm=SHA-256, nonce="n3", opaque="o", userhash=true`,
+	}}
+	got := fmt.Sprintf("%+v", parseDigestChallenges(header))
+	want := `[{Realm:y Nonce:n3 Opaque:o Algorithm:SHA-256 QOP:[auth] Userhash:true} ` +
+		`{Realm:a, b Nonce:n1 Opaque: Algorithm:MD5 QOP:[auth auth-int] Userhash:false}]`
+	if got != want {
+		t.Errorf("Got  %s\nwant %s", got, want)
+	}
+}
+
+// digestHandler challenges with algorithm and verifies the response
+// for user ht with password s3cr3t.
+func digestHandler(algorithm string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		params := parseAuthParams(strings.TrimPrefix(r.Header.Get("Authorization"), "Digest "))
+		if params["nonce"] != "abc" {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(
+				`Digest realm="test", qop="auth, auth-int", algorithm=%s, nonce="abc"`,
+				algorithm))
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte("Please authenticate"))
+			return
+		}
+		var hf func() hash.Hash = md5.New
+		if algorithm == "SHA-256" {
+			hf = sha256.New
+		}
+		h := func(s string) string {
+			hh := hf()
+			hh.Write([]byte(s))
+			return hex.EncodeToString(hh.Sum(nil))
+		}
+		ha1 := h("ht:test:s3cr3t")
+		ha2 := h(r.Method + ":" + r.URL.RequestURI())
+		if params["qop"] == "auth-int" {
+			ha2 = h(r.Method + ":" + r.URL.RequestURI() + ":" + h(string(body)))
+		}
+		want := h(ha1 + ":abc:" + params["nc"] + ":" + params["cnonce"] + ":" +
+			params["qop"] + ":" + ha2)
+		if params["response"] != want || params["uri"] != r.URL.RequestURI() {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		fmt.Fprintf(w, "Welcome %s %s %s", params["username"], params["qop"], body)
+	}
+}
+
+func TestDigest(t *testing.T) {
+	for i, tc := range []struct {
+		algorithm string
+		digest    Digest
+		want      string
+	}{
+		{"MD5", Digest{Username: "ht", Password: "s3cr3t"}, "Welcome ht auth data"},
+		{"SHA-256", Digest{Username: "ht", Password: "s3cr3t"}, "Welcome ht auth data"},
+		{"SHA-256", Digest{Username: "ht", Password: "s3cr3t", QOP: "auth-int"},
+			"Welcome ht auth-int data"},
+		{"MD5", Digest{Username: "ht", Password: "wrong"}, "403"},
+		{"SHA-512-256", Digest{Username: "ht", Password: "s3cr3t"}, "401"},
+	} {
+		ts := httptest.NewServer(digestHandler(tc.algorithm))
+		test := &Test{
+			Request: Request{
+				Method: "POST",
+				URL:    ts.URL + "/admin?x=1",
+				Body:   "data",
+				Auth:   &Auth{Digest: &tc.digest},
+			},
+		}
+		if strings.HasPrefix(tc.want, "Welcome") {
+			test.Checks = CheckList{StatusCode{Expect: 200}, &Body{Equals: tc.want}}
+		} else {
+			test.Checks = CheckList{StatusCode{Expect: 401}}
+		}
+		test.Run()
+		ts.Close()
+
+		switch tc.want {
+		case "403":
+			if test.Response.Response.StatusCode != 403 {
+				t.Errorf("%d. Got %s", i, test.Response.Response.Status)
+			}
+		case "401":
+			if test.Result.Status != Pass || test.Response.Challenge != nil {
+				t.Errorf("%d. Got %s %v", i, test.Result.Status, test.Result.Error)
+			}
+		default:
+			if test.Result.Status != Pass {
+				t.Errorf("%d. Got %s %v", i, test.Result.Status, test.Result.Error)
+				continue
+			}
+			if c := test.Response.Challenge; c == nil || c.StatusCode != 401 ||
+				c.BodyStr != "Please authenticate" {
+				t.Errorf("%d. Bad challenge %+v", i, c)
+			}
+		}
+	}
+}
+
+func TestDigestAfterRedirect(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.Handle("/admin", digestHandler("SHA-256"))
+	mux.Handle("/login", http.RedirectHandler("/admin?x=1", http.StatusSeeOther))
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	test := &Test{
+		Request: Request{
+			Method:          "POST",
+			URL:             ts.URL + "/login",
+			Body:            "data",
+			FollowRedirects: true,
+			Auth:            &Auth{Digest: &Digest{Username: "ht", Password: "s3cr3t"}},
+		},
+		Checks: CheckList{StatusCode{Expect: 200}, &Body{Equals: "Welcome ht auth "}},
+	}
+	test.Run()
+	if test.Result.Status != Pass {
+		t.Fatalf("Got %s %v", test.Result.Status, test.Result.Error)
+	}
+	if c := test.Response.Challenge; c == nil || !strings.HasSuffix(c.URL, "/admin?x=1") {
+		t.Errorf("Bad challenge %+v", c)
+	}
+	if n, m := len(test.Response.Redirections), len(test.Response.Hops); n != 0 || m != 0 {
+		t.Errorf("Got %d redirections and %d hops", n, m)
+	}
+}
@@ -31,11 +31,16 @@
 //     * AnyOne          logical OR of several tests
 //     * Body            text in the response body
 //     * Cache           Cache-Control header
+//     * ContentEfficiency compression, minification and image formats
 //     * ContentType     Content-Type header
+//     * CORS            Cross-Origin Resource Sharing incl. preflight
+//     * CSP             Content-Security-Policy and blocked resources
 //     * CustomJS        performed by your own JavaScript code
 //     * DeleteCookie    for proper deletion of cookies
 //     * ETag            presence of working ETag header
 //     * FinalURL        final URL after a redirect chain
+//     * Golden          body compared to a golden file
+//     * GraphQL         errors and data of a GraphQL response
 //     * Header          presence and values of received HTTP header
 //     * HTMLContains    text content of CSS-selected elements
 //     * HTMLTag         occurrence HTML elements chosen via CSS-selectors
@@ -44,6 +49,7 @@
 //     * Image           image format, size and content
 //     * JSON            structure and content of a JSON body
 //     * JSONExpr        structure and content of a JSON body
+//     * JWT             signature and claims of a JSON Web Token
 //     * Latency         latency distribution of a request
 //     * Links           accesability of hrefs and srcs in HTML
 //     * Logfile         data written to a logfile
@@ -56,9 +62,12 @@
 //     * Resilience      how wellbehaved does the server answer modified requests
 //     * ResponseTime    lower and higher bounds on the response time
 //     * Screenshot      render screen via PhantomJS and compare to reference
+//     * SecurityHeaders security related HTTP headers
 //     * SetCookie       properties of received cookies
 //     * Sorted          sorted occurrence of text on body
 //     * StatusCode      the received HTTP status code
+//     * TLS             certificate and parameters of the TLS handshake
+//     * Timing          durations of DNS, connect, TLS, first byte and transfer
 //     * UTF8Encoded     that the HTTP body is UTF-8 encoded
 //     * ValidHTML       not obviousely malformed HTML
 //     * W3CValidHTML    if body parses as valid HTML5
@@ -91,6 +100,7 @@
 //   * HTMLExtractor    value of a HTML attribute or HTML text
 //   * JSExtractor      custom via interpreded JavaScript script
 //   * JSONExtractor    from a JSON document
+//   * JWTExtractor     a claim of a JSON Web Token
 //   * SetVariable      not extracted but set manually
 //
 //
@@ -0,0 +1,277 @@
+// Copyright 2018 Volker Dobler.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// efficiency.go provides a check of the content efficiency.
+
+package ht
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"image"
+	"image/color"
+	"mime"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/vdobler/ht/errorlist"
+)
+
+func init() {
+	RegisterCheck(&ContentEfficiency{})
+}
+
+// The aspects checked by ContentEfficiency.
+var efficiencyAspects = []string{"compression", "minification", "logo", "image-format"}
+
+// Defaults of ContentEfficiency.
+const (
+	defaultMinCompressSize = 1024
+	defaultMaxWaste        = 0.1
+	defaultLargeImage      = 50 * 1024
+)
+
+// modernImageSavings is the typical fraction saved by WebP or AVIF
+// compared to JPEG or PNG.
+const modernImageSavings = 0.3
+
+var (
+	htmlCommentRE  = regexp.MustCompile(`(?s)<!--.*?-->`)
+	blockCommentRE = regexp.MustCompile(`(?s)/\*.*?\*/`)
+	lineCommentRE  = regexp.MustCompile(`(?m)(^|[ \t;{}])//[^\n]*`)
+	whitespaceRE   = regexp.MustCompile(`\s{2,}`)
+)
+
+// ----------------------------------------------------------------------------
+// ContentEfficiency
+
+// ContentEfficiency checks how efficient the content is delivered following
+// https://developers.google.com/web/fundamentals/performance/optimizing-content-efficiency/
+// The following aspects are checked:
+//
+//	compression    compressible responses (text, JSON, JavaScript, XML,
+//	               SVG) are sent gzip or brotli compressed if allowed by
+//	               the Accept-Encoding of the request
+//	minification   HTML, CSS and JavaScript contain only a small fraction
+//	               of comments and superfluous whitespace
+//	logo           images with "logo" in their filename are SVGs or PNGs
+//	               with a palette (PNG-8)
+//	image-format   large images are delivered in a modern format like
+//	               WebP or AVIF
+//
+// Failures report an estimate of the bytes which could be saved.
+type ContentEfficiency struct {
+	// MinCompressSize is the size in bytes from which on compressible
+	// responses must be compressed. The zero value means 1024 bytes.
+	MinCompressSize int `json:",omitempty"`
+
+	// MaxWaste is the maximal fraction of a HTML, CSS or JavaScript body
+	// which could be saved by minification. The zero value means 0.1.
+	MaxWaste float64 `json:",omitempty"`
+
+	// LargeImage is the size in bytes from which on images must use a
+	// modern format. The zero value means 50kB.
+	LargeImage int `json:",omitempty"`
+
+	// Ignore lists aspects not to check, e.g. "logo".
+	Ignore []string `json:",omitempty"`
+}
+
+// Prepare implements Check's Prepare method.
+func (c *ContentEfficiency) Prepare(*Test) error {
+	if c.MinCompressSize < 0 || c.MaxWaste < 0 || c.MaxWaste > 1 || c.LargeImage < 0 {
+		return MalformedCheck{fmt.Errorf("limits out of range")}
+	}
+outer:
+	for _, ignore := range c.Ignore {
+		for _, aspect := range efficiencyAspects {
+			if ignore == aspect {
+				continue outer
+			}
+		}
+		return MalformedCheck{fmt.Errorf("unknown aspect %q in Ignore", ignore)}
+	}
+	return nil
+}
+
+var _ Preparable = &ContentEfficiency{}
+
+// Execute implements Check's Execute method.
+func (c *ContentEfficiency) Execute(t *Test) error {
+	if t.Response.BodyErr != nil {
+		return ErrBadBody
+	}
+	if t.Response.Response == nil {
+		return fmt.Errorf("no response available")
+	}
+	mediatype, _, _ := mime.ParseMediaType(t.Response.Response.Header.Get("Content-Type"))
+	body := t.Response.BodyStr
+
+	errs := errorlist.List{}
+	check := func(aspect string, fn func() error) {
+		for _, ignore := range c.Ignore {
+			if ignore == aspect {
+				return
+			}
+		}
+		if err := fn(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	check("compression", func() error {
+		return c.checkCompression(t, mediatype, body)
+	})
+	check("minification", func() error {
+		return c.checkMinification(mediatype, body)
+	})
+	if strings.HasPrefix(mediatype, "image/") {
+		check("logo", func() error {
+			return checkLogo(requestURL(t), mediatype, body)
+		})
+		check("image-format", func() error {
+			return c.checkImageFormat(mediatype, body)
+		})
+	}
+
+	return errs.AsError()
+}
+
+// requestURL returns the URL of the request of t or nil.
+func requestURL(t *Test) *url.URL {
+	if t.Request.Request != nil {
+		return t.Request.Request.URL
+	}
+	u, err := url.Parse(t.Request.URL)
+	if err != nil {
+		return nil
+	}
+	return u
+}
+
+// compressible reports whether the media type benefits from compression.
+func compressible(mediatype string) bool {
+	return strings.HasPrefix(mediatype, "text/") ||
+		strings.HasSuffix(mediatype, "json") ||
+		strings.HasSuffix(mediatype, "xml") ||
+		strings.HasSuffix(mediatype, "javascript") ||
+		mediatype == "image/svg+xml"
+}
+
+func (c *ContentEfficiency) checkCompression(t *Test, mediatype string, body string) error {
+	minSize := c.MinCompressSize
+	if minSize == 0 {
+		minSize = defaultMinCompressSize
+	}
+	if !compressible(mediatype) || len(body) < minSize {
+		return nil
+	}
+
+	// Without an explicit Accept-Encoding DefaultAcceptEncoding is sent.
+	accept := strings.ToLower(DefaultAcceptEncoding)
+	if t.Request.Request != nil {
+		if ae, ok := t.Request.Request.Header["Accept-Encoding"]; ok {
+			accept = strings.ToLower(strings.Join(ae, ","))
+		}
+	}
+	if !strings.Contains(accept, "gzip") && !strings.Contains(accept, "br") {
+		return nil
+	}
+	if contentEncoding(t.Response.Response) != "" {
+		return nil
+	}
+
+	saved := len(body) - gzipSize(body)
+	if saved <= 0 {
+		return nil // Incompressible content.
+	}
+	return fmt.Errorf("%s not compressed (gzip would save about %d of %d bytes, %d%%)",
+		mediatype, saved, len(body), percent(saved, len(body)))
+}
+
+func (c *ContentEfficiency) checkMinification(mediatype string, body string) error {
+	var minified string
+	switch {
+	case mediatype == "text/html":
+		minified = htmlCommentRE.ReplaceAllString(body, "")
+	case mediatype == "text/css":
+		minified = blockCommentRE.ReplaceAllString(body, "")
+	case strings.HasSuffix(mediatype, "javascript"):
+		minified = blockCommentRE.ReplaceAllString(body, "")
+		minified = lineCommentRE.ReplaceAllString(minified, "$1")
+	default:
+		return nil
+	}
+	minified = whitespaceRE.ReplaceAllString(minified, " ")
+	if len(body) == 0 {
+		return nil
+	}
+
+	maxWaste := c.MaxWaste
+	if maxWaste == 0 {
+		maxWaste = defaultMaxWaste
+	}
+	saved := len(body) - len(minified)
+	if float64(saved)/float64(len(body)) <= maxWaste {
+		return nil
+	}
+	return fmt.Errorf("%s not minified (minification would save about %d of %d bytes, %d%%)",
+		mediatype, saved, len(body), percent(saved, len(body)))
+}
+
+func checkLogo(u *url.URL, mediatype string, body string) error {
+	if u == nil || !strings.Contains(strings.ToLower(path.Base(u.Path)), "logo") ||
+		mediatype == "image/svg+xml" {
+		return nil
+	}
+	if mediatype == "image/png" {
+		config, _, err := image.DecodeConfig(strings.NewReader(body))
+		if err != nil {
+			return CantCheck{err}
+		}
+		if _, ok := config.ColorModel.(color.Palette); ok {
+			return nil
+		}
+		return fmt.Errorf("logo is a truecolor PNG of %d bytes, use SVG or PNG-8", len(body))
+	}
+	return fmt.Errorf("logo is %s of %d bytes, use SVG or PNG-8", mediatype, len(body))
+}
+
+func (c *ContentEfficiency) checkImageFormat(mediatype string, body string) error {
+	largeImage := c.LargeImage
+	if largeImage == 0 {
+		largeImage = defaultLargeImage
+	}
+	switch mediatype {
+	case "image/jpeg", "image/png", "image/gif", "image/bmp", "image/tiff":
+	default:
+		return nil
+	}
+	if len(body) < largeImage {
+		return nil
+	}
+	saved := int(modernImageSavings * float64(len(body)))
+	return fmt.Errorf("large %s of %d bytes (WebP or AVIF would save about %d bytes, %d%%)",
+		mediatype, len(body), saved, percent(saved, len(body)))
+}
+
+// gzipSize returns the size of s after gzip compression.
+func gzipSize(s string) int {
+	buf := &bytes.Buffer{}
+	w := gzip.NewWriter(buf)
+	w.Write([]byte(s))
+	w.Close()
+	return buf.Len()
+}
+
+// percent returns part/total in percent.
+func percent(part, total int) int {
+	if total == 0 {
+		return 0
+	}
+	return (100*part + total/2) / total
+}
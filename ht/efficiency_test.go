@@ -0,0 +1,132 @@
+// Copyright 2018 Volker Dobler.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ht
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func efficiencyResponse(contentType, encoding, body string) Response {
+	header := http.Header{"Content-Type": {contentType}}
+	if encoding != "" {
+		header.Set("Content-Encoding", encoding)
+	}
+	return Response{
+		Response: &http.Response{Header: header, ContentLength: -1},
+		BodyStr:  body,
+	}
+}
+
+func pngImage(paletted bool) string {
+	var img image.Image
+	if paletted {
+		img = image.NewPaletted(image.Rect(0, 0, 20, 10),
+			color.Palette{color.White, color.Black})
+	} else {
+		img = image.NewRGBA(image.Rect(0, 0, 20, 10))
+	}
+	buf := &bytes.Buffer{}
+	png.Encode(buf, img)
+	return buf.String()
+}
+
+func TestContentEfficiency(t *testing.T) {
+	text := strings.Repeat("Hello World! ", 200)
+	minJS := strings.Repeat("var a=1;", 100)
+	bloatedJS := strings.Repeat("// Set a to one\nvar a = 1;      /* one */\n", 100)
+	html := "<html>" + strings.Repeat("<p>Hello World!</p>", 100) + "</html>"
+	bloatedHTML := "<html>\n" + strings.Repeat("    <!-- greeting -->\n    <p>Hello World!</p>\n", 100) + "</html>"
+	paletted, truecolor := pngImage(true), pngImage(false)
+	large := strings.Repeat("x", 60000)
+
+	for i, tc := range []struct {
+		url string
+		tc  TC
+	}{
+		{"/a.txt", TC{efficiencyResponse("text/plain", "gzip", text), &ContentEfficiency{}, nil}},
+		{"/a.txt", TC{efficiencyResponse("text/plain", "", "short"), &ContentEfficiency{}, nil}},
+		{"/a.txt", TC{efficiencyResponse("text/plain", "", text), &ContentEfficiency{},
+			errCheck}}, // exact savings depend on compress/gzip
+		{"/a.txt", TC{efficiencyResponse("text/plain", "", text),
+			&ContentEfficiency{Ignore: []string{"compression"}}, nil}},
+		{"/a.js", TC{efficiencyResponse("application/javascript", "br", minJS), &ContentEfficiency{}, nil}},
+		{"/a.js", TC{efficiencyResponse("application/javascript", "br", bloatedJS), &ContentEfficiency{},
+			errors.New("application/javascript not minified (minification would save about 3099 of 4200 bytes, 74%)")}},
+		{"/a.js", TC{efficiencyResponse("application/javascript", "br", bloatedJS),
+			&ContentEfficiency{MaxWaste: 0.8}, nil}},
+		{"/", TC{efficiencyResponse("text/html", "br", html), &ContentEfficiency{}, nil}},
+		{"/", TC{efficiencyResponse("text/html", "br", bloatedHTML), &ContentEfficiency{},
+			errors.New("text/html not minified (minification would save about 2600 of 4614 bytes, 56%)")}},
+		{"/img/logo.png", TC{efficiencyResponse("image/png", "", paletted), &ContentEfficiency{}, nil}},
+		{"/img/logo.png", TC{efficiencyResponse("image/png", "", truecolor), &ContentEfficiency{},
+			fmt.Errorf("logo is a truecolor PNG of %d bytes, use SVG or PNG-8", len(truecolor))}},
+		{"/img/photo.png", TC{efficiencyResponse("image/png", "", truecolor), &ContentEfficiency{}, nil}},
+		{"/img/Logo.jpg", TC{efficiencyResponse("image/jpeg", "", "JPEG"), &ContentEfficiency{},
+			errors.New("logo is image/jpeg of 4 bytes, use SVG or PNG-8")}},
+		{"/img/photo.jpg", TC{efficiencyResponse("image/jpeg", "", large), &ContentEfficiency{},
+			errors.New("large image/jpeg of 60000 bytes (WebP or AVIF would save about 18000 bytes, 30%)")}},
+		{"/img/photo.webp", TC{efficiencyResponse("image/webp", "", large), &ContentEfficiency{}, nil}},
+		{"/img/photo.jpg", TC{efficiencyResponse("image/jpeg", "", large),
+			&ContentEfficiency{LargeImage: 100000}, nil}},
+		{"/", TC{efficiencyResponse("text/html", "", ""),
+			&ContentEfficiency{Ignore: []string{"speed"}}, errDuringPrepare}},
+		{"/", TC{efficiencyResponse("text/html", "", ""),
+			&ContentEfficiency{MaxWaste: 2}, errDuringPrepare}},
+	} {
+		fakeTest := Test{Request: Request{URL: "http://www.example.org" + tc.url}, Response: tc.tc.r}
+		check := tc.tc.c.(*ContentEfficiency)
+		if err := check.Prepare(&fakeTest); err != nil {
+			if tc.tc.e != errDuringPrepare {
+				t.Errorf("%d. Unexpected error during Prepare: %s", i, err)
+			}
+			continue
+		}
+		err := check.Execute(&fakeTest)
+		switch {
+		case tc.tc.e == nil && err != nil:
+			t.Errorf("%d. Unexpected error %s", i, err)
+		case tc.tc.e != nil && err == nil:
+			t.Errorf("%d. Missing error, want %s", i, tc.tc.e)
+		case tc.tc.e != nil && tc.tc.e != errCheck && err.Error() != tc.tc.e.Error():
+			t.Errorf("%d. Got error\n%s\nwant\n%s", i, err, tc.tc.e)
+		}
+	}
+}
+
+func TestContentEfficiencyCompression(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		encodingHandler(w, r)
+	}))
+	defer ts.Close()
+
+	for i, tc := range []struct {
+		ce   string
+		want Status
+	}{
+		{"gzip", Pass},
+		{"br", Pass},
+		{"", Fail},
+	} {
+		test := &Test{
+			Request: Request{URL: ts.URL + "/?ce=" + tc.ce},
+			Checks:  CheckList{&ContentEfficiency{}},
+		}
+		test.Run()
+		if test.Result.Status != tc.want {
+			t.Errorf("%d. %q: got %s %v, want %s", i, tc.ce,
+				test.Result.Status, test.Result.Error, tc.want)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+// Copyright 2018 Volker Dobler.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// encoding.go provides decoding of the Content-Encoding of response bodies.
+
+package ht
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+// DefaultAcceptEncoding is the Accept-Encoding header sent if no such
+// header is set explicitly in the test. The bodies of all these content
+// codings are decoded transparently.
+var DefaultAcceptEncoding = "gzip"
+
+// countingReader counts the bytes read from r.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// decodedBody is a reader of a decoded body which releases the resources
+// of all decoders on Close.
+type decodedBody struct {
+	io.Reader
+	closers []func()
+}
+
+func (d *decodedBody) Close() error {
+	for _, c := range d.closers {
+		c()
+	}
+	return nil
+}
+
+// contentEncoding returns the Content-Encoding of resp or "" if resp
+// cannot have a body: Responses to HEAD requests, 204 and 304 responses
+// and responses with a Content-Length of 0 often carry the header of
+// the full response, but decoders like gzip fail on empty input.
+func contentEncoding(resp *http.Response) string {
+	if resp.ContentLength == 0 ||
+		resp.StatusCode == http.StatusNoContent ||
+		resp.StatusCode == http.StatusNotModified ||
+		(resp.Request != nil && resp.Request.Method == http.MethodHead) {
+		return ""
+	}
+	return resp.Header.Get("Content-Encoding")
+}
+
+// decodeBody returns a reader decoding body according to the (comma
+// separated list of) content codings in encoding. Stacked codings like
+// "gzip, br" are undone in reverse order of their application.
+func decodeBody(body io.Reader, encoding string) (*decodedBody, error) {
+	d := &decodedBody{Reader: body}
+	codings := strings.Split(encoding, ",")
+	for i := len(codings) - 1; i >= 0; i-- {
+		coding := strings.ToLower(strings.TrimSpace(codings[i]))
+		switch coding {
+		case "", "identity":
+		case "gzip", "x-gzip":
+			zr, err := gzip.NewReader(d.Reader)
+			if err != nil {
+				return nil, err
+			}
+			d.Reader = zr
+			d.closers = append(d.closers, func() { zr.Close() })
+		case "deflate":
+			// Deflate should be zlib wrapped (RFC 7230) but some
+			// servers send raw deflate data.
+			br := bufio.NewReader(d.Reader)
+			if header, err := br.Peek(2); err == nil && isZlibHeader(header) {
+				zr, err := zlib.NewReader(br)
+				if err != nil {
+					return nil, err
+				}
+				d.Reader = zr
+				d.closers = append(d.closers, func() { zr.Close() })
+			} else {
+				fr := flate.NewReader(br)
+				d.Reader = fr
+				d.closers = append(d.closers, func() { fr.Close() })
+			}
+		case "br":
+			d.Reader = brotli.NewReader(d.Reader)
+		case "zstd":
+			zr, err := zstd.NewReader(d.Reader, zstd.WithDecoderConcurrency(1))
+			if err != nil {
+				return nil, err
+			}
+			d.Reader = zr
+			d.closers = append(d.closers, zr.Close)
+		default:
+			return nil, fmt.Errorf("unsupported Content-Encoding %q", coding)
+		}
+	}
+	return d, nil
+}
+
+// isZlibHeader reports whether h are the two bytes of a zlib header.
+func isZlibHeader(h []byte) bool {
+	return h[0]&0x0f == 8 && (uint16(h[0])<<8|uint16(h[1]))%31 == 0
+}
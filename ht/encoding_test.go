@@ -0,0 +1,129 @@
+// Copyright 2018 Volker Dobler.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ht
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+var encodingBody = strings.Repeat("Hello World! ", 100)
+
+// encode body with the given content coding.
+func encode(coding string, body []byte) []byte {
+	buf := &bytes.Buffer{}
+	var w io.WriteCloser
+	switch coding {
+	case "gzip":
+		w = gzip.NewWriter(buf)
+	case "deflate":
+		w = zlib.NewWriter(buf)
+	case "rawdeflate":
+		w, _ = flate.NewWriter(buf, flate.DefaultCompression)
+	case "br":
+		w = brotli.NewWriter(buf)
+	case "zstd":
+		w, _ = zstd.NewWriter(buf)
+	}
+	w.Write(body)
+	w.Close()
+	return buf.Bytes()
+}
+
+func encodingHandler(w http.ResponseWriter, r *http.Request) {
+	codings := r.URL.Query().Get("ce")
+	body := []byte(encodingBody)
+	header := []string{}
+	for _, coding := range strings.Split(codings, ",") {
+		if coding == "" {
+			continue
+		}
+		body = encode(coding, body)
+		if coding == "rawdeflate" {
+			coding = "deflate"
+		}
+		header = append(header, coding)
+	}
+	if len(header) > 0 {
+		w.Header().Set("Content-Encoding", strings.Join(header, ", "))
+	}
+	w.Header().Set("X-Accept-Encoding", r.Header.Get("Accept-Encoding"))
+	w.Write(body)
+}
+
+func TestContentEncoding(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(encodingHandler))
+	defer ts.Close()
+
+	for i, ce := range []string{"", "gzip", "deflate", "rawdeflate", "br", "zstd",
+		"gzip,br", "br,zstd,gzip"} {
+		test := &Test{
+			Request: Request{URL: ts.URL + "/?ce=" + ce},
+			Checks: CheckList{
+				&Body{Equals: encodingBody},
+				&Header{Header: "X-Accept-Encoding", Condition: Condition{Equals: "gzip"}},
+			},
+		}
+		test.Run()
+		if test.Result.Status != Pass {
+			t.Errorf("%d. %s: %s %s", i, ce, test.Result.Status, test.Result.Error)
+			continue
+		}
+		if got := test.Response.DecodedSize; got != int64(len(encodingBody)) {
+			t.Errorf("%d. %s: DecodedSize=%d, want %d", i, ce, got, len(encodingBody))
+		}
+		wire := test.Response.WireSize
+		if ce == "" && wire != int64(len(encodingBody)) {
+			t.Errorf("%d. %s: WireSize=%d, want %d", i, ce, wire, len(encodingBody))
+		} else if ce != "" && (wire <= 0 || wire >= int64(len(encodingBody))) {
+			t.Errorf("%d. %s: WireSize=%d", i, ce, wire)
+		}
+	}
+
+	// Unknown codings cannot be decoded.
+	if _, err := decodeBody(strings.NewReader("x"), "compress"); err == nil ||
+		err.Error() != `unsupported Content-Encoding "compress"` {
+		t.Errorf("Got %v", err)
+	}
+}
+
+func TestContentEncodingEmptyBody(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "gzip")
+		if r.URL.Path == "/empty" {
+			w.Header().Set("Content-Length", "0")
+			return
+		}
+		if r.URL.Path == "/204" {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.WriteHeader(http.StatusNotModified)
+	}))
+	defer ts.Close()
+
+	for i, path := range []string{"/204", "/304", "/empty"} {
+		test := &Test{
+			Request: Request{URL: ts.URL + path},
+			Checks:  CheckList{&Body{Equals: ""}},
+		}
+		test.Run()
+		if test.Result.Status != Pass || test.Response.BodyErr != nil {
+			t.Errorf("%d. %s: %s %s %v", i, path, test.Result.Status,
+				test.Result.Error, test.Response.BodyErr)
+		}
+	}
+}
@@ -0,0 +1,356 @@
+// Copyright 2018 Volker Dobler.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// golden.go provides a check comparing the body to a golden file.
+
+package ht
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+func init() {
+	RegisterCheck(&Golden{})
+}
+
+// UpdateGolden controls the behaviour of the Golden check: If set the
+// golden files are (re)written from the current responses instead of
+// being compared to them.
+var UpdateGolden = false
+
+// maskedValue replaces masked parts of the body.
+const maskedValue = "<masked>"
+
+var errMaskWithoutJSON = errors.New("Mask requires Normalize \"json\"")
+
+// ----------------------------------------------------------------------------
+// Golden
+
+// Golden compares the response body to the content of a golden file.
+// The body may be normalized and volatile parts like timestamps or session
+// IDs can be masked before comparison. A mismatch is reported as a unified
+// diff between the golden file and the (normalized) body.
+//
+// If UpdateGolden is set (e.g. via the -update-golden flag of cmd/ht) the
+// golden file is written from the normalized body and the check passes.
+type Golden struct {
+	// File is the golden file, typically given relative to the test
+	// as "{{TEST_DIR}}/some-name.golden".
+	File string
+
+	// Normalize the body before comparison and before writing the
+	// golden file:
+	//     ""      compare the body as is
+	//     "json"  pretty print the JSON body with sorted object keys
+	Normalize string `json:",omitempty"`
+
+	// Mask lists elements of a JSON body whose values are replaced by
+	// "<masked>". The elements are given in the syntax of the JSON check
+	// (e.g. "data.0.id"), a "*" matches any key or index (e.g.
+	// "items.*.created"). Mask requires Normalize "json".
+	Mask []string `json:",omitempty"`
+
+	// Sep is the separator in Mask, a zero value is equivalent to ".".
+	Sep string `json:",omitempty"`
+
+	// MaskRegexp lists regular expressions whose matches in the
+	// (normalized) body are replaced by "<masked>".
+	MaskRegexp []string `json:",omitempty"`
+
+	re []*regexp.Regexp
+}
+
+// Prepare implements Check's Prepare method.
+func (g *Golden) Prepare(*Test) error {
+	if g.File == "" {
+		return MalformedCheck{errors.New("missing File")}
+	}
+	switch g.Normalize {
+	case "", "json":
+	default:
+		return MalformedCheck{fmt.Errorf("unknown Normalize %q", g.Normalize)}
+	}
+	if len(g.Mask) > 0 && g.Normalize != "json" {
+		return MalformedCheck{errMaskWithoutJSON}
+	}
+
+	g.re = make([]*regexp.Regexp, len(g.MaskRegexp))
+	for i, expr := range g.MaskRegexp {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return MalformedCheck{err}
+		}
+		g.re[i] = re
+	}
+	return nil
+}
+
+var _ Preparable = &Golden{}
+
+// Execute implements Check's Execute method.
+func (g *Golden) Execute(t *Test) error {
+	if t.Response.BodyErr != nil {
+		return ErrBadBody
+	}
+	body, err := g.normalize(t.Response.BodyStr)
+	if err != nil {
+		return err
+	}
+
+	if UpdateGolden {
+		err := os.MkdirAll(filepath.Dir(g.File), 0766)
+		if err != nil {
+			return err
+		}
+		return ioutil.WriteFile(g.File, []byte(body), 0666)
+	}
+
+	golden, err := ioutil.ReadFile(g.File)
+	if err != nil {
+		return fmt.Errorf("cannot read golden file (update it with -update-golden): %s", err)
+	}
+	if string(golden) == body {
+		return nil
+	}
+	return fmt.Errorf("body differs from golden file %s:\n%s", g.File,
+		unifiedDiff(string(golden), body, g.File, "body"))
+}
+
+// normalize and mask body.
+func (g *Golden) normalize(body string) (string, error) {
+	if g.Normalize == "json" {
+		decoder := json.NewDecoder(strings.NewReader(body))
+		decoder.UseNumber()
+		var v interface{}
+		if err := decoder.Decode(&v); err != nil {
+			return "", augmentJSONError(err, []byte(body))
+		}
+		sep := "."
+		if g.Sep != "" {
+			sep = g.Sep
+		}
+		for _, path := range g.Mask {
+			v = maskJSON(v, strings.Split(path, sep))
+		}
+		buf := &bytes.Buffer{}
+		encoder := json.NewEncoder(buf)
+		encoder.SetEscapeHTML(false)
+		encoder.SetIndent("", "    ")
+		if err := encoder.Encode(v); err != nil {
+			return "", err
+		}
+		body = buf.String()
+	}
+
+	for _, re := range g.re {
+		body = re.ReplaceAllLiteralString(body, maskedValue)
+	}
+	return body, nil
+}
+
+// maskJSON replaces the elements of v selected by path with maskedValue.
+func maskJSON(v interface{}, path []string) interface{} {
+	if len(path) == 0 {
+		return maskedValue
+	}
+	key, rest := path[0], path[1:]
+	switch x := v.(type) {
+	case map[string]interface{}:
+		for k, elem := range x {
+			if key == "*" || key == k {
+				x[k] = maskJSON(elem, rest)
+			}
+		}
+	case []interface{}:
+		for i, elem := range x {
+			if key == "*" || key == strconv.Itoa(i) {
+				x[i] = maskJSON(elem, rest)
+			}
+		}
+	}
+	return v
+}
+
+// ----------------------------------------------------------------------------
+// Unified diff
+
+// maxDiffLines limits the length of the diff reported by Golden.
+const maxDiffLines = 100
+
+// diffLine is one line of an edit script.
+type diffLine struct {
+	kind       byte // ' ', '-' or '+'
+	text       string
+	aPos, bPos int // number of lines of a and b before this line
+}
+
+// unifiedDiff returns the differences between a and b as a unified diff
+// with three lines of context.
+func unifiedDiff(a, b string, nameA, nameB string) string {
+	script := diffLines(strings.SplitAfter(a, "\n"), strings.SplitAfter(b, "\n"))
+
+	const context = 3
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, "--- %s\n+++ %s\n", nameA, nameB)
+	lines := 0
+	for i := 0; i < len(script); {
+		for i < len(script) && script[i].kind == ' ' {
+			i++
+		}
+		if i == len(script) {
+			break
+		}
+
+		// Extend the hunk as long as changes are close together.
+		start, end := i-context, i
+		if start < 0 {
+			start = 0
+		}
+		for end < len(script) {
+			if script[end].kind != ' ' {
+				end++
+				continue
+			}
+			run := end
+			for run < len(script) && script[run].kind == ' ' {
+				run++
+			}
+			if run == len(script) || run-end > 2*context {
+				end += context
+				if end > len(script) {
+					end = len(script)
+				}
+				break
+			}
+			end = run
+		}
+
+		hunk := script[start:end]
+		aLen, bLen := 0, 0
+		for _, l := range hunk {
+			if l.kind != '+' {
+				aLen++
+			}
+			if l.kind != '-' {
+				bLen++
+			}
+		}
+		aStart, bStart := hunk[0].aPos, hunk[0].bPos
+		if aLen > 0 {
+			aStart++
+		}
+		if bLen > 0 {
+			bStart++
+		}
+		fmt.Fprintf(buf, "@@ -%d,%d +%d,%d @@\n", aStart, aLen, bStart, bLen)
+		for _, l := range hunk {
+			if lines++; lines > maxDiffLines {
+				buf.WriteString("... (diff truncated)\n")
+				return buf.String()
+			}
+			text := l.text
+			if !strings.HasSuffix(text, "\n") {
+				text += "\n\\ No newline at end of file\n"
+			}
+			buf.WriteByte(l.kind)
+			buf.WriteString(text)
+		}
+		i = end
+	}
+	return buf.String()
+}
+
+// diffLines computes a minimal edit script transforming a into b.
+func diffLines(a, b []string) []diffLine {
+	// Drop the empty last element of SplitAfter.
+	if n := len(a); n > 0 && a[n-1] == "" {
+		a = a[:n-1]
+	}
+	if n := len(b); n > 0 && b[n-1] == "" {
+		b = b[:n-1]
+	}
+
+	// Common prefix and suffix are not part of the expensive LCS.
+	pre := 0
+	for pre < len(a) && pre < len(b) && a[pre] == b[pre] {
+		pre++
+	}
+	suf := 0
+	for suf < len(a)-pre && suf < len(b)-pre && a[len(a)-1-suf] == b[len(b)-1-suf] {
+		suf++
+	}
+	ma, mb := a[pre:len(a)-suf], b[pre:len(b)-suf]
+
+	script := make([]diffLine, 0, len(a)+len(b))
+	ai, bi := 0, 0
+	add := func(kind byte, text string) {
+		script = append(script, diffLine{kind: kind, text: text, aPos: ai, bPos: bi})
+		if kind != '+' {
+			ai++
+		}
+		if kind != '-' {
+			bi++
+		}
+	}
+
+	for _, line := range a[:pre] {
+		add(' ', line)
+	}
+	n, m := len(ma), len(mb)
+	if n*m > 4000000 {
+		// Too expensive: Report everything in between as changed.
+		for _, line := range ma {
+			add('-', line)
+		}
+		for _, line := range mb {
+			add('+', line)
+		}
+	} else {
+		// lcs[i][j] is the length of the longest common subsequence
+		// of ma[i:] and mb[j:].
+		lcs := make([][]int, n+1)
+		for i := range lcs {
+			lcs[i] = make([]int, m+1)
+		}
+		for i := n - 1; i >= 0; i-- {
+			for j := m - 1; j >= 0; j-- {
+				if ma[i] == mb[j] {
+					lcs[i][j] = lcs[i+1][j+1] + 1
+				} else if lcs[i+1][j] >= lcs[i][j+1] {
+					lcs[i][j] = lcs[i+1][j]
+				} else {
+					lcs[i][j] = lcs[i][j+1]
+				}
+			}
+		}
+		i, j := 0, 0
+		for i < n || j < m {
+			switch {
+			case i < n && j < m && ma[i] == mb[j]:
+				add(' ', ma[i])
+				i++
+				j++
+			case j < m && (i == n || lcs[i][j+1] > lcs[i+1][j]):
+				add('+', mb[j])
+				j++
+			default:
+				add('-', ma[i])
+				i++
+			}
+		}
+	}
+	for _, line := range a[len(a)-suf:] {
+		add(' ', line)
+	}
+	return script
+}
@@ -0,0 +1,122 @@
+// Copyright 2018 Volker Dobler.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ht
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var unifiedDiffTests = []struct {
+	a, b, want string
+}{
+	{"a\nb\nc\n", "a\nb\nc\n", "--- A\n+++ B\n"},
+	{"a\nb\nc\n", "a\nX\nc\n", "--- A\n+++ B\n@@ -1,3 +1,3 @@\n a\n-b\n+X\n c\n"},
+	{"", "new\n", "--- A\n+++ B\n@@ -0,0 +1,1 @@\n+new\n"},
+	{"old\n", "", "--- A\n+++ B\n@@ -1,1 +0,0 @@\n-old\n"},
+	{"a\nb", "a\nb\n", "--- A\n+++ B\n@@ -1,2 +1,2 @@\n a\n-b\n\\ No newline at end of file\n+b\n"},
+	{
+		"1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n",
+		"1\nX\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\nY\n",
+		"--- A\n+++ B\n@@ -1,5 +1,5 @@\n 1\n-2\n+X\n 3\n 4\n 5\n" +
+			"@@ -10,3 +10,4 @@\n 10\n 11\n 12\n+Y\n",
+	},
+	{
+		"1\n2\n3\n4\n5\n6\n7\n8\n",
+		"1\nX\n3\n4\n5\n6\n7\nY\n",
+		"--- A\n+++ B\n@@ -1,8 +1,8 @@\n 1\n-2\n+X\n 3\n 4\n 5\n 6\n 7\n-8\n+Y\n",
+	},
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	for i, tc := range unifiedDiffTests {
+		if got := unifiedDiff(tc.a, tc.b, "A", "B"); got != tc.want {
+			t.Errorf("%d. got\n%s\nwant\n%s", i, got, tc.want)
+		}
+	}
+}
+
+func TestGolden(t *testing.T) {
+	dir, err := ioutil.TempDir("", "golden")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	golden := filepath.Join(dir, "sub", "user.golden")
+
+	body := `{"name": "Joe <joe@example.org>", "id": 123, "ts": "2018-01-02T10:11:12Z",
+                  "items": [{"id": 1, "n": 2}, {"id": 2, "n": 3.50}]}`
+	check := &Golden{
+		File:       golden,
+		Normalize:  "json",
+		Mask:       []string{"id", "items.*.id"},
+		MaskRegexp: []string{`\d{4}-\d\d-\d\dT[0-9:]+Z`},
+	}
+
+	// Create golden file.
+	UpdateGolden = true
+	runTest(t, 0, TC{Response{BodyStr: body}, check, nil})
+	UpdateGolden = false
+	content, err := ioutil.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{
+    "id": "<masked>",
+    "items": [
+        {
+            "id": "<masked>",
+            "n": 2
+        },
+        {
+            "id": "<masked>",
+            "n": 3.50
+        }
+    ],
+    "name": "Joe <joe@example.org>",
+    "ts": "<masked>"
+}
+`
+	if string(content) != want {
+		t.Errorf("Got golden file\n%s", content)
+	}
+
+	// Masked fields may change.
+	changed := strings.Replace(body, "123", "456", 1)
+	changed = strings.Replace(changed, "2018-01-02T10", "2019-03-04T11", 1)
+	runTest(t, 1, TC{Response{BodyStr: changed}, check, nil})
+
+	// Other changes are reported as a diff.
+	changed = strings.Replace(changed, "3.50", "4", 1)
+	wantErr := "body differs from golden file " + golden + ":\n" +
+		"--- " + golden + "\n+++ body\n" +
+		"@@ -7,7 +7,7 @@\n" +
+		"         },\n         {\n             \"id\": \"<masked>\",\n" +
+		"-            \"n\": 3.50\n+            \"n\": 4\n" +
+		"         }\n     ],\n     \"name\": \"Joe <joe@example.org>\",\n"
+	runTest(t, 2, TC{Response{BodyStr: changed}, check, errors.New(wantErr)})
+
+	// Verbatim comparison.
+	plain := filepath.Join(dir, "plain.golden")
+	if err := ioutil.WriteFile(plain, []byte("Hello\nWorld\n"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	for i, tc := range []TC{
+		{Response{BodyStr: "Hello\nWorld\n"}, &Golden{File: plain}, nil},
+		{Response{BodyStr: "Hello\nWorld"}, &Golden{File: plain}, errCheck},
+		{Response{BodyStr: "Hello\nWorld"}, &Golden{File: "/no/such/file"}, errCheck},
+		{Response{BodyStr: "{"}, &Golden{File: plain, Normalize: "json"}, errCheck},
+		{Response{BodyStr: "x"}, &Golden{}, errDuringPrepare},
+		{Response{BodyStr: "x"}, &Golden{File: plain, Normalize: "xml"}, errDuringPrepare},
+		{Response{BodyStr: "x"}, &Golden{File: plain, Mask: []string{"a"}}, errDuringPrepare},
+		{Response{BodyStr: "x"}, &Golden{File: plain, MaskRegexp: []string{"("}}, errDuringPrepare},
+	} {
+		runTest(t, i+3, tc)
+	}
+}
@@ -0,0 +1,169 @@
+// Copyright 2018 Volker Dobler.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// graphql.go contains GraphQL requests and checks.
+
+package ht
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/vdobler/ht/errorlist"
+)
+
+func init() {
+	RegisterCheck(&GraphQL{})
+}
+
+// ----------------------------------------------------------------------------
+// GraphQLRequest
+
+// GraphQLAccept is the Accept header sent with GraphQL requests if no
+// Accept header is set explicitly.
+var GraphQLAccept = "application/graphql-response+json, application/json"
+
+// GraphQLRequest is a GraphQL operation sent in a Request. It is sent
+// according to the GraphQL over HTTP specification: As a JSON body of a
+// POST request (the default) or as the URL parameters query, operationName
+// and variables of a GET request.
+type GraphQLRequest struct {
+	// Query is the GraphQL document. It may be read from a file with the
+	// @file: and @vfile: syntax, e.g. "@vfile:{{TEST_DIR}}/order.graphql".
+	Query string
+
+	// OperationName selects the operation to execute if Query contains
+	// several operations.
+	OperationName string `json:",omitempty"`
+
+	// Variables are the values of the variables of the operation. They
+	// are sent with their JSON type, e.g.
+	//     {"id": 123, "first": 10, "filter": {"status": "OPEN"}}
+	Variables map[string]interface{} `json:",omitempty"`
+}
+
+// encode gql for a request with the given method: The returned params
+// are appended to the URL of GET requests, other methods send body.
+func (gql *GraphQLRequest) encode(method string, variables map[string]string) (params url.Values, body string, err error) {
+	if gql.Query == "" {
+		return nil, "", errors.New("missing GraphQL Query")
+	}
+	query, _, err := FileData(gql.Query, variables)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if method == http.MethodGet {
+		params = url.Values{"query": {query}}
+		if gql.OperationName != "" {
+			params.Set("operationName", gql.OperationName)
+		}
+		if len(gql.Variables) > 0 {
+			vars, err := json.Marshal(gql.Variables)
+			if err != nil {
+				return nil, "", err
+			}
+			params.Set("variables", string(vars))
+		}
+		return params, "", nil
+	}
+
+	data, err := json.Marshal(struct {
+		Query         string                 `json:"query"`
+		OperationName string                 `json:"operationName,omitempty"`
+		Variables     map[string]interface{} `json:"variables,omitempty"`
+	}{query, gql.OperationName, gql.Variables})
+	if err != nil {
+		return nil, "", err
+	}
+	return nil, string(data), nil
+}
+
+// ----------------------------------------------------------------------------
+// GraphQL
+
+// GraphQL checks the response to a GraphQL request. GraphQL servers
+// typically report errors with a 200 status code in the errors array of
+// the response; the check fails if this array is not empty. The JSON checks
+// in Data are applied to the data object of the response, i.e. their
+// Elements are relative to data: The Element "order.status" selects the
+// status of the order in {"data": {"order": {"status": "OPEN"}}}.
+type GraphQL struct {
+	// AllowErrors does not fail the check on a non-empty errors array,
+	// e.g. to check partial data or Data of a failed operation.
+	AllowErrors bool `json:",omitempty"`
+
+	// Data are JSON checks applied to the data object.
+	Data []JSON `json:",omitempty"`
+}
+
+// Prepare implements Check's Prepare method.
+func (c *GraphQL) Prepare(t *Test) error {
+	for i := range c.Data {
+		if err := c.Data[i].Prepare(t); err != nil {
+			return fmt.Errorf("Data %d: %s", i, err)
+		}
+	}
+	return nil
+}
+
+var _ Preparable = &GraphQL{}
+
+// graphQLError is an entry in the errors array of a GraphQL response.
+type graphQLError struct {
+	Message string        `json:"message"`
+	Path    []interface{} `json:"path"`
+}
+
+func (e graphQLError) String() string {
+	if len(e.Path) == 0 {
+		return e.Message
+	}
+	path := make([]string, len(e.Path))
+	for i, p := range e.Path {
+		path[i] = fmt.Sprint(p)
+	}
+	return fmt.Sprintf("%s (at %s)", e.Message, strings.Join(path, "."))
+}
+
+// Execute implements Check's Execute method.
+func (c *GraphQL) Execute(t *Test) error {
+	if t.Response.BodyErr != nil {
+		return ErrBadBody
+	}
+
+	var resp struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []graphQLError  `json:"errors"`
+	}
+	body := []byte(t.Response.BodyStr)
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return augmentJSONError(err, body)
+	}
+
+	errs := errorlist.List{}
+	if !c.AllowErrors {
+		for _, e := range resp.Errors {
+			errs = append(errs, fmt.Errorf("GraphQL error: %s", e))
+		}
+	}
+
+	data := string(resp.Data)
+	if len(c.Data) > 0 && (data == "" || data == "null") {
+		errs = append(errs, errors.New("no data in response"))
+		return errs.AsError()
+	}
+	dataTest := &Test{Response: Response{BodyStr: data}}
+	for i := range c.Data {
+		if err := c.Data[i].Execute(dataTest); err != nil {
+			errs = append(errs, fmt.Errorf("data %s: %s", c.Data[i].Element, err))
+		}
+	}
+
+	return errs.AsError()
+}
@@ -0,0 +1,133 @@
+// Copyright 2018 Volker Dobler.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ht
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/vdobler/ht/errorlist"
+)
+
+// graphQLHandler echos the received GraphQL operation.
+func graphQLHandler(w http.ResponseWriter, r *http.Request) {
+	var op struct {
+		Query         string                 `json:"query"`
+		OperationName string                 `json:"operationName"`
+		Variables     map[string]interface{} `json:"variables"`
+	}
+	if r.Method == http.MethodGet {
+		op.Query = r.URL.Query().Get("query")
+		op.OperationName = r.URL.Query().Get("operationName")
+		if vars := r.URL.Query().Get("variables"); vars != "" {
+			json.Unmarshal([]byte(vars), &op.Variables)
+		}
+	} else {
+		body, _ := ioutil.ReadAll(r.Body)
+		if err := json.Unmarshal(body, &op); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if op.Query == "" {
+		fmt.Fprint(w, `{"errors": [{"message": "missing query"}]}`)
+		return
+	}
+	data, _ := json.Marshal(map[string]interface{}{"data": map[string]interface{}{
+		"method":    r.Method,
+		"type":      r.Header.Get("Content-Type"),
+		"accept":    r.Header.Get("Accept"),
+		"query":     op.Query,
+		"operation": op.OperationName,
+		"variables": op.Variables,
+	}})
+	w.Write(data)
+}
+
+func TestGraphQLRequest(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(graphQLHandler))
+	defer ts.Close()
+
+	gql := &GraphQLRequest{
+		Query:         "@file:@order.graphql:query Order($id: ID!) { order(id: $id) { status } }",
+		OperationName: "Order",
+		Variables: map[string]interface{}{
+			"id":     123,
+			"filter": map[string]interface{}{"open": true},
+		},
+	}
+	for i, method := range []string{"", "GET"} {
+		wantMethod, wantType := `"POST"`, `"application/json"`
+		if method == "GET" {
+			wantMethod, wantType = `"GET"`, `""`
+		}
+		test := &Test{
+			Request: Request{Method: method, URL: ts.URL, GraphQL: gql},
+			Checks: CheckList{&GraphQL{Data: []JSON{
+				{Element: "method", Condition: Condition{Equals: wantMethod}},
+				{Element: "type", Condition: Condition{Equals: wantType}},
+				{Element: "accept", Condition: Condition{Prefix: `"application/graphql-response+json`}},
+				{Element: "query", Condition: Condition{Prefix: `"query Order`}},
+				{Element: "operation", Condition: Condition{Equals: `"Order"`}},
+				{Element: "variables.id", Condition: Condition{Equals: "123"}},
+				{Element: "variables.filter.open", Condition: Condition{Equals: "true"}},
+			}}},
+		}
+		test.Run()
+		if test.Result.Status != Pass {
+			t.Errorf("%d. %s %s: %s", i, method, test.Result.Status, test.Result.Error)
+		}
+	}
+
+	test := &Test{Request: Request{URL: ts.URL, Body: "{}", GraphQL: gql}}
+	test.Run()
+	if test.Result.Status != Bogus {
+		t.Errorf("Got %s, want Bogus for Body with GraphQL", test.Result.Status)
+	}
+}
+
+func TestGraphQL(t *testing.T) {
+	ok := Response{BodyStr: `{"data": {"order": {"status": "OPEN", "items": [{"sku": "A1"}]}}}`}
+	partial := Response{BodyStr: `{"data": {"order": null},
+"errors": [{"message": "not allowed", "path": ["order", 0, "price"]}, {"message": "boom"}]}`}
+
+	for i, tc := range []TC{
+		{ok, &GraphQL{}, nil},
+		{ok, &GraphQL{Data: []JSON{
+			{Element: "order.status", Condition: Condition{Equals: `"OPEN"`}},
+			{Element: "order.items.0.sku"},
+		}}, nil},
+		{ok, &GraphQL{Data: []JSON{
+			{Element: "order.status", Condition: Condition{Equals: `"CLOSED"`}},
+		}}, fmt.Errorf(`data order.status: Unequal, was "\"OPEN\"" in "OPEN"`)},
+		{ok, &GraphQL{Data: []JSON{{Element: "order.id"}}},
+			fmt.Errorf("data order.id: Element order.id not found")},
+		{partial, &GraphQL{}, errCheck},
+		{partial, &GraphQL{AllowErrors: true}, nil},
+		{partial, &GraphQL{AllowErrors: true, Data: []JSON{
+			{Element: "order", Condition: Condition{Equals: "null"}},
+		}}, nil},
+		{Response{BodyStr: `{"errors": [{"message": "syntax error"}]}`},
+			&GraphQL{Data: []JSON{{Element: "order"}}}, errCheck},
+		{Response{BodyStr: `{"data": `}, &GraphQL{}, errCheck},
+		{ok, &GraphQL{Data: []JSON{{Element: "order", Condition: Condition{Regexp: "("}}}},
+			errDuringPrepare},
+	} {
+		runTest(t, i, tc)
+	}
+
+	check := &GraphQL{}
+	el, isList := check.Execute(&Test{Response: partial}).(errorlist.List)
+	if !isList || len(el) != 2 ||
+		el[0].Error() != "GraphQL error: not allowed (at order.0.price)" ||
+		el[1].Error() != "GraphQL error: boom" {
+		t.Errorf("Got %v", el)
+	}
+}
@@ -225,7 +225,8 @@ var _ Preparable = Redirect{}
 
 // RedirectChain checks steps in a redirect chain.
 // The check passes if all stations in Via have been accessed in order; the
-// actual redirect chain may hit additional stations.
+// actual redirect chain may hit additional stations. The individual
+// redirect responses of the chain can be checked with Hops.
 //
 // Note that this check can be used on tests with
 //     Request.FollowRedirects = true
@@ -235,11 +236,30 @@ type RedirectChain struct {
 	// Any URL may start with, end with or contain three dots "..." which
 	// indicate a suffix, prefix or suffix+prefix match like in the To
 	// field of Redirect.
-	Via []string
+	Via []string `json:",omitempty"`
+
+	// Hops are checks of individual redirect responses of the chain.
+	Hops []RedirectHop `json:",omitempty"`
+}
+
+// RedirectHop is a check of one redirect response in a redirect chain.
+type RedirectHop struct {
+	// Hop is the number of the redirect response in the chain: 1 is
+	// the response to the original request, 2 the response to the
+	// first redirected request and so on.
+	Hop int
+
+	// StatusCode is the expected status code of this hop. Zero means any.
+	StatusCode int `json:",omitempty"`
+
+	// Location and Body are applied to the Location header and the
+	// body of this hop.
+	Location Condition `json:",omitempty"`
+	Body     Condition `json:",omitempty"`
 }
 
 // Execute implements Check's Execute method.
-func (r RedirectChain) Execute(t *Test) error {
+func (r *RedirectChain) Execute(t *Test) error {
 	reds := t.Response.Redirections
 	if len(reds) == 0 {
 		return errors.New("No redirections at all")
@@ -254,15 +274,48 @@ func (r RedirectChain) Execute(t *Test) error {
 		j++
 	}
 
-	return nil
+	err := errorlist.List{}
+	for _, rh := range r.Hops {
+		if rh.Hop > len(t.Response.Hops) {
+			err = append(err, fmt.Errorf("Hop %d: only %d hops", rh.Hop,
+				len(t.Response.Hops)))
+			continue
+		}
+		hop := t.Response.Hops[rh.Hop-1]
+		if rh.StatusCode > 0 && hop.StatusCode != rh.StatusCode {
+			err = append(err, fmt.Errorf("Hop %d: got status code %d",
+				rh.Hop, hop.StatusCode))
+		}
+		if e := rh.Location.Fulfilled(hop.Header.Get("Location")); e != nil {
+			err = append(err, fmt.Errorf("Hop %d: bad Location: %s", rh.Hop, e))
+		}
+		if hop.BodyErr != nil {
+			err = append(err, fmt.Errorf("Hop %d: %s", rh.Hop, hop.BodyErr))
+		} else if e := rh.Body.Fulfilled(hop.BodyStr); e != nil {
+			err = append(err, fmt.Errorf("Hop %d: bad body: %s", rh.Hop, e))
+		}
+	}
+
+	return err.AsError()
 }
 
 // Prepare implements Check's Prepare method.
-func (r RedirectChain) Prepare(*Test) error {
-	if len(r.Via) == 0 {
-		return fmt.Errorf("Via must not be empty")
+func (r *RedirectChain) Prepare(*Test) error {
+	if len(r.Via) == 0 && len(r.Hops) == 0 {
+		return fmt.Errorf("Via and Hops must not both be empty")
+	}
+	for i := range r.Hops {
+		if r.Hops[i].Hop < 1 {
+			return fmt.Errorf("Hop must be positive, got %d", r.Hops[i].Hop)
+		}
+		if err := r.Hops[i].Location.Compile(); err != nil {
+			return err
+		}
+		if err := r.Hops[i].Body.Compile(); err != nil {
+			return err
+		}
 	}
 	return nil
 }
 
-var _ Preparable = RedirectChain{}
+var _ Preparable = &RedirectChain{}
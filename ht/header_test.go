@@ -6,6 +6,7 @@ package ht
 
 import (
 	"net/http"
+	"net/http/httptest"
 	"testing"
 )
 
@@ -116,3 +117,56 @@ func TestRedirectChain(t *testing.T) {
 		runTest(t, i, tc)
 	}
 }
+
+func ssoHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/sso":
+		http.SetCookie(w, &http.Cookie{Name: "sso", Value: "ticket"})
+		http.Redirect(w, r, "/login", http.StatusFound)
+	case "/login":
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "abc", HttpOnly: true})
+		http.Redirect(w, r, "/home", http.StatusSeeOther)
+	default:
+		w.Write([]byte("Home"))
+	}
+}
+
+func TestRedirectHops(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(ssoHandler))
+	defer ts.Close()
+
+	for i, tc := range []struct {
+		follow bool
+		check  Check
+		want   Status
+	}{
+		{true, &RedirectChain{Hops: []RedirectHop{
+			{Hop: 1, StatusCode: 302, Location: Condition{Equals: "/login"},
+				Body: Condition{Contains: "Found"}},
+			{Hop: 2, StatusCode: 303, Location: Condition{Equals: "/home"}},
+		}}, Pass},
+		{true, &RedirectChain{Hops: []RedirectHop{{Hop: 2, StatusCode: 302}}}, Fail},
+		{true, &RedirectChain{Hops: []RedirectHop{{Hop: 3}}}, Fail},
+		{true, &RedirectChain{Hops: []RedirectHop{{Hop: 0}}}, Bogus},
+		{true, &SetCookie{Name: "sso", Hop: 1, Value: Condition{Equals: "ticket"}}, Pass},
+		{true, &SetCookie{Name: "session", Hop: 2, Type: "httpOnly"}, Pass},
+		{true, &SetCookie{Name: "session", Hop: 1}, Fail},
+		{true, &SetCookie{Name: "session"}, Fail},
+		{true, &SetCookie{Name: "sso", Hop: 3}, Fail},
+		{false, &Body{Contains: `<a href="/login">Found</a>`}, Pass},
+		{false, &SetCookie{Name: "sso"}, Pass},
+	} {
+		test := Test{
+			Request: Request{
+				URL:             ts.URL + "/sso",
+				FollowRedirects: tc.follow,
+			},
+			Checks: []Check{tc.check},
+		}
+		test.Run()
+		if test.Result.Status != tc.want {
+			t.Errorf("%d. got %s (%v), want %s", i, test.Result.Status,
+				test.Result.Error, tc.want)
+		}
+	}
+}
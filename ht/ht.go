@@ -6,8 +6,6 @@ package ht
 
 import (
 	"bytes"
-	"compress/gzip"
-	"crypto/tls"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -15,8 +13,8 @@ import (
 	"io/ioutil"
 	"mime"
 	"mime/multipart"
-	"net"
 	"net/http"
+	"net/http/httptrace"
 	"net/textproto"
 	"net/url"
 	"path"
@@ -41,25 +39,6 @@ var (
 	DefaultClientTimeout = 10 * time.Second
 )
 
-// Transport is the http Transport used while making requests.
-// It is exposed to allow different Timeouts, less idle connections
-// or laxer TLS settings.
-var Transport = &http.Transport{
-	Proxy: http.ProxyFromEnvironment,
-	DialContext: (&net.Dialer{
-		Timeout:   30 * time.Second,
-		KeepAlive: 30 * time.Second,
-		DualStack: true,
-	}).DialContext,
-	MaxIdleConns:    100,
-	IdleConnTimeout: 90 * time.Second,
-	TLSClientConfig: &tls.Config{
-		InsecureSkipVerify: false,
-	},
-	TLSHandshakeTimeout:   10 * time.Second,
-	ExpectContinueTimeout: 1 * time.Second,
-}
-
 // Request is a HTTP request.
 type Request struct {
 	// Method is the HTTP method to use.
@@ -109,6 +88,11 @@ type Request struct {
 	// empty if Params are sent as multipart or form-urlencoded.
 	Body string `json:",omitempty"`
 
+	// GraphQL is a GraphQL operation to send. It is sent as a JSON body
+	// of a POST request if Method is empty. Body and body or multipart
+	// Params must not be used together with GraphQL.
+	GraphQL *GraphQLRequest `json:",omitempty"`
+
 	// FollowRedirects determines if automatic following of
 	// redirects should be done.
 	FollowRedirects bool `json:",omitempty"`
@@ -120,6 +104,10 @@ type Request struct {
 	BasicAuthUser string `json:",omitempty"`
 	BasicAuthPass string `json:",omitempty"`
 
+	// Auth configures other authentication schemes like OAuth2 and
+	// request signing.
+	Auth *Auth `json:",omitempty"`
+
 	// Chunked turns of setting of the Content-Length header resulting
 	// in chunked transfer encoding of POST bodies.
 	Chunked bool `json:",omitempty"`
@@ -127,6 +115,10 @@ type Request struct {
 	// Timeout of this request. If zero use DefaultClientTimeout.
 	Timeout time.Duration `json:",omitempty"`
 
+	// Client configures the HTTP client used to make this request,
+	// e.g. the protocol version, keep-alives or DNS overrides.
+	Client ClientConfig `json:",omitzero"`
+
 	Request    *http.Request `json:"-"` // the 'real' request
 	SentBody   string        `json:"-"` // the 'real' body
 	SentParams url.Values    `json:"-"` // the 'real' parameters
@@ -145,8 +137,81 @@ type Response struct {
 	BodyStr string `json:",omitempty"`
 	BodyErr error  `json:",omitempty"`
 
+	// WireSize is the number of body bytes received, i.e. before
+	// decoding the Content-Encoding.
+	WireSize int64 `json:",omitempty"`
+
+	// DecodedSize is the size of the decoded body in BodyStr.
+	DecodedSize int64 `json:",omitempty"`
+
 	// Redirections records the URLs of automatic GET requests due to redirects.
 	Redirections []string `json:",omitempty"`
+
+	// Hops are the redirect responses received while following a
+	// redirect chain, Hops[0] being the response to the original request.
+	Hops []Hop `json:",omitempty"`
+
+	// Challenge is the 401 response to the first request carrying the
+	// Digest challenge which was answered by resending the request with
+	// credentials (see Auth.Digest).
+	Challenge *Hop `json:",omitempty"`
+
+	// Timings breaks Duration down into the phases of the request.
+	Timings Timings
+}
+
+// Hop is a intermediate redirect response received while following a
+// redirect chain.
+type Hop struct {
+	// Method and URL of the request in this hop.
+	Method string `json:",omitempty"`
+	URL    string
+
+	// StatusCode and Header of the redirect response.
+	StatusCode int
+	Header     http.Header `json:",omitempty"`
+
+	// The (decoded) body of the redirect response and the error got
+	// while reading it.
+	BodyStr string `json:",omitempty"`
+	BodyErr error  `json:",omitempty"`
+}
+
+// Cookies returns the cookies set in this hop.
+func (h Hop) Cookies() []*http.Cookie {
+	return (&http.Response{Header: h.Header}).Cookies()
+}
+
+// newHop records the redirect response resp. Its body is read which is
+// okay as the http.Client just discards it.
+func newHop(resp *http.Response) Hop {
+	hop := Hop{
+		Method:     resp.Request.Method,
+		URL:        resp.Request.URL.String(),
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+	}
+	reader, err := decodeBody(resp.Body, contentEncoding(resp))
+	if err != nil {
+		hop.BodyErr = err
+		return hop
+	}
+	body, err := ioutil.ReadAll(reader)
+	reader.Close()
+	hop.BodyStr, hop.BodyErr = string(body), err
+	return hop
+}
+
+// Timings are the durations of the individual phases of a HTTP request.
+// Phases which did not happen, e.g. DNS lookup and connection setup on
+// reused connections, have a zero duration. The phases of all requests
+// of a followed redirect chain are summed up.
+type Timings struct {
+	DNS      time.Duration `json:",omitempty"` // DNS lookup
+	Connect  time.Duration `json:",omitempty"` // TCP connection setup
+	TLS      time.Duration `json:",omitempty"` // TLS handshake
+	TTFB     time.Duration `json:",omitempty"` // from start until first response byte
+	Transfer time.Duration `json:",omitempty"` // from first byte until body is read
 }
 
 // Body returns a reader of the response body.
@@ -210,6 +275,10 @@ type Test struct {
 	// Jar is the cookie jar to use
 	Jar *cookiejar.Jar `json:"-"`
 
+	// Tokens caches the OAuth2 access tokens. It is typically shared by
+	// all tests of a suite.
+	Tokens *TokenCache `json:"-"`
+
 	// Variables contains name/value-pairs used for variable substitution
 	// in files read in, e.g. for Request.Body = "@vfile:/path/to/file".
 	Variables map[string]string `json:",omitempty"`
@@ -370,6 +439,24 @@ outer:
 		return err
 	}
 
+	if err := m.Client.merge(r.Client); err != nil {
+		return err
+	}
+
+	if r.Auth != nil {
+		if m.Auth != nil {
+			return errors.New("Won't overwrite Auth")
+		}
+		m.Auth = r.Auth
+	}
+
+	if r.GraphQL != nil {
+		if m.GraphQL != nil {
+			return errors.New("Won't overwrite GraphQL")
+		}
+		m.GraphQL = r.GraphQL
+	}
+
 	return nil
 }
 
@@ -385,8 +472,12 @@ outer:
 //       Header     Merge by key
 //       Cookies    Merge by cookie name
 //       Body       Only one may be nonempty
+//       GraphQL    Only one may be set
 //       FollowRdr  Last wins
 //       Chunked    Last wins
+//       BasicAuth  Only one user and password may be nonempty
+//       Auth       Only one may be set
+//       Client     Flags or-ed, limits maxed, other fields must not conflict
 //     Checks       Append all checks
 //     DataExtraction Merge, same keys must have same value
 //     TestVars     Use values from first only.
@@ -396,7 +487,6 @@ outer:
 //     Timeout      Use largets
 //     Verbosity    Use largets
 //     PreSleep     Summ of all;  same for InterSleep and PostSleep
-//     ClientPool   ignore
 func Merge(tests ...*Test) (*Test, error) {
 	m := Test{}
 
@@ -693,11 +783,21 @@ func (t *Test) prepareRequest() error {
 		t.Request.Request.Header.Set("Content-Type", contentType)
 	}
 	if t.Request.Request.Header.Get("Accept") == "" {
-		t.Request.Request.Header.Set("Accept", DefaultAccept)
+		if t.Request.GraphQL != nil {
+			t.Request.Request.Header.Set("Accept", GraphQLAccept)
+		} else {
+			t.Request.Request.Header.Set("Accept", DefaultAccept)
+		}
 	}
 	if t.Request.Request.Header.Get("User-Agent") == "" {
 		t.Request.Request.Header.Set("User-Agent", DefaultUserAgent)
 	}
+	if t.Request.Request.Header.Get("Accept-Encoding") == "" &&
+		t.Request.Request.Header.Get("Range") == "" && t.Request.Request.Method != "HEAD" {
+		// Request compression explicitly (instead of the http.Transport)
+		// to be able to record the WireSize and to decode more codings.
+		t.Request.Request.Header.Set("Accept-Encoding", DefaultAcceptEncoding)
+	}
 	for _, cookie := range t.Request.Cookies {
 		t.Request.Request.AddCookie(&http.Cookie{Name: cookie.Name, Value: cookie.Value})
 	}
@@ -705,11 +805,23 @@ func (t *Test) prepareRequest() error {
 	if t.Request.BasicAuthUser != "" {
 		t.Request.Request.SetBasicAuth(t.Request.BasicAuthUser, t.Request.BasicAuthPass)
 	}
+	if auth := t.Request.Auth; auth != nil {
+		if err := auth.validate(); err != nil {
+			t.errorf("%s", err.Error())
+			return err
+		}
+	}
 
 	if t.Request.Timeout <= 0 {
 		t.Request.Timeout = DefaultClientTimeout
 	}
 
+	transport, err := t.Request.Client.transport(t.Variables)
+	if err != nil {
+		err = fmt.Errorf("failed preparing client: %s", err)
+		t.errorf("%s", err.Error())
+		return err
+	}
 	if t.Request.FollowRedirects {
 		cr := func(req *http.Request, via []*http.Request) error {
 			if len(via) >= 10 {
@@ -722,16 +834,19 @@ func (t *Test) prepareRequest() error {
 				}
 			}
 			t.Response.Redirections = append(t.Response.Redirections, req.URL.String())
+			if req.Response != nil {
+				t.Response.Hops = append(t.Response.Hops, newHop(req.Response))
+			}
 			return nil
 		}
 		t.client = &http.Client{
-			Transport:     Transport,
+			Transport:     transport,
 			CheckRedirect: cr,
 			Timeout:       t.Request.Timeout,
 		}
 	} else {
 		t.client = &http.Client{
-			Transport:     Transport,
+			Transport:     transport,
 			CheckRedirect: dontFollowRedirects,
 			Jar:           nil,
 			Timeout:       t.Request.Timeout,
@@ -751,6 +866,9 @@ func (t *Test) newRequest() (contentType string, err error) {
 	// Set efaults for the request method and the parameter transmission type.
 	if t.Request.Method == "" {
 		t.Request.Method = http.MethodGet
+		if t.Request.GraphQL != nil {
+			t.Request.Method = http.MethodPost
+		}
 	}
 	if t.Request.ParamsAs == "" {
 		t.Request.ParamsAs = "URL"
@@ -822,6 +940,30 @@ func (t *Test) newRequest() (contentType string, err error) {
 		t.Request.SentBody = bodydata
 	}
 
+	// The GraphQL operation.
+	if gql := t.Request.GraphQL; gql != nil {
+		if t.Request.SentBody != "" {
+			return "", fmt.Errorf("body or body/multipart parameters used with GraphQL")
+		}
+		params, body, err := gql.encode(t.Request.Method, t.Variables)
+		if err != nil {
+			return "", err
+		}
+		if len(params) > 0 {
+			for param, vals := range params {
+				t.Request.SentParams[param] = append(t.Request.SentParams[param], vals...)
+			}
+			if strings.Contains(rurl, "?") {
+				rurl += "&" + params.Encode()
+			} else {
+				rurl += "?" + params.Encode()
+			}
+		} else {
+			t.Request.SentBody = body
+			contentType = "application/json"
+		}
+	}
+
 	// body := ioutil.NopCloser(strings.NewReader(t.Request.SentBody))
 	t.Request.Request, err = http.NewRequest(t.Request.Method, rurl, nil /*body*/)
 	if err != nil {
@@ -881,18 +1023,20 @@ func FileData(s string, variables map[string]string) (data string, basename stri
 	return data, basename, nil
 }
 
-var (
-	errRedirectNofollow = errors.New("we do not follow redirects")
-)
-
 // executeRequest performs the HTTP request defined in t which must have been
 // prepared by Prepare. Executing an unprepared Test results will panic.
 func (t *Test) executeRequest() error {
 	t.infof("%s %q", t.Request.Request.Method, t.Request.Request.URL.String())
 
 	var err error
-	abortedRedirection := false
 	t.Response.Redirections = nil
+	t.Response.Hops = nil
+	t.Response.Challenge = nil
+
+	// Token acquisition and signing are not part of the measured request.
+	if err := t.authorize(); err != nil {
+		return err
+	}
 
 	start := time.Now()
 
@@ -904,37 +1048,43 @@ func (t *Test) executeRequest() error {
 		t.Request.Request.Body = ioutil.NopCloser(strings.NewReader(t.Request.SentBody))
 	}
 
-	resp, err := t.client.Do(t.Request.Request)
-	if ue, ok := err.(*url.Error); ok && ue.Err == errRedirectNofollow &&
-		!t.Request.FollowRedirects {
-		// Clear err if it is just our redirect non-following policy.
-		err = nil
-		abortedRedirection = true
-		t.debugf("Aborted redirect chain")
-	}
+	tracer := &requestTracer{}
+	req := t.Request.Request.WithContext(
+		httptrace.WithClientTrace(t.Request.Request.Context(), tracer.clientTrace()))
 
+	resp, err := t.client.Do(req)
+	if err == nil && resp.StatusCode == http.StatusUnauthorized {
+		resp, err = t.answerChallenge(req, resp)
+	}
 	t.Response.Response = resp
 	msg := "okay"
 	if err == nil {
-		if t.Request.Request.Method == "HEAD" || abortedRedirection {
+		if !t.Request.FollowRedirects && resp.StatusCode/100 == 3 &&
+			resp.Header.Get("Location") != "" {
+			t.debugf("Not following redirect to %s", resp.Header.Get("Location"))
+		}
+		if t.Request.Request.Method == "HEAD" {
+			resp.Body.Close()
 			goto done
 		}
-		var reader io.ReadCloser
-		switch resp.Header.Get("Content-Encoding") {
-		case "gzip":
-			reader, err = gzip.NewReader(resp.Body)
-			if err != nil {
-				t.Response.BodyErr = err
-				goto done
-			}
-			t.debugf("Unzipping gzip body")
-		default:
-			reader = resp.Body
+		wire := &countingReader{r: resp.Body}
+		encoding := contentEncoding(resp)
+		reader, de := decodeBody(wire, encoding)
+		if de != nil {
+			t.Response.BodyErr = de
+			resp.Body.Close()
+			goto done
+		}
+		if encoding != "" {
+			t.debugf("Decoding %s body", encoding)
 		}
 		bb, be := ioutil.ReadAll(reader)
 		t.Response.BodyStr = string(bb)
 		t.Response.BodyErr = be
+		t.Response.WireSize = wire.n
+		t.Response.DecodedSize = int64(len(bb))
 		reader.Close()
+		resp.Body.Close()
 		if t.Execution.Verbosity >= 4 {
 			buf := &bytes.Buffer{}
 			t.Response.Response.Header.Write(buf)
@@ -950,6 +1100,7 @@ func (t *Test) executeRequest() error {
 
 done:
 	t.Response.Duration = time.Since(start)
+	t.Response.Timings = tracer.timings(start, start.Add(t.Response.Duration))
 
 	for i, via := range t.Response.Redirections {
 		t.infof("Redirection %d: %s", i+1, via)
@@ -1156,8 +1307,10 @@ func (p Execution) Skip() bool {
 	return p.Tries < 0
 }
 
+// dontFollowRedirects makes the http.Client return the redirect response
+// itself with its body still readable.
 func dontFollowRedirects(*http.Request, []*http.Request) error {
-	return errRedirectNofollow
+	return http.ErrUseLastResponse
 }
 
 // newReplacer produces a strings.Replacer which
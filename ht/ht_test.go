@@ -740,4 +740,7 @@ func TestAsJSON(t *testing.T) {
 		t.Errorf("Unexpected error %q (%T)", err, err)
 	}
 	fmt.Println(string(ser))
+	if strings.Contains(string(ser), `"Client"`) {
+		t.Errorf("Zero ClientConfig serialized")
+	}
 }
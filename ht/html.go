@@ -528,6 +528,7 @@ func (c *Links) Execute(t *Test) error {
 				BasicAuthUser:   t.Request.BasicAuthUser,
 				BasicAuthPass:   t.Request.BasicAuthPass,
 				Timeout:         timeout,
+				Client:          t.Request.Client,
 			},
 			Checks: CheckList{
 				StatusCode{Expect: 200},
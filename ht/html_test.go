@@ -483,8 +483,8 @@ func TestLinksMixedContent(t *testing.T) {
 	defer ts1.Close()
 	ts2 := httptest.NewTLSServer(http.HandlerFunc(htmlDummyLinksHandler))
 	defer ts2.Close()
-	Transport.TLSClientConfig.InsecureSkipVerify = true
-	defer func() { Transport.TLSClientConfig.InsecureSkipVerify = false }()
+	SkipTLSVerify = true
+	defer func() { SkipTLSVerify = false }()
 	u1, _ := url.Parse(ts1.URL + "/foo")
 	u2, _ := url.Parse(ts2.URL + "/foo")
 	body := fmt.Sprintf(mixedContentBody, u1.Host, u2.Host, u1.Host)
@@ -15,12 +15,14 @@ import (
 	"strings"
 
 	"github.com/nytlabs/gojee"
+	"github.com/vdobler/ht/errorlist"
 	hjson "github.com/vdobler/ht/internal/hjson"
 )
 
 func init() {
 	RegisterCheck(&JSONExpr{})
 	RegisterCheck(&JSON{})
+	RegisterCheck(&JSONSchema{})
 }
 
 // ----------------------------------------------------------------------------
@@ -134,6 +136,9 @@ func (c *JSONExpr) Execute(t *Test) error {
 //
 // It is typically not useful to combine schema validation with checking
 // a condition.
+//
+// The schema here is not a JSON Schema, use the JSONSchema check to
+// validate against a real JSON Schema.
 type JSON struct {
 	// Element in the flattened JSON map to apply the Condition to.
 	// E.g.  "foo.2" in "{foo: [4,5,6,7]}" would be 6.
@@ -297,6 +302,88 @@ func (c *JSON) Execute(t *Test) error {
 	return nil
 }
 
+// ----------------------------------------------------------------------------
+// JSONSchema
+
+// JSONSchema validates a JSON document (or an element of it) against a
+// JSON Schema as described on http://json-schema.org.
+// Draft-07 and 2020-12 schemas are supported including required, enum,
+// const, pattern, format, allOf, anyOf, oneOf, not, if/then/else and local
+// $refs like "#/definitions/address" or "#/$defs/address".
+// Unsupported are unevaluatedProperties, unevaluatedItems and $refs to
+// other documents.
+//
+// The regular expressions in pattern and patternProperties use Go's RE2
+// syntax, not ECMA-262: Lookarounds and backreferences like "^(?!admin)"
+// are rejected when preparing the check.
+//
+// All violations are reported, each prefixed with the JSON pointer of the
+// offending element, e.g.
+//     #/items/3/price: got string, want number
+//     #/items/5: missing required property "name"
+type JSONSchema struct {
+	// Element in the flattened JSON map to validate, see JSON for
+	// details. An empty Element validates the whole document.
+	Element string `json:",omitempty"`
+
+	// Sep is the separator in Element, a zero value is equivalent
+	// to ".".
+	Sep string `json:",omitempty"`
+
+	// Schema is the JSON Schema document. It may be given inline or
+	// read from a file with the @file: and @vfile: syntax, e.g.
+	// "@file:{{TEST_DIR}}/schema.json".
+	Schema string
+
+	schema *jsonSchema
+}
+
+// Prepare implements Check's Prepare method.
+func (c *JSONSchema) Prepare(t *Test) error {
+	if c.Schema == "" {
+		return fmt.Errorf("missing Schema")
+	}
+	data, _, err := FileData(c.Schema, t.Variables)
+	if err != nil {
+		return err
+	}
+	c.schema, err = newJSONSchema([]byte(data))
+	return err
+}
+
+var _ Preparable = &JSONSchema{}
+
+// Execute implements Check's Execute method.
+func (c *JSONSchema) Execute(t *Test) error {
+	if t.Response.BodyErr != nil {
+		return ErrBadBody
+	}
+
+	sep := "."
+	if c.Sep != "" {
+		sep = c.Sep
+	}
+
+	var v interface{}
+	body := []byte(t.Response.BodyStr)
+	if err := json.Unmarshal(body, &v); err != nil {
+		return augmentJSONError(err, body)
+	}
+	raw, err := findJSONelement(body, c.Element, sep)
+	if err != nil {
+		return err
+	}
+	instance, err := decodeJSONInstance(raw)
+	if err != nil {
+		return err
+	}
+
+	if errs := c.schema.validate(instance); len(errs) > 0 {
+		return errorlist.List(errs)
+	}
+	return nil
+}
+
 // augmentJSONError tries to augment err by a line/column number pointing into
 // jsonData. encoding/json.Unmarshal's error for syntax errors in the JSON is
 // very hard to use as a human, augmenting the error with a line number makes
@@ -130,6 +130,42 @@ func TestJSONSchema(t *testing.T) {
 	}
 }
 
+var jsonSchemaCheckTests = []TC{
+	{jre, &JSONSchema{Schema: `{"type": "object", "required": ["foo", "bar"]}`}, nil},
+	{jre, &JSONSchema{Schema: `{
+            type: object
+            properties: {
+                foo: {type: "integer", minimum: 1, maximum: 9}
+                bar: {type: "array", items: {type: ["integer", "string"]}}
+                uuid: {type: "string", format: "uuid"}
+                nil: {type: "null"}
+            }
+        }`}, nil},
+	{jre, &JSONSchema{Schema: `{"required": ["foo", "qux", "zap"]}`},
+		errors.New(`#: missing required property "qux"; ` + "\u2029" +
+			`#: missing required property "zap"`)},
+	{jre, &JSONSchema{Element: "bar", Schema: `{"items": {"type": "integer"}}`},
+		errors.New(`#/1: got string, want integer`)},
+	{jre, &JSONSchema{Element: "bar.1", Schema: `{"enum": ["qux", "quz"]}`}, nil},
+	{jre, &JSONSchema{Element: "pi", Schema: `{"type": "integer"}`},
+		errors.New(`#: got number, want integer`)},
+	{jre, &JSONSchema{Schema: `{"properties": {"foo": {"$ref": "#/definitions/small"}},
+            "definitions": {"small": {"maximum": 3}}}`},
+		errors.New(`#/foo: 5 greater than maximum 3`)},
+	{jre, &JSONSchema{Schema: `{"properties": {"foo": {"$ref": "#/definitions/nope"}}}`},
+		errDuringPrepare},
+	{jre, &JSONSchema{Schema: `{"pattern": "(("}`}, errDuringPrepare},
+	{jre, &JSONSchema{}, errDuringPrepare},
+	{jrx, &JSONSchema{Schema: `{}`}, errCheck},
+	{jrm, &JSONSchema{Schema: `{"type": "string"}`}, nil},
+}
+
+func TestJSONSchemaCheck(t *testing.T) {
+	for i, tc := range jsonSchemaCheckTests {
+		runTest(t, i, tc)
+	}
+}
+
 var findJSONelementTests = []struct {
 	doc  string
 	elem string
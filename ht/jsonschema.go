@@ -0,0 +1,818 @@
+// Copyright 2018 Volker Dobler.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// jsonschema.go contains a validator for JSON Schema documents.
+
+package ht
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	hjson "github.com/vdobler/ht/internal/hjson"
+)
+
+// jsonSchema is a compiled JSON Schema. The validator understands the
+// keywords of draft-07 and of 2020-12 with the following exceptions:
+// unevaluatedProperties, unevaluatedItems, $dynamicRef and references
+// to external documents are not supported. Of the format keyword only
+// date-time, date, time, email, hostname, ipv4, ipv6, uri,
+// uri-reference, uuid and regex are checked, other formats are
+// treated as annotations and always validate. The nullable keyword of
+// OpenAPI 3.0 schemas is honoured.
+type jsonSchema struct {
+	// root is the whole decoded document, all $refs are resolved
+	// against root.
+	root interface{}
+
+	// schema is the actual schema applied to an instance. Normally it
+	// is root but it may be any subschema, e.g. the schema of a
+	// response in an OpenAPI specification.
+	schema interface{}
+
+	patterns map[string]*regexp.Regexp
+	anchors  map[string]interface{}
+}
+
+// schemaViolation describes a single violation of a JSON Schema.
+type schemaViolation struct {
+	Pointer string // JSON pointer to the offending element.
+	Message string
+}
+
+func (v schemaViolation) Error() string {
+	return fmt.Sprintf("#%s: %s", v.Pointer, v.Message)
+}
+
+// maxSchemaDepth limits the nesting of schema application and thus
+// detects infinitely recursive $refs.
+const maxSchemaDepth = 200
+
+// newJSONSchema parses data as a JSON Schema. As JSON is a subset of Hjson
+// the schema may be written in Hjson too.
+func newJSONSchema(data []byte) (*jsonSchema, error) {
+	var root interface{}
+	if err := hjson.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("schema is not valid JSON: %s", err)
+	}
+	return compileJSONSchema(root, root)
+}
+
+// compileJSONSchema prepares schema which is part of the document root
+// for validation.
+func compileJSONSchema(root, schema interface{}) (*jsonSchema, error) {
+	s := &jsonSchema{
+		root:     root,
+		schema:   schema,
+		patterns: make(map[string]*regexp.Regexp),
+		anchors:  make(map[string]interface{}),
+	}
+	var refs []string
+	if err := s.collect(schema, &refs); err != nil {
+		return nil, err
+	}
+	// Schemas reached through a $ref only (e.g. the components of an
+	// OpenAPI document) are collected too; refs grows while doing so.
+	seen := make(map[string]bool)
+	for i := 0; i < len(refs); i++ {
+		ref := refs[i]
+		if seen[ref] {
+			continue
+		}
+		seen[ref] = true
+		target, err := s.resolve(ref)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.collect(target, &refs); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// schemaKeywords are the keywords whose value is a schema or a list of
+// schemas, schemaMapKeywords those whose value maps names to schemas.
+// All other keywords (e.g. const, enum, default or examples) contain
+// instance data or plain values and are not walked by collect.
+var (
+	schemaKeywords = []string{
+		"additionalItems", "additionalProperties", "allOf", "anyOf",
+		"contains", "else", "if", "items", "not", "oneOf", "prefixItems",
+		"propertyNames", "then",
+	}
+	schemaMapKeywords = []string{
+		"$defs", "definitions", "dependencies", "dependentSchemas",
+		"patternProperties", "properties",
+	}
+)
+
+// collect walks the subschemas of the schema node, compiles all regular
+// expressions, records anchors and collects all $refs.
+func (s *jsonSchema) collect(node interface{}, refs *[]string) error {
+	switch n := node.(type) {
+	case []interface{}:
+		for _, e := range n {
+			if err := s.collect(e, refs); err != nil {
+				return err
+			}
+		}
+	case map[string]interface{}:
+		if p, ok := n["pattern"].(string); ok {
+			if err := s.compilePattern(p); err != nil {
+				return err
+			}
+		}
+		if pp, ok := n["patternProperties"].(map[string]interface{}); ok {
+			for p := range pp {
+				if err := s.compilePattern(p); err != nil {
+					return err
+				}
+			}
+		}
+		if ref, ok := n["$ref"].(string); ok {
+			*refs = append(*refs, ref)
+		}
+		if a, ok := n["$anchor"].(string); ok {
+			s.anchors[a] = n
+		}
+		if id, ok := n["$id"].(string); ok && strings.HasPrefix(id, "#") {
+			s.anchors[id[1:]] = n
+		}
+		for _, kw := range schemaKeywords {
+			if err := s.collect(n[kw], refs); err != nil {
+				return err
+			}
+		}
+		for _, kw := range schemaMapKeywords {
+			sub, _ := n[kw].(map[string]interface{})
+			for _, v := range sub {
+				if err := s.collect(v, refs); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (s *jsonSchema) compilePattern(p string) error {
+	if _, ok := s.patterns[p]; ok {
+		return nil
+	}
+	re, err := regexp.Compile(p)
+	if err != nil {
+		return fmt.Errorf("bad pattern %q in schema: %s", p, err)
+	}
+	s.patterns[p] = re
+	return nil
+}
+
+// resolve the reference ref. Only references into the same document are
+// supported.
+func (s *jsonSchema) resolve(ref string) (interface{}, error) {
+	if !strings.HasPrefix(ref, "#") {
+		return nil, fmt.Errorf("unsupported non-local $ref %q", ref)
+	}
+	fragment, err := url.PathUnescape(ref[1:])
+	if err != nil {
+		return nil, fmt.Errorf("malformed $ref %q: %s", ref, err)
+	}
+	if fragment != "" && fragment[0] != '/' {
+		if a, ok := s.anchors[fragment]; ok {
+			return a, nil
+		}
+		return nil, fmt.Errorf("no anchor for $ref %q", ref)
+	}
+
+	node := s.root
+	for _, tok := range splitJSONPointer(fragment) {
+		switch n := node.(type) {
+		case map[string]interface{}:
+			v, ok := n[tok]
+			if !ok {
+				return nil, fmt.Errorf("cannot resolve $ref %q", ref)
+			}
+			node = v
+		case []interface{}:
+			i, err := strconv.Atoi(tok)
+			if err != nil || i < 0 || i >= len(n) {
+				return nil, fmt.Errorf("cannot resolve $ref %q", ref)
+			}
+			node = n[i]
+		default:
+			return nil, fmt.Errorf("cannot resolve $ref %q", ref)
+		}
+	}
+	return node, nil
+}
+
+// splitJSONPointer splits the JSON pointer p into its unescaped
+// reference tokens.
+func splitJSONPointer(p string) []string {
+	if p == "" {
+		return nil
+	}
+	parts := strings.Split(p[1:], "/")
+	for i, part := range parts {
+		part = strings.Replace(part, "~1", "/", -1)
+		parts[i] = strings.Replace(part, "~0", "~", -1)
+	}
+	return parts
+}
+
+// appendJSONPointer appends the reference token tok to the pointer p.
+func appendJSONPointer(p string, tok string) string {
+	tok = strings.Replace(tok, "~", "~0", -1)
+	tok = strings.Replace(tok, "/", "~1", -1)
+	return p + "/" + tok
+}
+
+// decodeJSONInstance decodes data into a generic value suitable for
+// validation. Numbers are kept as json.Number to allow exact integer
+// detection.
+func decodeJSONInstance(data []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v interface{}
+	err := dec.Decode(&v)
+	return v, err
+}
+
+// validate instance against s and return all violations found.
+func (s *jsonSchema) validate(instance interface{}) []error {
+	return s.apply(s.schema, instance, "", 0)
+}
+
+// apply schema to the instance located at ptr.
+func (s *jsonSchema) apply(schema interface{}, instance interface{}, ptr string, depth int) []error {
+	if depth > maxSchemaDepth {
+		return []error{schemaViolation{ptr, "schema nesting too deep (recursive $ref?)"}}
+	}
+
+	var errs []error
+	fail := func(format string, a ...interface{}) {
+		errs = append(errs, schemaViolation{ptr, fmt.Sprintf(format, a...)})
+	}
+
+	if b, ok := schema.(bool); ok {
+		if !b {
+			fail("no value allowed here")
+		}
+		return errs
+	}
+	sch, ok := schema.(map[string]interface{})
+	if !ok {
+		return errs // Malformed subschemas accept everything.
+	}
+
+	if ref, ok := sch["$ref"].(string); ok {
+		target, err := s.resolve(ref)
+		if err != nil {
+			fail("%s", err)
+		} else {
+			errs = append(errs, s.apply(target, instance, ptr, depth+1)...)
+		}
+	}
+
+	if nullable, _ := sch["nullable"].(bool); nullable && instance == nil {
+		return errs
+	}
+
+	// Generic keywords.
+	if t, ok := sch["type"]; ok {
+		if !matchesSchemaType(t, instance) {
+			fail("got %s, want %s", jsonTypeOf(instance), schemaTypeString(t))
+			return errs // Further type-specific checks are pointless.
+		}
+	}
+	if enum, ok := sch["enum"].([]interface{}); ok {
+		found := false
+		for _, e := range enum {
+			if jsonEqual(e, instance) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			fail("value %s not in enum %s", jsonShort(instance), jsonShort(enum))
+		}
+	}
+	if c, ok := sch["const"]; ok && !jsonEqual(c, instance) {
+		fail("value %s is not the constant %s", jsonShort(instance), jsonShort(c))
+	}
+
+	// Combinators.
+	if all, ok := sch["allOf"].([]interface{}); ok {
+		for _, sub := range all {
+			errs = append(errs, s.apply(sub, instance, ptr, depth+1)...)
+		}
+	}
+	if anyOf, ok := sch["anyOf"].([]interface{}); ok {
+		var suberrs []error
+		matched := false
+		for _, sub := range anyOf {
+			e := s.apply(sub, instance, ptr, depth+1)
+			if len(e) == 0 {
+				matched = true
+				break
+			}
+			suberrs = append(suberrs, e...)
+		}
+		if !matched {
+			fail("matches none of the schemas in anyOf (%s)", joinErrors(suberrs))
+		}
+	}
+	if oneOf, ok := sch["oneOf"].([]interface{}); ok {
+		matching := []string{}
+		var suberrs []error
+		for i, sub := range oneOf {
+			e := s.apply(sub, instance, ptr, depth+1)
+			if len(e) == 0 {
+				matching = append(matching, strconv.Itoa(i))
+			}
+			suberrs = append(suberrs, e...)
+		}
+		if len(matching) == 0 {
+			fail("matches none of the schemas in oneOf (%s)", joinErrors(suberrs))
+		} else if len(matching) > 1 {
+			fail("matches schemas %s in oneOf, want exactly one",
+				strings.Join(matching, ", "))
+		}
+	}
+	if not, ok := sch["not"]; ok {
+		if len(s.apply(not, instance, ptr, depth+1)) == 0 {
+			fail("must not match schema in not")
+		}
+	}
+	if cond, ok := sch["if"]; ok {
+		if len(s.apply(cond, instance, ptr, depth+1)) == 0 {
+			if then, ok := sch["then"]; ok {
+				errs = append(errs, s.apply(then, instance, ptr, depth+1)...)
+			}
+		} else {
+			if els, ok := sch["else"]; ok {
+				errs = append(errs, s.apply(els, instance, ptr, depth+1)...)
+			}
+		}
+	}
+
+	// Type specific keywords.
+	switch inst := instance.(type) {
+	case map[string]interface{}:
+		errs = append(errs, s.applyObject(sch, inst, ptr, depth)...)
+	case []interface{}:
+		errs = append(errs, s.applyArray(sch, inst, ptr, depth)...)
+	case string:
+		errs = append(errs, s.applyString(sch, inst, ptr)...)
+	case bool, nil:
+	default:
+		if x, ok := jsonFloat(inst); ok {
+			errs = append(errs, applyNumber(sch, x, ptr)...)
+		}
+	}
+
+	return errs
+}
+
+func (s *jsonSchema) applyObject(sch map[string]interface{}, obj map[string]interface{}, ptr string, depth int) []error {
+	var errs []error
+	fail := func(format string, a ...interface{}) {
+		errs = append(errs, schemaViolation{ptr, fmt.Sprintf(format, a...)})
+	}
+
+	if req, ok := sch["required"].([]interface{}); ok {
+		for _, r := range req {
+			name, _ := r.(string)
+			if _, present := obj[name]; !present {
+				fail("missing required property %q", name)
+			}
+		}
+	}
+	if n, ok := jsonInt(sch["minProperties"]); ok && len(obj) < n {
+		fail("got %d properties, want at least %d", len(obj), n)
+	}
+	if n, ok := jsonInt(sch["maxProperties"]); ok && len(obj) > n {
+		fail("got %d properties, want at most %d", len(obj), n)
+	}
+
+	// Dependencies: draft-07 dependencies and 2020-12 dependentRequired
+	// and dependentSchemas.
+	dependentRequired := func(prop string, deps []interface{}) {
+		for _, d := range deps {
+			name, _ := d.(string)
+			if _, present := obj[name]; !present {
+				fail("property %q requires property %q", prop, name)
+			}
+		}
+	}
+	if deps, ok := sch["dependencies"].(map[string]interface{}); ok {
+		for _, prop := range sortedKeys(deps) {
+			if _, present := obj[prop]; !present {
+				continue
+			}
+			if list, ok := deps[prop].([]interface{}); ok {
+				dependentRequired(prop, list)
+			} else {
+				errs = append(errs, s.apply(deps[prop], obj, ptr, depth+1)...)
+			}
+		}
+	}
+	if deps, ok := sch["dependentRequired"].(map[string]interface{}); ok {
+		for _, prop := range sortedKeys(deps) {
+			if _, present := obj[prop]; present {
+				list, _ := deps[prop].([]interface{})
+				dependentRequired(prop, list)
+			}
+		}
+	}
+	if deps, ok := sch["dependentSchemas"].(map[string]interface{}); ok {
+		for _, prop := range sortedKeys(deps) {
+			if _, present := obj[prop]; present {
+				errs = append(errs, s.apply(deps[prop], obj, ptr, depth+1)...)
+			}
+		}
+	}
+
+	props, _ := sch["properties"].(map[string]interface{})
+	patternProps, _ := sch["patternProperties"].(map[string]interface{})
+	additional, hasAdditional := sch["additionalProperties"]
+	propertyNames, hasPropertyNames := sch["propertyNames"]
+
+	for _, name := range sortedKeys(obj) {
+		value := obj[name]
+		vptr := appendJSONPointer(ptr, name)
+		if hasPropertyNames {
+			for _, e := range s.apply(propertyNames, name, ptr, depth+1) {
+				fail("property name %q: %s", name, e.(schemaViolation).Message)
+			}
+		}
+		evaluated := false
+		if sub, ok := props[name]; ok {
+			evaluated = true
+			errs = append(errs, s.apply(sub, value, vptr, depth+1)...)
+		}
+		for _, pattern := range sortedKeys(patternProps) {
+			if s.patterns[pattern].MatchString(name) {
+				evaluated = true
+				errs = append(errs, s.apply(patternProps[pattern], value, vptr, depth+1)...)
+			}
+		}
+		if !evaluated && hasAdditional {
+			if allowed, ok := additional.(bool); ok && !allowed {
+				fail("additional property %q not allowed", name)
+			} else {
+				errs = append(errs, s.apply(additional, value, vptr, depth+1)...)
+			}
+		}
+	}
+
+	return errs
+}
+
+func (s *jsonSchema) applyArray(sch map[string]interface{}, arr []interface{}, ptr string, depth int) []error {
+	var errs []error
+	fail := func(format string, a ...interface{}) {
+		errs = append(errs, schemaViolation{ptr, fmt.Sprintf(format, a...)})
+	}
+
+	if n, ok := jsonInt(sch["minItems"]); ok && len(arr) < n {
+		fail("got %d items, want at least %d", len(arr), n)
+	}
+	if n, ok := jsonInt(sch["maxItems"]); ok && len(arr) > n {
+		fail("got %d items, want at most %d", len(arr), n)
+	}
+	if unique, ok := sch["uniqueItems"].(bool); ok && unique {
+	outer:
+		for i := range arr {
+			for j := i + 1; j < len(arr); j++ {
+				if jsonEqual(arr[i], arr[j]) {
+					fail("items %d and %d are equal", i, j)
+					break outer
+				}
+			}
+		}
+	}
+
+	// Positional items: draft-07 uses an array in items together with
+	// additionalItems, 2020-12 uses prefixItems together with items.
+	var prefix []interface{}
+	var rest interface{}
+	if p, ok := sch["prefixItems"].([]interface{}); ok {
+		prefix, rest = p, sch["items"]
+	} else if p, ok := sch["items"].([]interface{}); ok {
+		prefix, rest = p, sch["additionalItems"]
+	} else {
+		rest = sch["items"]
+	}
+	for i, item := range arr {
+		iptr := appendJSONPointer(ptr, strconv.Itoa(i))
+		if i < len(prefix) {
+			errs = append(errs, s.apply(prefix[i], item, iptr, depth+1)...)
+		} else if allowed, ok := rest.(bool); ok && !allowed {
+			fail("got %d items, want at most %d", len(arr), len(prefix))
+			break
+		} else if rest != nil {
+			errs = append(errs, s.apply(rest, item, iptr, depth+1)...)
+		}
+	}
+
+	if contains, ok := sch["contains"]; ok {
+		n := 0
+		for _, item := range arr {
+			if len(s.apply(contains, item, ptr, depth+1)) == 0 {
+				n++
+			}
+		}
+		min, hasMin := jsonInt(sch["minContains"])
+		if !hasMin {
+			min = 1
+		}
+		if n < min {
+			fail("contains %d matching items, want at least %d", n, min)
+		}
+		if max, ok := jsonInt(sch["maxContains"]); ok && n > max {
+			fail("contains %d matching items, want at most %d", n, max)
+		}
+	}
+
+	return errs
+}
+
+func (s *jsonSchema) applyString(sch map[string]interface{}, str string, ptr string) []error {
+	var errs []error
+	fail := func(format string, a ...interface{}) {
+		errs = append(errs, schemaViolation{ptr, fmt.Sprintf(format, a...)})
+	}
+
+	length := utf8.RuneCountInString(str)
+	if n, ok := jsonInt(sch["minLength"]); ok && length < n {
+		fail("string of length %d shorter than %d", length, n)
+	}
+	if n, ok := jsonInt(sch["maxLength"]); ok && length > n {
+		fail("string of length %d longer than %d", length, n)
+	}
+	if p, ok := sch["pattern"].(string); ok {
+		if !s.patterns[p].MatchString(str) {
+			fail("%q does not match pattern %q", LimitString(str), p)
+		}
+	}
+	if f, ok := sch["format"].(string); ok {
+		if err := checkSchemaFormat(f, str); err != nil {
+			fail("%q is not a valid %s: %s", LimitString(str), f, err)
+		}
+	}
+	return errs
+}
+
+func applyNumber(sch map[string]interface{}, x float64, ptr string) []error {
+	var errs []error
+	fail := func(format string, a ...interface{}) {
+		errs = append(errs, schemaViolation{ptr, fmt.Sprintf(format, a...)})
+	}
+
+	// Draft-04 style boolean exclusiveMinimum/Maximum are still used in
+	// OpenAPI 3.0 documents.
+	exclMin, _ := sch["exclusiveMinimum"].(bool)
+	exclMax, _ := sch["exclusiveMaximum"].(bool)
+	fs := strconv.FormatFloat
+	if min, ok := jsonFloat(sch["minimum"]); ok {
+		if exclMin && x <= min {
+			fail("%s not greater than %s", fs(x, 'g', -1, 64), fs(min, 'g', -1, 64))
+		} else if x < min {
+			fail("%s less than minimum %s", fs(x, 'g', -1, 64), fs(min, 'g', -1, 64))
+		}
+	}
+	if max, ok := jsonFloat(sch["maximum"]); ok {
+		if exclMax && x >= max {
+			fail("%s not less than %s", fs(x, 'g', -1, 64), fs(max, 'g', -1, 64))
+		} else if x > max {
+			fail("%s greater than maximum %s", fs(x, 'g', -1, 64), fs(max, 'g', -1, 64))
+		}
+	}
+	if min, ok := jsonFloat(sch["exclusiveMinimum"]); ok && x <= min {
+		fail("%s not greater than %s", fs(x, 'g', -1, 64), fs(min, 'g', -1, 64))
+	}
+	if max, ok := jsonFloat(sch["exclusiveMaximum"]); ok && x >= max {
+		fail("%s not less than %s", fs(x, 'g', -1, 64), fs(max, 'g', -1, 64))
+	}
+	if m, ok := jsonFloat(sch["multipleOf"]); ok && m > 0 {
+		q := x / m
+		if math.Abs(q-math.Round(q)) > 1e-9 {
+			fail("%s is not a multiple of %s", fs(x, 'g', -1, 64), fs(m, 'g', -1, 64))
+		}
+	}
+	return errs
+}
+
+var (
+	uuidRegexp     = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+	hostnameRegexp = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?(\.[a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)*\.?$`)
+)
+
+// checkSchemaFormat checks s against the format f. Unknown formats
+// always validate.
+func checkSchemaFormat(f string, s string) error {
+	var err error
+	switch f {
+	case "date-time":
+		_, err = time.Parse(time.RFC3339Nano, s)
+	case "date":
+		_, err = time.Parse("2006-01-02", s)
+	case "time":
+		_, err = time.Parse("15:04:05Z07:00", s)
+		if err != nil {
+			_, err = time.Parse("15:04:05.999999999Z07:00", s)
+		}
+	case "email":
+		var addr *mail.Address
+		addr, err = mail.ParseAddress(s)
+		if err == nil && addr.Address != s {
+			err = fmt.Errorf("not a plain address")
+		}
+	case "hostname":
+		if len(s) > 253 || !hostnameRegexp.MatchString(s) {
+			err = fmt.Errorf("malformed")
+		}
+	case "ipv4":
+		if ip := net.ParseIP(s); ip == nil || ip.To4() == nil || strings.Contains(s, ":") {
+			err = fmt.Errorf("malformed")
+		}
+	case "ipv6":
+		if ip := net.ParseIP(s); ip == nil || !strings.Contains(s, ":") {
+			err = fmt.Errorf("malformed")
+		}
+	case "uri":
+		var u *url.URL
+		u, err = url.Parse(s)
+		if err == nil && !u.IsAbs() {
+			err = fmt.Errorf("not absolute")
+		}
+	case "uri-reference":
+		_, err = url.Parse(s)
+	case "uuid":
+		if !uuidRegexp.MatchString(s) {
+			err = fmt.Errorf("malformed")
+		}
+	case "regex":
+		_, err = regexp.Compile(s)
+	}
+	return err
+}
+
+// ----------------------------------------------------------------------------
+// Helpers to deal with decoded JSON values.
+
+// jsonFloat converts the decoded number v to a float64.
+func jsonFloat(v interface{}) (float64, bool) {
+	switch x := v.(type) {
+	case float64:
+		return x, true
+	case int64:
+		return float64(x), true
+	case uint64:
+		return float64(x), true
+	case int:
+		return float64(x), true
+	case json.Number:
+		f, err := x.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// jsonInt converts the decoded number v to an int.
+func jsonInt(v interface{}) (int, bool) {
+	f, ok := jsonFloat(v)
+	if !ok || f != math.Trunc(f) {
+		return 0, false
+	}
+	return int(f), true
+}
+
+// jsonTypeOf returns the JSON Schema type of v, "integer" is reported
+// for integral numbers.
+func jsonTypeOf(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	if f, ok := jsonFloat(v); ok {
+		if f == math.Trunc(f) && !math.IsInf(f, 0) {
+			return "integer"
+		}
+		return "number"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// matchesSchemaType reports whether v has (one of) the type(s) given by t.
+func matchesSchemaType(t interface{}, v interface{}) bool {
+	typ := jsonTypeOf(v)
+	matches := func(want interface{}) bool {
+		w, _ := want.(string)
+		return w == typ || (w == "number" && typ == "integer")
+	}
+	if list, ok := t.([]interface{}); ok {
+		for _, want := range list {
+			if matches(want) {
+				return true
+			}
+		}
+		return false
+	}
+	return matches(t)
+}
+
+func schemaTypeString(t interface{}) string {
+	if list, ok := t.([]interface{}); ok {
+		s := make([]string, len(list))
+		for i, e := range list {
+			s[i] = fmt.Sprintf("%v", e)
+		}
+		return strings.Join(s, " or ")
+	}
+	return fmt.Sprintf("%v", t)
+}
+
+// jsonEqual compares the two decoded JSON values a and b where numbers
+// are considered equal if they have the same numerical value.
+func jsonEqual(a, b interface{}) bool {
+	if fa, ok := jsonFloat(a); ok {
+		fb, ok := jsonFloat(b)
+		return ok && fa == fb
+	}
+	switch x := a.(type) {
+	case []interface{}:
+		y, ok := b.([]interface{})
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for i := range x {
+			if !jsonEqual(x[i], y[i]) {
+				return false
+			}
+		}
+		return true
+	case map[string]interface{}:
+		y, ok := b.(map[string]interface{})
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for k, v := range x {
+			w, ok := y[k]
+			if !ok || !jsonEqual(v, w) {
+				return false
+			}
+		}
+		return true
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// jsonShort is a short JSON representation of v for error messages.
+func jsonShort(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return LimitString(string(b))
+}
+
+func joinErrors(errs []error) string {
+	s := make([]string, len(errs))
+	for i, e := range errs {
+		s[i] = e.Error()
+	}
+	return strings.Join(s, "; ")
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,162 @@
+// Copyright 2018 Volker Dobler.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ht
+
+import (
+	"strings"
+	"testing"
+)
+
+var jsonSchemaValidationTests = []struct {
+	schema   string
+	instance string
+	want     []string
+}{
+	// Boolean schemas and types.
+	{`true`, `[1,2]`, nil},
+	{`false`, `1`, []string{`#: no value allowed here`}},
+	{`{"type": "number"}`, `7`, nil},
+	{`{"type": "number"}`, `7.5`, nil},
+	{`{"type": "integer"}`, `7.0`, nil},
+	{`{"type": "integer"}`, `"7"`, []string{`#: got string, want integer`}},
+	{`{"type": ["string", "null"]}`, `null`, nil},
+	{`{"type": ["string", "null"]}`, `{}`, []string{`#: got object, want string or null`}},
+	{`{"type": "string", "nullable": true}`, `null`, nil},
+	{`{"type": "string", "nullable": false}`, `null`, []string{`#: got null, want string`}},
+
+	// Generic keywords.
+	{`{"enum": [1, "a", {"b": [2]}]}`, `{"b": [2.0]}`, nil},
+	{`{"enum": [1, "a"]}`, `"b"`, []string{`#: value "b" not in enum [1,"a"]`}},
+	{`{"const": 3}`, `4`, []string{`#: value 4 is not the constant 3`}},
+
+	// Objects.
+	{`{"properties": {"a": {"type": "string"}}, "additionalProperties": false}`,
+		`{"a": "x", "b": 1, "c": 2}`,
+		[]string{`#: additional property "b" not allowed`,
+			`#: additional property "c" not allowed`}},
+	{`{"patternProperties": {"^x-": {"type": "integer"}}, "additionalProperties": {"type": "string"}}`,
+		`{"x-a": 1, "x-b": "2", "c": "3", "d": 4}`,
+		[]string{`#/d: got integer, want string`,
+			`#/x-b: got string, want integer`}},
+	{`{"propertyNames": {"maxLength": 3}}`, `{"abcd": 1}`,
+		[]string{`#: property name "abcd": string of length 4 longer than 3`}},
+	{`{"minProperties": 2, "maxProperties": 3}`, `{"a": 1}`,
+		[]string{`#: got 1 properties, want at least 2`}},
+	{`{"dependentRequired": {"a": ["b"]}}`, `{"a": 1}`,
+		[]string{`#: property "a" requires property "b"`}},
+	{`{"dependencies": {"a": ["b"]}}`, `{"a": 1}`,
+		[]string{`#: property "a" requires property "b"`}},
+	{`{"properties": {"a/b": {"properties": {"c~d": false}}}}`, `{"a/b": {"c~d": 1}}`,
+		[]string{`#/a~1b/c~0d: no value allowed here`}},
+
+	// Arrays.
+	{`{"items": {"type": "integer"}, "minItems": 4}`, `[1, "x", 3]`,
+		[]string{`#: got 3 items, want at least 4`, `#/1: got string, want integer`}},
+	{`{"items": [{"type": "integer"}, {"type": "string"}], "additionalItems": false}`,
+		`[1, "x", 3]`, []string{`#: got 3 items, want at most 2`}},
+	{`{"prefixItems": [{"type": "integer"}], "items": {"type": "string"}}`,
+		`[1, "x", 3]`, []string{`#/2: got integer, want string`}},
+	{`{"uniqueItems": true}`, `[1, 2, 1.0]`, []string{`#: items 0 and 2 are equal`}},
+	{`{"contains": {"type": "string"}}`, `[1, 2]`,
+		[]string{`#: contains 0 matching items, want at least 1`}},
+	{`{"contains": {"type": "string"}, "maxContains": 1}`, `["a", "b"]`,
+		[]string{`#: contains 2 matching items, want at most 1`}},
+
+	// Strings.
+	{`{"minLength": 2, "maxLength": 3}`, `"äöüß"`,
+		[]string{`#: string of length 4 longer than 3`}},
+	{`{"pattern": "^[a-z]+$"}`, `"abc1"`,
+		[]string{`#: "abc1" does not match pattern "^[a-z]+$"`}},
+	{`{"format": "date-time"}`, `"2018-03-04T12:13:14Z"`, nil},
+	{`{"format": "date"}`, `"2018-13-04"`, []string{`#: "2018-13-04" is not a valid date: parsing time "2018-13-04": month out of range`}},
+	{`{"format": "email"}`, `"foo@example.org"`, nil},
+	{`{"format": "ipv4"}`, `"::1"`, []string{`#: "::1" is not a valid ipv4: malformed`}},
+	{`{"format": "uuid"}`, `"ad09b43c-6538-11e6-8b77-86f30ca893d3"`, nil},
+	{`{"format": "something-unknown"}`, `"whatever"`, nil},
+
+	// Numbers.
+	{`{"minimum": 3, "exclusiveMaximum": 5}`, `5`, []string{`#: 5 not less than 5`}},
+	{`{"minimum": 3, "exclusiveMinimum": true}`, `3`, []string{`#: 3 not greater than 3`}},
+	{`{"multipleOf": 0.1}`, `0.3`, nil},
+	{`{"multipleOf": 3}`, `10`, []string{`#: 10 is not a multiple of 3`}},
+
+	// Combinators.
+	{`{"allOf": [{"minimum": 2}, {"maximum": 4}]}`, `5`,
+		[]string{`#: 5 greater than maximum 4`}},
+	{`{"anyOf": [{"type": "string"}, {"minimum": 7}]}`, `5`,
+		[]string{`#: matches none of the schemas in anyOf (#: got integer, want string; #: 5 less than minimum 7)`}},
+	{`{"oneOf": [{"type": "integer"}, {"minimum": 2}]}`, `5`,
+		[]string{`#: matches schemas 0, 1 in oneOf, want exactly one`}},
+	{`{"oneOf": [{"type": "integer"}, {"minimum": 2}]}`, `1`, nil},
+	{`{"not": {"type": "string"}}`, `"x"`, []string{`#: must not match schema in not`}},
+	{`{"if": {"properties": {"a": {"const": 1}}}, "then": {"required": ["b"]}, "else": {"required": ["c"]}}`,
+		`{"a": 1}`, []string{`#: missing required property "b"`}},
+	{`{"if": {"properties": {"a": {"const": 1}}}, "then": {"required": ["b"]}, "else": {"required": ["c"]}}`,
+		`{"a": 2}`, []string{`#: missing required property "c"`}},
+
+	// References.
+	{`{"$defs": {"pos": {"type": "integer", "minimum": 0}}, "items": {"$ref": "#/$defs/pos"}}`,
+		`[1, -2, "x"]`,
+		[]string{`#/1: -2 less than minimum 0`, `#/2: got string, want integer`}},
+	{`{"definitions": {"node": {"$anchor": "node", "properties": {"next": {"$ref": "#node"}}, "required": ["v"]}},
+           "$ref": "#/definitions/node"}`,
+		`{"v": 1, "next": {"v": 2, "next": {"next": {"v": 4}}}}`,
+		[]string{`#/next/next: missing required property "v"`}},
+	{`{"$ref": "#"}`, `1`, []string{`#: schema nesting too deep (recursive $ref?)`}},
+
+	// Instance data is not compiled or resolved.
+	{`{"const": {"pattern": "(?!x)", "$ref": "#/nowhere"},
+	   "default": {"pattern": "["}, "examples": [{"$ref": "other.json"}]}`,
+		`{"pattern": "(?!x)", "$ref": "#/nowhere"}`, nil},
+	{`{"enum": [{"patternProperties": {"[": 1}}]}`, `{"patternProperties": {"[": 1}}`, nil},
+
+	// Subschemas reached through a $ref only.
+	{`{"$ref": "#/components/schemas/id", "components": {"schemas": {"id": {"pattern": "^a"}}}}`,
+		`"ba"`, []string{`#: "ba" does not match pattern "^a"`}},
+}
+
+func TestJSONSchemaValidation(t *testing.T) {
+	for i, tc := range jsonSchemaValidationTests {
+		schema, err := newJSONSchema([]byte(tc.schema))
+		if err != nil {
+			t.Errorf("%d. %s: unexpected error %s", i, tc.schema, err)
+			continue
+		}
+		instance, err := decodeJSONInstance([]byte(tc.instance))
+		if err != nil {
+			t.Errorf("%d. %s: bad instance %s", i, tc.instance, err)
+			continue
+		}
+		errs := schema.validate(instance)
+		got := make([]string, len(errs))
+		for j, e := range errs {
+			got[j] = e.Error()
+		}
+		if strings.Join(got, "\n") != strings.Join(tc.want, "\n") {
+			t.Errorf("%d. %s on %s:\ngot  %q\nwant %q",
+				i, tc.schema, tc.instance, got, tc.want)
+		}
+	}
+}
+
+func TestJSONSchemaCompile(t *testing.T) {
+	for i, tc := range []struct {
+		schema, err string
+	}{
+		{`{"$ref": "other.json#/foo"}`, `unsupported non-local $ref "other.json#/foo"`},
+		{`{"$ref": "#/definitions/foo"}`, `cannot resolve $ref "#/definitions/foo"`},
+		{`{"$ref": "#foo"}`, `no anchor for $ref "#foo"`},
+		{`{"patternProperties": {"[": {}}}`, "bad pattern \"[\" in schema: error parsing regexp: missing closing ]: `[`"},
+		{`{"items": {"pattern": "^(?!admin)"}}`, "bad pattern \"^(?!admin)\" in schema: error parsing regexp: invalid or unsupported Perl syntax: `(?!`"},
+		{`{"a": `, ``},
+	} {
+		_, err := newJSONSchema([]byte(tc.schema))
+		if err == nil {
+			t.Errorf("%d. %s: missing error", i, tc.schema)
+		} else if tc.err != "" && err.Error() != tc.err {
+			t.Errorf("%d. %s: got error %q, want %q", i, tc.schema, err, tc.err)
+		}
+	}
+}
@@ -0,0 +1,605 @@
+// Copyright 2018 Volker Dobler.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// jwt.go contains checks and extractors for JSON Web Tokens.
+
+package ht
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/vdobler/ht/errorlist"
+)
+
+func init() {
+	RegisterCheck(&JWT{})
+	RegisterExtractor(JWTExtractor{})
+}
+
+// ----------------------------------------------------------------------------
+// JWTSource
+
+// JWTSource locates a JSON Web Token in the response. At most one of Header,
+// Cookie and Element may be set; if none is set the whole (trimmed) body is
+// taken as the token.
+type JWTSource struct {
+	// Header is the name of the response header containing the token.
+	// A "Bearer " prefix is stripped.
+	Header string `json:",omitempty"`
+
+	// Cookie is the name of the cookie containing the token.
+	Cookie string `json:",omitempty"`
+
+	// Element selects the token in a JSON body like in the JSON check,
+	// e.g. "access_token".
+	Element string `json:",omitempty"`
+
+	// Sep is the separator in Element and in the names of nested claims.
+	// A zero value is equivalent to ".".
+	Sep string `json:",omitempty"`
+}
+
+func (s JWTSource) validate() error {
+	n := 0
+	for _, f := range []string{s.Header, s.Cookie, s.Element} {
+		if f != "" {
+			n++
+		}
+	}
+	if n > 1 {
+		return errors.New("only one of Header, Cookie and Element may be set")
+	}
+	return nil
+}
+
+func (s JWTSource) sep() string {
+	if s.Sep == "" {
+		return "."
+	}
+	return s.Sep
+}
+
+// token returns the encoded token in the response of t.
+func (s JWTSource) token(t *Test) (string, error) {
+	switch {
+	case s.Header != "":
+		h := t.Response.Response.Header.Get(s.Header)
+		if h == "" {
+			return "", fmt.Errorf("header %s not received", s.Header)
+		}
+		if len(h) > 7 && strings.EqualFold(h[:7], "Bearer ") {
+			h = h[7:]
+		}
+		return strings.TrimSpace(h), nil
+	case s.Cookie != "":
+		cookies := findCookiesByName(t, s.Cookie)
+		if len(cookies) == 0 {
+			return "", fmt.Errorf("cookie %s not received", s.Cookie)
+		}
+		return cookies[0].Value, nil
+	}
+
+	if t.Response.BodyErr != nil {
+		return "", ErrBadBody
+	}
+	if s.Element == "" {
+		return strings.TrimSpace(t.Response.BodyStr), nil
+	}
+	raw, err := findJSONelement([]byte(t.Response.BodyStr), s.Element, s.sep())
+	if err != nil {
+		return "", err
+	}
+	tok := ""
+	if err := json.Unmarshal(raw, &tok); err != nil {
+		return "", fmt.Errorf("element %s is not a string", s.Element)
+	}
+	return tok, nil
+}
+
+// ----------------------------------------------------------------------------
+// Decoding and verification
+
+// jwtToken is a decoded JSON Web Token.
+type jwtToken struct {
+	header, claims []byte // the raw JSON
+	alg, kid       string
+	signingInput   string
+	signature      []byte
+}
+
+// parseJWT decodes the JWS compact serialization s.
+func parseJWT(s string) (*jwtToken, error) {
+	parts := strings.Split(s, ".")
+	if len(parts) == 5 {
+		return nil, errors.New("encrypted tokens (JWE) are not supported")
+	}
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("malformed token %q", LimitString(s))
+	}
+	decode := func(p string) ([]byte, error) {
+		return base64.RawURLEncoding.DecodeString(strings.TrimRight(p, "="))
+	}
+
+	tok := &jwtToken{signingInput: parts[0] + "." + parts[1]}
+	var err error
+	if tok.header, err = decode(parts[0]); err != nil {
+		return nil, fmt.Errorf("bad token header: %s", err)
+	}
+	if tok.claims, err = decode(parts[1]); err != nil {
+		return nil, fmt.Errorf("bad token claims: %s", err)
+	}
+	if tok.signature, err = decode(parts[2]); err != nil {
+		return nil, fmt.Errorf("bad token signature: %s", err)
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := json.Unmarshal(tok.header, &header); err != nil {
+		return nil, fmt.Errorf("bad token header: %s", err)
+	}
+	var claims map[string]interface{}
+	if err := json.Unmarshal(tok.claims, &claims); err != nil {
+		return nil, fmt.Errorf("bad token claims: %s", err)
+	}
+	tok.alg, tok.kid = header.Alg, header.Kid
+	return tok, nil
+}
+
+// jwtCurves maps the ECDSA algorithms to the curve they require.
+var jwtCurves = map[string]string{
+	"ES256": "P-256",
+	"ES384": "P-384",
+	"ES512": "P-521",
+}
+
+// jwtKey is a key to verify signatures: A []byte for HMAC, a
+// *rsa.PublicKey or a *ecdsa.PublicKey.
+type jwtKey struct {
+	kid, alg string
+	key      interface{}
+}
+
+// verify the signature of tok with key.
+func (tok *jwtToken) verify(key interface{}) error {
+	if len(tok.alg) != 5 {
+		return fmt.Errorf("unsupported algorithm %q", tok.alg)
+	}
+	var hash crypto.Hash
+	switch tok.alg[2:] {
+	case "256":
+		hash = crypto.SHA256
+	case "384":
+		hash = crypto.SHA384
+	case "512":
+		hash = crypto.SHA512
+	default:
+		return fmt.Errorf("unsupported algorithm %q", tok.alg)
+	}
+	h := hash.New()
+	h.Write([]byte(tok.signingInput))
+	digest := h.Sum(nil)
+
+	switch k := key.(type) {
+	case []byte:
+		if tok.alg[:2] != "HS" {
+			break
+		}
+		mac := hmac.New(hash.New, k)
+		mac.Write([]byte(tok.signingInput))
+		if !hmac.Equal(mac.Sum(nil), tok.signature) {
+			return errors.New("invalid signature")
+		}
+		return nil
+	case *rsa.PublicKey:
+		var err error
+		switch tok.alg[:2] {
+		case "RS":
+			err = rsa.VerifyPKCS1v15(k, hash, digest, tok.signature)
+		case "PS":
+			err = rsa.VerifyPSS(k, hash, digest, tok.signature,
+				&rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+		default:
+			return fmt.Errorf("RSA key cannot verify %s", tok.alg)
+		}
+		if err != nil {
+			return errors.New("invalid signature")
+		}
+		return nil
+	case *ecdsa.PublicKey:
+		if tok.alg[:2] != "ES" {
+			break
+		}
+		if curve := k.Curve.Params().Name; curve != jwtCurves[tok.alg] {
+			return fmt.Errorf("%s key cannot verify %s", curve, tok.alg)
+		}
+		size := (k.Curve.Params().BitSize + 7) / 8
+		if len(tok.signature) != 2*size {
+			return errors.New("invalid signature")
+		}
+		r := new(big.Int).SetBytes(tok.signature[:size])
+		s := new(big.Int).SetBytes(tok.signature[size:])
+		if !ecdsa.Verify(k, digest, r, s) {
+			return errors.New("invalid signature")
+		}
+		return nil
+	}
+	return fmt.Errorf("key of type %T cannot verify %s", key, tok.alg)
+}
+
+// parseJWTKey parses a PEM encoded public key or certificate. Anything
+// else is a shared secret for HMAC.
+func parseJWTKey(data string) (interface{}, error) {
+	block, _ := pem.Decode([]byte(data))
+	if block == nil {
+		return []byte(data), nil
+	}
+	switch block.Type {
+	case "PUBLIC KEY":
+		return x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	case "CERTIFICATE":
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		return cert.PublicKey, nil
+	}
+	return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+}
+
+// parseJWKS parses a JSON Web Key Set (RFC 7517).
+func parseJWKS(data string) ([]jwtKey, error) {
+	var jwks struct {
+		Keys []struct {
+			Kty, Kid, Alg, Crv, N, E, X, Y, K string
+		} `json:"keys"`
+	}
+	if err := json.Unmarshal([]byte(data), &jwks); err != nil {
+		return nil, err
+	}
+	decode := func(s string) *big.Int {
+		b, _ := base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+		return new(big.Int).SetBytes(b)
+	}
+
+	keys := []jwtKey{}
+	for i, k := range jwks.Keys {
+		key := jwtKey{kid: k.Kid, alg: k.Alg}
+		switch k.Kty {
+		case "oct":
+			secret, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(k.K, "="))
+			if err != nil {
+				return nil, fmt.Errorf("key %d: %s", i, err)
+			}
+			key.key = secret
+		case "RSA":
+			key.key = &rsa.PublicKey{N: decode(k.N), E: int(decode(k.E).Int64())}
+		case "EC":
+			var curve elliptic.Curve
+			switch k.Crv {
+			case "P-256":
+				curve = elliptic.P256()
+			case "P-384":
+				curve = elliptic.P384()
+			case "P-521":
+				curve = elliptic.P521()
+			default:
+				return nil, fmt.Errorf("key %d: unsupported curve %q", i, k.Crv)
+			}
+			key.key = &ecdsa.PublicKey{Curve: curve, X: decode(k.X), Y: decode(k.Y)}
+		default:
+			continue // Ignore unknown key types like OKP.
+		}
+		keys = append(keys, key)
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("no usable key in JWKS")
+	}
+	return keys, nil
+}
+
+// claimValue returns the value of the (nested) claim name in raw with
+// strings unquoted.
+func claimValue(raw []byte, name, sep string) (string, error) {
+	v, err := findJSONelement(raw, name, sep)
+	if err != nil {
+		return "", err
+	}
+	s := ""
+	if json.Unmarshal(v, &s) == nil {
+		return s, nil
+	}
+	return string(v), nil
+}
+
+// ----------------------------------------------------------------------------
+// JWT
+
+var errJWTUnsigned = errors.New("token is not signed")
+
+// JWT checks a JSON Web Token (RFC 7519) received in the response.
+// The token is decoded and its signature verified if a Key or a JWKS is
+// given. Unsigned tokens (alg "none"), expired tokens and tokens not yet
+// valid due to their nbf claim always fail the check.
+//
+// Conditions on claims and header parameters are applied to the values
+// with strings unquoted; other values like numbers, arrays or objects are
+// checked as raw JSON. Nested claims are selected like elements in the
+// JSON check, e.g. the condition {Contains: "\"admin\""} on the claim
+// "realm_access.roles" requires the admin role in a Keycloak token.
+type JWT struct {
+	// JWTSource determines where the token is found.
+	JWTSource
+
+	// Key is used to verify the signature: The shared secret for the HS256,
+	// HS384 and HS512 algorithms or a PEM encoded public key or certificate
+	// for RS*, PS* and ES*. The key may be read from a file with the
+	// @file: syntax.
+	Key string `json:",omitempty"`
+
+	// JWKS is a JSON Web Key Set, typically read from a file like
+	// "@file:{{TEST_DIR}}/jwks.json". The key is selected by the kid
+	// header parameter of the token.
+	JWKS string `json:",omitempty"`
+
+	// MinValidity is the minimum time the token must be valid: Its exp
+	// claim must be at least this far in the future.
+	MinValidity time.Duration `json:",omitempty"`
+
+	// Audience must be (one of) the aud claim.
+	Audience string `json:",omitempty"`
+
+	// Scopes must all be contained in the space separated scope claim
+	// (or the scp claim).
+	Scopes []string `json:",omitempty"`
+
+	// Claims are conditions on the claims of the token.
+	Claims map[string]Condition `json:",omitempty"`
+
+	// TokenHeader are conditions on the parameters of the token header
+	// like "alg" or "kid".
+	TokenHeader map[string]Condition `json:",omitempty"`
+
+	keys []jwtKey
+}
+
+// Prepare implements Check's Prepare method.
+func (c *JWT) Prepare(t *Test) error {
+	if err := c.JWTSource.validate(); err != nil {
+		return err
+	}
+	if c.Key != "" && c.JWKS != "" {
+		return errors.New("only one of Key and JWKS may be set")
+	}
+	c.keys = nil
+	if c.Key != "" {
+		data, _, err := FileData(c.Key, t.Variables)
+		if err != nil {
+			return err
+		}
+		key, err := parseJWTKey(data)
+		if err != nil {
+			return fmt.Errorf("bad Key: %s", err)
+		}
+		c.keys = []jwtKey{{key: key}}
+	}
+	if c.JWKS != "" {
+		data, _, err := FileData(c.JWKS, t.Variables)
+		if err != nil {
+			return err
+		}
+		if c.keys, err = parseJWKS(data); err != nil {
+			return fmt.Errorf("bad JWKS: %s", err)
+		}
+	}
+	for _, conds := range []map[string]Condition{c.Claims, c.TokenHeader} {
+		for name, cond := range conds {
+			if err := cond.Compile(); err != nil {
+				return fmt.Errorf("condition on %s: %s", name, err)
+			}
+			conds[name] = cond
+		}
+	}
+	return nil
+}
+
+var _ Preparable = &JWT{}
+
+// Execute implements Check's Execute method.
+func (c *JWT) Execute(t *Test) error {
+	encoded, err := c.token(t)
+	if err != nil {
+		return err
+	}
+	tok, err := parseJWT(encoded)
+	if err != nil {
+		return err
+	}
+
+	if c.keys != nil {
+		if err := c.verify(tok); err != nil {
+			return err
+		}
+	} else if tok.alg == "" || tok.alg == "none" {
+		return errJWTUnsigned
+	}
+
+	var claims struct {
+		Exp   *float64        `json:"exp"`
+		Nbf   *float64        `json:"nbf"`
+		Aud   json.RawMessage `json:"aud"`
+		Scope string          `json:"scope"`
+		Scp   json.RawMessage `json:"scp"`
+	}
+	if err := json.Unmarshal(tok.claims, &claims); err != nil {
+		return fmt.Errorf("bad registered claims: %s", err)
+	}
+
+	errs := errorlist.List{}
+	now := time.Now()
+	if claims.Exp != nil {
+		exp := time.Unix(int64(*claims.Exp), 0)
+		if left := exp.Sub(now); left <= 0 {
+			errs = append(errs, fmt.Errorf("token expired %s ago", -left.Round(time.Second)))
+		} else if left < c.MinValidity {
+			errs = append(errs, fmt.Errorf("token expires in %s (want at least %s)",
+				left.Round(time.Second), c.MinValidity))
+		}
+	} else if c.MinValidity > 0 {
+		errs = append(errs, errors.New("token has no exp claim"))
+	}
+	if claims.Nbf != nil {
+		if nbf := time.Unix(int64(*claims.Nbf), 0); nbf.After(now) {
+			errs = append(errs, fmt.Errorf("token not valid before %s", nbf))
+		}
+	}
+
+	if c.Audience != "" {
+		if aud := stringOrList(claims.Aud); !containsString(aud, c.Audience) {
+			errs = append(errs, fmt.Errorf("audience %v does not contain %q", aud, c.Audience))
+		}
+	}
+	if len(c.Scopes) > 0 {
+		scopes := strings.Fields(claims.Scope)
+		if len(scopes) == 0 {
+			scopes = stringOrList(claims.Scp)
+		}
+		for _, s := range c.Scopes {
+			if !containsString(scopes, s) {
+				errs = append(errs, fmt.Errorf("scope %q not granted, got %v", s, scopes))
+			}
+		}
+	}
+
+	errs = append(errs, checkClaims("claim", tok.claims, c.Claims, c.sep())...)
+	errs = append(errs, checkClaims("header", tok.header, c.TokenHeader, c.sep())...)
+
+	return errs.AsError()
+}
+
+// verify the signature of tok with the keys of c.
+func (c *JWT) verify(tok *jwtToken) error {
+	if tok.alg == "" || tok.alg == "none" {
+		return errJWTUnsigned
+	}
+	keys := c.keys
+	if c.JWKS != "" && tok.kid != "" {
+		keys = nil
+		for _, k := range c.keys {
+			if k.kid == tok.kid {
+				keys = append(keys, k)
+			}
+		}
+		if len(keys) == 0 {
+			return fmt.Errorf("no key with kid %q in JWKS", tok.kid)
+		}
+	}
+	var err error
+	for _, k := range keys {
+		if k.alg != "" && k.alg != tok.alg {
+			err = fmt.Errorf("key is for %s, token uses %s", k.alg, tok.alg)
+			continue
+		}
+		if err = tok.verify(k.key); err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+// checkClaims applies the conditions to the (nested) claims in raw.
+func checkClaims(what string, raw []byte, conds map[string]Condition, sep string) errorlist.List {
+	names := make([]string, 0, len(conds))
+	for name := range conds {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	errs := errorlist.List{}
+	for _, name := range names {
+		v, err := claimValue(raw, name, sep)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s %s: %s", what, name, err))
+			continue
+		}
+		cond := conds[name]
+		if err := cond.Fulfilled(v); err != nil {
+			errs = append(errs, fmt.Errorf("%s %s: %s", what, name, err))
+		}
+	}
+	return errs
+}
+
+// stringOrList decodes raw which is either a string or a list of strings.
+func stringOrList(raw json.RawMessage) []string {
+	var list []string
+	if json.Unmarshal(raw, &list) == nil {
+		return list
+	}
+	s := ""
+	if json.Unmarshal(raw, &s) == nil && s != "" {
+		return []string{s}
+	}
+	return nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, e := range list {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
+
+// ----------------------------------------------------------------------------
+// JWTExtractor
+
+// JWTExtractor extracts a claim from a JSON Web Token. The signature is
+// not verified, use the JWT check for this.
+type JWTExtractor struct {
+	// JWTSource determines where the token is found.
+	JWTSource
+
+	// Claim is the (nested) claim to extract, e.g. "sub" or
+	// "realm_access.roles.0". Strings are unquoted, other values are
+	// extracted as raw JSON. The zero value extracts the token itself.
+	Claim string `json:",omitempty"`
+}
+
+// Extract implements Extractor's Extract method.
+func (e JWTExtractor) Extract(t *Test) (string, error) {
+	if err := e.JWTSource.validate(); err != nil {
+		return "", err
+	}
+	encoded, err := e.token(t)
+	if err != nil {
+		return "", err
+	}
+	tok, err := parseJWT(encoded)
+	if err != nil {
+		return "", err
+	}
+	if e.Claim == "" {
+		return encoded, nil
+	}
+	return claimValue(tok.claims, e.Claim, e.sep())
+}
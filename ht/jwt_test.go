@@ -0,0 +1,200 @@
+// Copyright 2018 Volker Dobler.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ht
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net/http"
+	"testing"
+	"time"
+)
+
+var (
+	jwtRSAKey, _ = rsa.GenerateKey(rand.Reader, 2048)
+	jwtECKey, _  = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+)
+
+// signJWT creates a token with the given header and claims.
+func signJWT(header, claims map[string]interface{}) string {
+	h, _ := json.Marshal(header)
+	c, _ := json.Marshal(claims)
+	enc := base64.RawURLEncoding.EncodeToString
+	input := enc(h) + "." + enc(c)
+
+	alg := header["alg"].(string)
+	digest := func(hash crypto.Hash) []byte {
+		hh := hash.New()
+		hh.Write([]byte(input))
+		return hh.Sum(nil)
+	}
+	var sig []byte
+	switch alg {
+	case "HS256":
+		mac := hmac.New(crypto.SHA256.New, []byte("s3cr3t"))
+		mac.Write([]byte(input))
+		sig = mac.Sum(nil)
+	case "RS256":
+		sig, _ = rsa.SignPKCS1v15(rand.Reader, jwtRSAKey, crypto.SHA256, digest(crypto.SHA256))
+	case "PS384":
+		sig, _ = rsa.SignPSS(rand.Reader, jwtRSAKey, crypto.SHA384, digest(crypto.SHA384),
+			&rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+	case "ES256":
+		r, s, _ := ecdsa.Sign(rand.Reader, jwtECKey, digest(crypto.SHA256))
+		sig = make([]byte, 64)
+		r.FillBytes(sig[:32])
+		s.FillBytes(sig[32:])
+	}
+	return input + "." + enc(sig)
+}
+
+func publicKeyPEM(key interface{}) string {
+	der, _ := x509.MarshalPKIXPublicKey(key)
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+}
+
+func jwksJSON() string {
+	enc := func(b []byte) string { return base64.RawURLEncoding.EncodeToString(b) }
+	jwks := map[string]interface{}{"keys": []map[string]string{
+		{"kty": "oct", "kid": "hmac", "k": enc([]byte("s3cr3t"))},
+		{"kty": "RSA", "kid": "rsa", "n": enc(jwtRSAKey.N.Bytes()),
+			"e": enc(big.NewInt(int64(jwtRSAKey.E)).Bytes())},
+		{"kty": "EC", "kid": "ec", "crv": "P-256", "x": enc(jwtECKey.X.Bytes()),
+			"y": enc(jwtECKey.Y.Bytes())},
+		{"kty": "OKP", "kid": "ed", "crv": "Ed25519", "x": "11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"},
+	}}
+	data, _ := json.Marshal(jwks)
+	return string(data)
+}
+
+func jwtResponse(token string) Response {
+	return Response{
+		Response: &http.Response{Header: http.Header{
+			"Authorization": {"Bearer " + token},
+			"Set-Cookie":    {"session=" + token + "; Path=/"},
+		}},
+		BodyStr: `{"data": {"token": "` + token + `"}}`,
+	}
+}
+
+func TestJWT(t *testing.T) {
+	exp := time.Now().Add(time.Hour).Unix()
+	claims := map[string]interface{}{
+		"sub":   "john",
+		"aud":   []string{"api", "web"},
+		"scope": "read write",
+		"exp":   exp,
+		"realm_access": map[string]interface{}{
+			"roles": []string{"user", "admin"},
+		},
+	}
+	hs := signJWT(map[string]interface{}{"alg": "HS256", "kid": "hmac"}, claims)
+	rs := signJWT(map[string]interface{}{"alg": "RS256", "kid": "rsa"}, claims)
+	ps := signJWT(map[string]interface{}{"alg": "PS384"}, claims)
+	es := signJWT(map[string]interface{}{"alg": "ES256", "kid": "ec"}, claims)
+	expired := signJWT(map[string]interface{}{"alg": "HS256"},
+		map[string]interface{}{"exp": time.Now().Add(-time.Hour).Unix()})
+	unsigned := signJWT(map[string]interface{}{"alg": "none"}, claims)
+	rsaPEM, ecPEM := publicKeyPEM(&jwtRSAKey.PublicKey), publicKeyPEM(&jwtECKey.PublicKey)
+	p384Key, _ := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	p384PEM := publicKeyPEM(&p384Key.PublicKey)
+	jwks := jwksJSON()
+
+	src := JWTSource{Header: "Authorization"}
+	for i, tc := range []TC{
+		{jwtResponse(hs), &JWT{JWTSource: src}, nil},
+		{jwtResponse(hs), &JWT{JWTSource: src, Key: "s3cr3t"}, nil},
+		{jwtResponse(hs), &JWT{JWTSource: JWTSource{Cookie: "session"}, Key: "s3cr3t"}, nil},
+		{jwtResponse(hs), &JWT{JWTSource: JWTSource{Element: "data.token"}, Key: "s3cr3t"}, nil},
+		{jwtResponse(rs), &JWT{JWTSource: src, Key: rsaPEM}, nil},
+		{jwtResponse(ps), &JWT{JWTSource: src, Key: rsaPEM}, nil},
+		{jwtResponse(es), &JWT{JWTSource: src, Key: ecPEM}, nil},
+		{jwtResponse(hs), &JWT{JWTSource: src, JWKS: jwks}, nil},
+		{jwtResponse(rs), &JWT{JWTSource: src, JWKS: jwks}, nil},
+		{jwtResponse(es), &JWT{JWTSource: src, JWKS: jwks}, nil},
+		{jwtResponse(hs), &JWT{JWTSource: src, Key: "wrong"},
+			fmt.Errorf("invalid signature")},
+		{jwtResponse(rs), &JWT{JWTSource: src, Key: ecPEM},
+			fmt.Errorf("key of type *ecdsa.PublicKey cannot verify RS256")},
+		{jwtResponse(hs), &JWT{JWTSource: src, Key: rsaPEM},
+			fmt.Errorf("RSA key cannot verify HS256")},
+		{jwtResponse(es), &JWT{JWTSource: src, Key: p384PEM},
+			fmt.Errorf("P-384 key cannot verify ES256")},
+		{jwtResponse(ps), &JWT{JWTSource: src, JWKS: jwks}, nil},
+		{jwtResponse(unsigned), &JWT{JWTSource: src, JWKS: jwks},
+			fmt.Errorf("token is not signed")},
+		{jwtResponse(unsigned), &JWT{JWTSource: src},
+			fmt.Errorf("token is not signed")},
+		{jwtResponse(expired), &JWT{JWTSource: src}, errCheck},
+		{jwtResponse(hs), &JWT{JWTSource: src, MinValidity: 30 * time.Minute,
+			Audience: "api", Scopes: []string{"read"}}, nil},
+		{jwtResponse(hs), &JWT{JWTSource: src, MinValidity: 2 * time.Hour}, errCheck},
+		{jwtResponse(hs), &JWT{JWTSource: src, Audience: "admin"},
+			fmt.Errorf(`audience [api web] does not contain "admin"`)},
+		{jwtResponse(hs), &JWT{JWTSource: src, Scopes: []string{"read", "delete"}},
+			fmt.Errorf(`scope "delete" not granted, got [read write]`)},
+		{jwtResponse(hs), &JWT{JWTSource: src, Claims: map[string]Condition{
+			"sub":                {Equals: "john"},
+			"realm_access.roles": {Contains: `"admin"`},
+		}}, nil},
+		{jwtResponse(hs), &JWT{JWTSource: src, TokenHeader: map[string]Condition{
+			"alg": {Equals: "HS256"}, "kid": {Equals: "hmac"},
+		}}, nil},
+		{jwtResponse(hs), &JWT{JWTSource: src, Claims: map[string]Condition{
+			"sub": {Equals: "jane"}, "iss": {},
+		}}, errCheck},
+		{jwtResponse(hs), &JWT{JWTSource: JWTSource{Header: "X-Token"}},
+			fmt.Errorf("header X-Token not received")},
+		{Response{BodyStr: "no.jwt"}, &JWT{}, fmt.Errorf(`malformed token "no.jwt"`)},
+		{jwtResponse(hs), &JWT{JWTSource: JWTSource{Header: "A", Cookie: "b"}},
+			errDuringPrepare},
+		{jwtResponse(hs), &JWT{Key: "a", JWKS: jwks}, errDuringPrepare},
+		{jwtResponse(hs), &JWT{JWKS: `{"keys": []}`}, errDuringPrepare},
+		{jwtResponse(hs), &JWT{Claims: map[string]Condition{"sub": {Regexp: "("}}},
+			errDuringPrepare},
+	} {
+		runTest(t, i, tc)
+	}
+}
+
+func TestJWTExtractor(t *testing.T) {
+	token := signJWT(map[string]interface{}{"alg": "HS256"},
+		map[string]interface{}{"sub": "john", "n": 3,
+			"roles": []string{"user", "admin"}})
+	test := &Test{Response: jwtResponse(token)}
+
+	for i, tc := range []struct {
+		ex   JWTExtractor
+		want string
+	}{
+		{JWTExtractor{JWTSource: JWTSource{Header: "Authorization"}}, token},
+		{JWTExtractor{JWTSource: JWTSource{Header: "Authorization"}, Claim: "sub"}, "john"},
+		{JWTExtractor{JWTSource: JWTSource{Cookie: "session"}, Claim: "n"}, "3"},
+		{JWTExtractor{JWTSource: JWTSource{Element: "data.token"}, Claim: "roles.1"}, "admin"},
+		{JWTExtractor{JWTSource: JWTSource{Element: "data/token", Sep: "/"},
+			Claim: "roles"}, `["user","admin"]`},
+	} {
+		got, err := tc.ex.Extract(test)
+		if err != nil {
+			t.Errorf("%d. Unexpected error %s", i, err)
+		} else if got != tc.want {
+			t.Errorf("%d. Got %q, want %q", i, got, tc.want)
+		}
+	}
+
+	if _, err := (JWTExtractor{Claim: "sub"}).Extract(test); err == nil {
+		t.Errorf("Missing error extracting from JSON body")
+	}
+}
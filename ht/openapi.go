@@ -0,0 +1,247 @@
+// Copyright 2018 Volker Dobler.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// openapi.go contains a check for conformance to an OpenAPI spec.
+
+package ht
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/vdobler/ht/errorlist"
+	"github.com/vdobler/ht/internal/openapi"
+)
+
+func init() {
+	RegisterCheck(&OpenAPI{})
+}
+
+// The two ways a response may be not covered by an OpenAPI spec at all.
+var (
+	// ErrUndocumentedOperation is reported by the OpenAPI check if
+	// the spec does not describe the method and path of the request.
+	ErrUndocumentedOperation = errors.New("undocumented operation")
+
+	// ErrUndocumentedStatus is reported by the OpenAPI check if the
+	// operation has no response for the received status code (and
+	// no default response).
+	ErrUndocumentedStatus = errors.New("undocumented status code")
+)
+
+// OpenAPI checks that the response conforms to an OpenAPI 3 spec.
+// The operation is looked up by the method and the path of the request
+// (after following redirects): Its path must match one of the path
+// templates like "/pets/{petId}" and the method must be described for
+// this path. The received status code must be documented for this
+// operation (possibly through a range like "2XX" or a default response).
+// Headers declared required for the response must be present and
+// their values must validate against their schema. A body must have
+// one of the documented media types and JSON bodies must validate
+// against the schema of this media type.
+//
+// An undocumented operation fails with ErrUndocumentedOperation, an
+// undocumented status code with ErrUndocumentedStatus.
+type OpenAPI struct {
+	// Spec is the OpenAPI 3 document in JSON or YAML. Typically it
+	// is read from a file with the @file: syntax, e.g.
+	// "@file:{{TEST_DIR}}/openapi.yaml".
+	Spec string
+
+	// BasePath is stripped from the request path before looking up
+	// the operation. If empty the path of the first server URL of
+	// the spec is used.
+	BasePath string `json:",omitempty"`
+
+	spec       *openapi.Spec
+	basePath   string
+	validators map[string]*jsonSchema // see validatorKey
+}
+
+// Prepare implements Check's Prepare method.
+func (o *OpenAPI) Prepare(t *Test) error {
+	if o.Spec == "" {
+		return fmt.Errorf("missing Spec")
+	}
+	data, _, err := FileData(o.Spec, t.Variables)
+	if err != nil {
+		return err
+	}
+	o.spec, err = openapi.Parse([]byte(data))
+	if err != nil {
+		return err
+	}
+
+	o.basePath = o.BasePath
+	if o.basePath == "" {
+		if u, err := url.Parse(o.spec.ServerURL()); err == nil {
+			o.basePath = u.Path
+		}
+	}
+	o.basePath = strings.TrimSuffix(o.basePath, "/")
+
+	// Compile the schemas of all response headers and bodies.
+	o.validators = make(map[string]*jsonSchema)
+	for _, op := range o.spec.Operations() {
+		for _, code := range op.Responses() {
+			response := op.Response(code)
+			headers, _ := response["headers"].(map[string]interface{})
+			for name, header := range headers {
+				schema, ok := o.spec.Resolve(header)["schema"]
+				if !ok {
+					continue
+				}
+				key := validatorKey(op, code, "header", name)
+				if o.validators[key], err = o.validator(schema); err != nil {
+					return fmt.Errorf("%s %s %s header %s: %s", op.Method, op.Path, code, name, err)
+				}
+			}
+			content, _ := response["content"].(map[string]interface{})
+			for mt, media := range content {
+				schema, ok := o.spec.Resolve(media)["schema"]
+				if !ok {
+					continue
+				}
+				key := validatorKey(op, code, "content", mt)
+				if o.validators[key], err = o.validator(schema); err != nil {
+					return fmt.Errorf("%s %s %s content %s: %s", op.Method, op.Path, code, mt, err)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// validatorKey is the key of the validator of the named header or media
+// type of the response documented under code for op.
+func validatorKey(op *openapi.Operation, code, kind, name string) string {
+	return op.Method + " " + op.Path + " " + code + " " + kind + " " + name
+}
+
+var _ Preparable = &OpenAPI{}
+
+// Execute implements Check's Execute method.
+func (o *OpenAPI) Execute(t *Test) error {
+	if t.Response.Response == nil {
+		return errors.New("No response to check")
+	}
+	req := t.Response.Response.Request
+	if req == nil {
+		req = t.Request.Request
+	}
+	if req == nil {
+		return errors.New("No request to check")
+	}
+
+	path := req.URL.Path
+	if path != o.basePath && !strings.HasPrefix(path, o.basePath+"/") {
+		return ErrUndocumentedOperation
+	}
+	path = strings.TrimPrefix(path, o.basePath)
+	if path == "" {
+		path = "/"
+	}
+
+	op, _ := o.spec.Find(req.Method, path)
+	if op == nil {
+		return ErrUndocumentedOperation
+	}
+	response, code := op.ResponseFor(t.Response.Response.StatusCode)
+	if code == "" {
+		return ErrUndocumentedStatus
+	}
+
+	errs := o.checkHeaders(t, op, code, response)
+	errs = errs.Append(o.checkBody(t, op, code, response))
+	return errs.AsError()
+}
+
+// checkHeaders checks the response headers of t against the header
+// objects of the response documented under code for op.
+func (o *OpenAPI) checkHeaders(t *Test, op *openapi.Operation, code string, response map[string]interface{}) errorlist.List {
+	headers, _ := response["headers"].(map[string]interface{})
+	var errs errorlist.List
+	for _, name := range sortedKeys(headers) {
+		if strings.EqualFold(name, "Content-Type") {
+			continue // Ignored by definition of the OpenAPI spec.
+		}
+		header := o.spec.Resolve(headers[name])
+		values := t.Response.Response.Header[http.CanonicalHeaderKey(name)]
+		if len(values) == 0 {
+			if required, _ := header["required"].(bool); required {
+				errs = append(errs, fmt.Errorf("missing header %s", name))
+			}
+			continue
+		}
+		schema, ok := header["schema"]
+		if !ok {
+			continue
+		}
+		validator := o.validators[validatorKey(op, code, "header", name)]
+		for _, value := range values {
+			for _, e := range validator.validate(headerInstance(value, o.spec.Resolve(schema))) {
+				errs = append(errs, fmt.Errorf("header %s: %s", name, e))
+			}
+		}
+	}
+	return errs
+}
+
+// headerInstance converts the header value to the JSON type the schema
+// expects.
+func headerInstance(value string, schema map[string]interface{}) interface{} {
+	switch schema["type"] {
+	case "integer", "number", "boolean":
+		if v, err := decodeJSONInstance([]byte(value)); err == nil {
+			return v
+		}
+	}
+	return value
+}
+
+// checkBody checks the body of t against the content of the response
+// documented under code for op.
+func (o *OpenAPI) checkBody(t *Test, op *openapi.Operation, code string, response map[string]interface{}) error {
+	content, _ := response["content"].(map[string]interface{})
+	if len(content) == 0 {
+		return nil
+	}
+	if t.Response.BodyErr != nil {
+		return ErrBadBody
+	}
+	if len(t.Response.BodyStr) == 0 && t.Request.Method == http.MethodHead {
+		return nil
+	}
+	ct := t.Response.Response.Header.Get("Content-Type")
+	mt, ok := openapi.MatchMediaType(content, ct)
+	if !ok {
+		return fmt.Errorf("undocumented Content-Type %q", ct)
+	}
+	validator, ok := o.validators[validatorKey(op, code, "content", mt)]
+	if !ok || !openapi.IsJSON(ct) {
+		return nil
+	}
+
+	body := []byte(t.Response.BodyStr)
+	instance, err := decodeJSONInstance(body)
+	if err != nil {
+		return augmentJSONError(err, body)
+	}
+	if errs := validator.validate(instance); len(errs) > 0 {
+		return errorlist.List(errs)
+	}
+	return nil
+}
+
+// validator compiles schema, a schema object of the spec.
+func (o *OpenAPI) validator(schema interface{}) (*jsonSchema, error) {
+	doc, err := o.spec.SchemaDocument(schema)
+	if err != nil {
+		return nil, err
+	}
+	return newJSONSchema(doc)
+}
@@ -0,0 +1,154 @@
+// Copyright 2018 Volker Dobler.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ht
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+var petstoreSpec = `
+openapi: 3.0.0
+info:
+  title: Petstore
+  version: 1.0.0
+servers:
+  - url: http://petstore.example.org/v1
+paths:
+  /pets:
+    get:
+      responses:
+        "200":
+          description: all pets
+          headers:
+            X-Total:
+              required: true
+              schema:
+                type: integer
+                minimum: 0
+          content:
+            application/json:
+              schema:
+                type: array
+                items:
+                  $ref: "#/components/schemas/Pet"
+  /pets/{petId}:
+    get:
+      responses:
+        "200":
+          description: a pet
+          content:
+            application/json:
+              schema:
+                $ref: "#/components/schemas/Pet"
+            text/*: {}
+        4XX:
+          description: not found
+  /pets/mine:
+    get:
+      responses:
+        "204":
+          description: no pet
+components:
+  schemas:
+    Pet:
+      type: object
+      required: [id, name]
+      properties:
+        id:
+          type: integer
+        name:
+          type: string
+        tag:
+          type: string
+          nullable: true
+`
+
+func openAPIResponse(method, path string, status int, ct string, header http.Header, body string) Response {
+	u, _ := url.Parse("http://petstore.example.org" + path)
+	if header == nil {
+		header = http.Header{}
+	}
+	if ct != "" {
+		header.Set("Content-Type", ct)
+	}
+	return Response{
+		Response: &http.Response{
+			StatusCode: status,
+			Header:     header,
+			Request:    &http.Request{Method: method, URL: u},
+		},
+		BodyStr: body,
+	}
+}
+
+var openAPITests = []TC{
+	{openAPIResponse("GET", "/v1/pets", 200, "application/json",
+		http.Header{"X-Total": {"2"}},
+		`[{"id": 1, "name": "Tom", "tag": null}, {"id": 2, "name": "Jerry"}]`),
+		&OpenAPI{Spec: petstoreSpec}, nil},
+	{openAPIResponse("GET", "/v1/pets", 200, "application/json",
+		http.Header{"X-Total": {"-1"}},
+		`[{"id": "1", "name": "Tom"}, {"id": 2}]`),
+		&OpenAPI{Spec: petstoreSpec},
+		errors.New("header X-Total: #: -1 less than minimum 0; \u2029" +
+			"#/0/id: got string, want integer; \u2029" +
+			`#/1: missing required property "name"`)},
+	{openAPIResponse("GET", "/v1/pets", 200, "application/json", nil, `[]`),
+		&OpenAPI{Spec: petstoreSpec}, errors.New("missing header X-Total")},
+	{openAPIResponse("GET", "/v1/pets/17", 200, "application/json; charset=utf-8", nil,
+		`{"id": 17, "name": "Garfield"}`),
+		&OpenAPI{Spec: petstoreSpec}, nil},
+	{openAPIResponse("GET", "/v1/pets/17", 200, "text/plain", nil, `Garfield`),
+		&OpenAPI{Spec: petstoreSpec}, nil},
+	{openAPIResponse("GET", "/v1/pets/17", 200, "image/png", nil, `PNG`),
+		&OpenAPI{Spec: petstoreSpec},
+		errors.New(`undocumented Content-Type "image/png"`)},
+	{openAPIResponse("GET", "/v1/pets/17", 404, "", nil, ``),
+		&OpenAPI{Spec: petstoreSpec}, nil},
+	{openAPIResponse("GET", "/v1/pets/mine", 204, "", nil, ``),
+		&OpenAPI{Spec: petstoreSpec}, nil},
+	{openAPIResponse("GET", "/api/pets/mine", 204, "", nil, ``),
+		&OpenAPI{Spec: petstoreSpec, BasePath: "/api"}, nil},
+
+	// Undocumented stuff.
+	{openAPIResponse("GET", "/v1/pets/17", 500, "", nil, ``),
+		&OpenAPI{Spec: petstoreSpec}, ErrUndocumentedStatus},
+	{openAPIResponse("GET", "/v1/pets/mine", 200, "", nil, ``),
+		&OpenAPI{Spec: petstoreSpec}, ErrUndocumentedStatus},
+	{openAPIResponse("DELETE", "/v1/pets/17", 204, "", nil, ``),
+		&OpenAPI{Spec: petstoreSpec}, ErrUndocumentedOperation},
+	{openAPIResponse("GET", "/v1/owners", 200, "", nil, ``),
+		&OpenAPI{Spec: petstoreSpec}, ErrUndocumentedOperation},
+	{openAPIResponse("GET", "/v2/pets", 200, "", nil, ``),
+		&OpenAPI{Spec: petstoreSpec}, ErrUndocumentedOperation},
+	{openAPIResponse("GET", "/v10/pets", 200, "", nil, ``),
+		&OpenAPI{Spec: petstoreSpec}, ErrUndocumentedOperation},
+
+	// Bogus specs.
+	{Response{}, &OpenAPI{}, errDuringPrepare},
+	{Response{}, &OpenAPI{Spec: `swagger: "2.0"`}, errDuringPrepare},
+	{Response{}, &OpenAPI{Spec: `
+openapi: 3.0.0
+info: {title: Broken, version: 1.0.0}
+paths:
+  /pets:
+    get:
+      responses:
+        "200":
+          description: all pets
+          content:
+            application/json:
+              schema: {$ref: "#/components/schemas/Missing"}
+`}, errDuringPrepare},
+}
+
+func TestOpenAPI(t *testing.T) {
+	for i, tc := range openAPITests {
+		runTest(t, i, tc)
+	}
+}
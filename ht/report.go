@@ -65,7 +65,8 @@ var DefaultCheckTemplate = `{{define "CHECK"}}{{printf "%-7s %-15s %s" .Status .
 // DefaultTestTemplate is source for TestTmpl.
 var DefaultTestTemplate = `{{define "TEST"}}{{ToUpper .Result.Status.String}}: {{.Name}}{{if gt .Result.Tries 1}}
   {{printf "(after %d tries)" .Result.Tries}}{{end}}
-  Started: {{.Result.Started}}   Duration: {{.Result.FullDuration}}   Request: {{.Result.Duration}}{{if .Request.Request}}
+  Started: {{.Result.Started}}   Duration: {{.Result.FullDuration}}   Request: {{.Result.Duration}}{{if .Request.Request}}{{with .Response.Challenge}}
+  {{$.Request.Request.Method}} {{.URL}} ({{.StatusCode}} Digest challenge){{end}}
   {{.Request.Request.Method}} {{.Request.Request.URL.String}}{{range .Response.Redirections}}
   GET {{.}}{{end}}{{end}}{{if .Response.Response}}
   {{.Response.Response.Proto}} {{.Response.Response.Status}}{{end}}{{if .Result.Error}}
@@ -495,6 +495,7 @@ func (r Resilience) resilienceTest(orig *Test, method string, paramsAs string) *
 			ParamsAs:        paramsAs,
 			BasicAuthUser:   orig.Request.BasicAuthUser,
 			BasicAuthPass:   orig.Request.BasicAuthPass,
+			Client:          orig.Request.Client,
 		},
 		Execution: Execution{
 			Verbosity: orig.Execution.Verbosity - 1,
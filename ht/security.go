@@ -0,0 +1,302 @@
+// Copyright 2018 Volker Dobler.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// security.go provides a check of security related headers.
+
+package ht
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/vdobler/ht/errorlist"
+)
+
+func init() {
+	RegisterCheck(&SecurityHeaders{})
+}
+
+// The aspects checked by SecurityHeaders.
+var securityAspects = []string{
+	"Strict-Transport-Security",
+	"Content-Security-Policy",
+	"X-Content-Type-Options",
+	"Referrer-Policy",
+	"Permissions-Policy",
+	"X-Frame-Options",
+	"Set-Cookie",
+	"JSON",
+}
+
+// Minimum max-age of the Strict-Transport-Security header for the
+// basic and the strict level.
+const (
+	basicHSTSMaxAge  = 180 * 24 * 60 * 60
+	strictHSTSMaxAge = 365 * 24 * 60 * 60
+)
+
+// ----------------------------------------------------------------------------
+// SecurityHeaders
+
+// SecurityHeaders checks the security related headers of a response
+// against a policy level. The following aspects are checked:
+//
+//	Strict-Transport-Security  on HTTPS only: max-age of at least
+//	                           180 days (strict: one year and
+//	                           includeSubDomains and preload)
+//	Content-Security-Policy    present on HTML pages (strict: without
+//	                           'unsafe-inline' and 'unsafe-eval' for
+//	                           scripts)
+//	X-Content-Type-Options     is "nosniff"
+//	Referrer-Policy            present and not "unsafe-url" (strict:
+//	                           no-referrer, same-origin, strict-origin
+//	                           or strict-origin-when-cross-origin)
+//	Permissions-Policy         strict only: present
+//	X-Frame-Options            HTML pages must not be framable: DENY or
+//	                           SAMEORIGIN or a CSP frame-ancestors
+//	Set-Cookie                 on HTTPS cookies are Secure (strict: and
+//	                           HttpOnly with a SameSite attribute)
+//	JSON                       JSON bodies are sent as application/json
+//	                           and are objects (no top-level arrays or
+//	                           primitives)
+//
+// See https://httpsecurityreport.com/best_practice.html for background.
+type SecurityHeaders struct {
+	// Level is the policy level, either "basic" or "strict".
+	// The zero value is equivalent to "basic".
+	Level string `json:",omitempty"`
+
+	// Ignore lists aspects not to check, e.g. "Permissions-Policy"
+	// or "JSON". See above for the list of aspects.
+	Ignore []string `json:",omitempty"`
+}
+
+// Prepare implements Check's Prepare method.
+func (s *SecurityHeaders) Prepare(*Test) error {
+	switch s.Level {
+	case "", "basic", "strict":
+	default:
+		return MalformedCheck{fmt.Errorf("unknown Level %q", s.Level)}
+	}
+outer:
+	for _, ignore := range s.Ignore {
+		for _, aspect := range securityAspects {
+			if strings.EqualFold(ignore, aspect) {
+				continue outer
+			}
+		}
+		return MalformedCheck{fmt.Errorf("unknown aspect %q in Ignore", ignore)}
+	}
+	return nil
+}
+
+var _ Preparable = &SecurityHeaders{}
+
+// Execute implements Check's Execute method.
+func (s *SecurityHeaders) Execute(t *Test) error {
+	if t.Response.Response == nil {
+		return errors.New("no response available")
+	}
+	header := t.Response.Response.Header
+	strict := s.Level == "strict"
+	https := t.Response.Response.TLS != nil ||
+		(t.Request.Request != nil && t.Request.Request.URL.Scheme == "https")
+	mediatype, _, _ := mime.ParseMediaType(header.Get("Content-Type"))
+	html := mediatype == "text/html" || mediatype == "application/xhtml+xml"
+	csp := parseCSP(header.Get("Content-Security-Policy"))
+
+	errs := errorlist.List{}
+	check := func(aspect string, fn func() error) {
+		for _, ignore := range s.Ignore {
+			if strings.EqualFold(ignore, aspect) {
+				return
+			}
+		}
+		if err := fn(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %s", aspect, err))
+		}
+	}
+
+	if https {
+		check("Strict-Transport-Security", func() error {
+			return checkHSTS(header.Get("Strict-Transport-Security"), strict)
+		})
+	}
+	if html {
+		check("Content-Security-Policy", func() error {
+			if header.Get("Content-Security-Policy") == "" {
+				return errors.New("missing")
+			}
+			if !strict {
+				return nil
+			}
+			sources, _ := csp.sources("script-src")
+			for _, src := range sources {
+				if src == "'unsafe-inline'" || src == "'unsafe-eval'" {
+					return fmt.Errorf("scripts allow %s", src)
+				}
+			}
+			return nil
+		})
+	}
+	check("X-Content-Type-Options", func() error {
+		switch v := header.Get("X-Content-Type-Options"); {
+		case v == "":
+			return errors.New("missing")
+		case !strings.EqualFold(v, "nosniff"):
+			return fmt.Errorf("got %q, want nosniff", v)
+		}
+		return nil
+	})
+	check("Referrer-Policy", func() error {
+		return checkReferrerPolicy(header.Get("Referrer-Policy"), strict)
+	})
+	if strict {
+		check("Permissions-Policy", func() error {
+			if header.Get("Permissions-Policy") == "" {
+				return errors.New("missing")
+			}
+			return nil
+		})
+	}
+	if html {
+		check("X-Frame-Options", func() error {
+			if _, ok := csp["frame-ancestors"]; ok {
+				return nil
+			}
+			switch v := strings.ToUpper(header.Get("X-Frame-Options")); v {
+			case "DENY", "SAMEORIGIN":
+				return nil
+			case "":
+				return errors.New("missing (and no CSP frame-ancestors)")
+			default:
+				return fmt.Errorf("bad value %q", v)
+			}
+		})
+	}
+	check("Set-Cookie", func() error {
+		return checkCookieFlags(t.Response.Response.Cookies(), https, strict)
+	})
+	check("JSON", func() error {
+		return checkJSONDelivery(mediatype, t.Response.BodyStr)
+	})
+
+	return errs.AsError()
+}
+
+// checkHSTS checks the value of a Strict-Transport-Security header.
+func checkHSTS(hsts string, strict bool) error {
+	if hsts == "" {
+		return errors.New("missing")
+	}
+	maxAge, subdomains, preload := -1, false, false
+	for _, directive := range strings.Split(hsts, ";") {
+		directive = strings.ToLower(strings.TrimSpace(directive))
+		switch {
+		case strings.HasPrefix(directive, "max-age="):
+			v := strings.Trim(directive[len("max-age="):], `"`)
+			if age, err := strconv.Atoi(v); err == nil {
+				maxAge = age
+			}
+		case directive == "includesubdomains":
+			subdomains = true
+		case directive == "preload":
+			preload = true
+		}
+	}
+
+	min := basicHSTSMaxAge
+	if strict {
+		min = strictHSTSMaxAge
+	}
+	switch {
+	case maxAge < 0:
+		return errors.New("missing max-age")
+	case maxAge < min:
+		return fmt.Errorf("max-age=%d too short, want at least %d", maxAge, min)
+	case strict && !subdomains:
+		return errors.New("missing includeSubDomains")
+	case strict && !preload:
+		return errors.New("missing preload")
+	}
+	return nil
+}
+
+// checkReferrerPolicy checks the value of a Referrer-Policy header.
+// The last recognised policy of a comma separated list is used.
+func checkReferrerPolicy(value string, strict bool) error {
+	if value == "" {
+		return errors.New("missing")
+	}
+	policies := strings.Split(value, ",")
+	policy := strings.ToLower(strings.TrimSpace(policies[len(policies)-1]))
+	switch policy {
+	case "no-referrer", "same-origin", "strict-origin", "strict-origin-when-cross-origin":
+		return nil
+	case "origin", "origin-when-cross-origin", "no-referrer-when-downgrade":
+		if !strict {
+			return nil
+		}
+	}
+	return fmt.Errorf("weak policy %q", policy)
+}
+
+// checkCookieFlags checks the security relevant attributes of cookies.
+func checkCookieFlags(cookies []*http.Cookie, https bool, strict bool) error {
+	var problems []string
+	for _, cookie := range cookies {
+		missing := []string{}
+		if https && !cookie.Secure {
+			missing = append(missing, "Secure")
+		}
+		if strict && !cookie.HttpOnly {
+			missing = append(missing, "HttpOnly")
+		}
+		switch cookie.SameSite {
+		case http.SameSiteLaxMode, http.SameSiteStrictMode, http.SameSiteNoneMode:
+		default:
+			if strict {
+				missing = append(missing, "SameSite")
+			}
+		}
+		if len(missing) > 0 {
+			problems = append(problems, fmt.Sprintf("cookie %s lacks %s",
+				cookie.Name, strings.Join(missing, ", ")))
+		}
+	}
+	if len(problems) > 0 {
+		return errors.New(strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// checkJSONDelivery checks that a JSON body is delivered with a JSON
+// content type and is an object and not an array or a primitive.
+func checkJSONDelivery(mediatype string, body string) error {
+	isJSONType := mediatype == "application/json" || strings.HasSuffix(mediatype, "+json")
+	trimmed := strings.TrimSpace(body)
+	if !isJSONType {
+		// Only bodies looking like an object or an array are considered
+		// JSON: "123" could be anything.
+		if trimmed == "" || (trimmed[0] != '{' && trimmed[0] != '[') || !json.Valid([]byte(trimmed)) {
+			return nil
+		}
+		if mediatype == "" {
+			return errors.New("JSON body without Content-Type")
+		}
+		return fmt.Errorf("JSON body delivered as %s", mediatype)
+	}
+	if trimmed == "" {
+		return nil
+	}
+	if trimmed[0] != '{' {
+		return errors.New("top-level value is not an object")
+	}
+	return nil
+}
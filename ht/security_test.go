@@ -0,0 +1,133 @@
+// Copyright 2018 Volker Dobler.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ht
+
+import (
+	"crypto/tls"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/vdobler/ht/errorlist"
+)
+
+// secureResponse returns a HTTPS response with the given header and body
+// on top of a set of headers passing the strict SecurityHeaders check.
+func secureResponse(body string, kv ...string) Response {
+	header := http.Header{
+		"Content-Type":              {"text/html; charset=utf-8"},
+		"Strict-Transport-Security": {"max-age=63072000; includeSubDomains; preload"},
+		"Content-Security-Policy":   {"default-src 'self'; frame-ancestors 'none'"},
+		"X-Content-Type-Options":    {"nosniff"},
+		"Referrer-Policy":           {"strict-origin-when-cross-origin"},
+		"Permissions-Policy":        {"geolocation=()"},
+	}
+	for i := 0; i < len(kv); i += 2 {
+		if kv[i+1] == "" {
+			header.Del(kv[i])
+		} else {
+			header.Set(kv[i], kv[i+1])
+		}
+	}
+	return Response{
+		Response: &http.Response{Header: header, TLS: &tls.ConnectionState{}},
+		BodyStr:  body,
+	}
+}
+
+var basic = &SecurityHeaders{}
+var strict = &SecurityHeaders{Level: "strict"}
+
+var securityHeadersTests = []TC{
+	{secureResponse("<html></html>"), basic, nil},
+	{secureResponse("<html></html>"), strict, nil},
+
+	// Strict-Transport-Security
+	{secureResponse("", "Strict-Transport-Security", ""), basic,
+		errors.New("Strict-Transport-Security: missing")},
+	{secureResponse("", "Strict-Transport-Security", "max-age=3600"), basic,
+		errors.New("Strict-Transport-Security: max-age=3600 too short, want at least 15552000")},
+	{secureResponse("", "Strict-Transport-Security", "max-age=31536000"), basic, nil},
+	{secureResponse("", "Strict-Transport-Security", "max-age=31536000"), strict,
+		errors.New("Strict-Transport-Security: missing includeSubDomains")},
+	{secureResponse("", "Strict-Transport-Security", "max-age=31536000; includeSubDomains"), strict,
+		errors.New("Strict-Transport-Security: missing preload")},
+	{secureResponse("", "Strict-Transport-Security", "includeSubDomains"), basic,
+		errors.New("Strict-Transport-Security: missing max-age")},
+
+	// Content-Security-Policy and X-Frame-Options
+	{secureResponse("", "Content-Security-Policy", ""), basic,
+		errorlist.List{errors.New("Content-Security-Policy: missing"),
+			errors.New("X-Frame-Options: missing (and no CSP frame-ancestors)")}},
+	{secureResponse("", "Content-Security-Policy", "", "X-Frame-Options", "DENY"), basic,
+		errors.New("Content-Security-Policy: missing")},
+	{secureResponse("", "Content-Security-Policy", "script-src 'self' 'unsafe-inline'", "X-Frame-Options", "sameorigin"), basic, nil},
+	{secureResponse("", "Content-Security-Policy", "script-src 'self' 'unsafe-inline'", "X-Frame-Options", "sameorigin"), strict,
+		errors.New("Content-Security-Policy: scripts allow 'unsafe-inline'")},
+	{secureResponse("", "Content-Security-Policy", "default-src * 'unsafe-eval'", "X-Frame-Options", "ALLOW-FROM x"), strict,
+		errorlist.List{errors.New("Content-Security-Policy: scripts allow 'unsafe-eval'"),
+			errors.New("X-Frame-Options: bad value \"ALLOW-FROM X\"")}},
+
+	// X-Content-Type-Options
+	{secureResponse("", "X-Content-Type-Options", ""), basic,
+		errors.New("X-Content-Type-Options: missing")},
+	{secureResponse("", "X-Content-Type-Options", "sniff"), basic,
+		errors.New("X-Content-Type-Options: got \"sniff\", want nosniff")},
+
+	// Referrer-Policy
+	{secureResponse("", "Referrer-Policy", ""), basic,
+		errors.New("Referrer-Policy: missing")},
+	{secureResponse("", "Referrer-Policy", "unsafe-url"), basic,
+		errors.New("Referrer-Policy: weak policy \"unsafe-url\"")},
+	{secureResponse("", "Referrer-Policy", "origin"), basic, nil},
+	{secureResponse("", "Referrer-Policy", "origin"), strict,
+		errors.New("Referrer-Policy: weak policy \"origin\"")},
+	{secureResponse("", "Referrer-Policy", "unsafe-url, no-referrer"), strict, nil},
+
+	// Permissions-Policy
+	{secureResponse("", "Permissions-Policy", ""), basic, nil},
+	{secureResponse("", "Permissions-Policy", ""), strict,
+		errors.New("Permissions-Policy: missing")},
+	{secureResponse("", "Permissions-Policy", ""),
+		&SecurityHeaders{Level: "strict", Ignore: []string{"permissions-policy"}}, nil},
+
+	// Set-Cookie
+	{secureResponse("", "Set-Cookie", "a=b; Secure"), basic, nil},
+	{secureResponse("", "Set-Cookie", "a=b"), basic,
+		errors.New("Set-Cookie: cookie a lacks Secure")},
+	{secureResponse("", "Set-Cookie", "a=b; Secure"), strict,
+		errors.New("Set-Cookie: cookie a lacks HttpOnly, SameSite")},
+	{secureResponse("", "Set-Cookie", "a=b; Secure; HttpOnly; SameSite=Lax"), strict, nil},
+
+	// JSON
+	{secureResponse(`{"a": 1}`, "Content-Type", "application/json"), strict, nil},
+	{secureResponse(`{"a": 1}`, "Content-Type", "application/problem+json"), strict, nil},
+	{secureResponse(`[1, 2]`, "Content-Type", "application/json"), strict,
+		errors.New("JSON: top-level value is not an object")},
+	{secureResponse(`"foo"`, "Content-Type", "application/json"), strict,
+		errors.New("JSON: top-level value is not an object")},
+	{secureResponse(`{"a": 1}`, "Content-Type", "text/plain"), strict,
+		errors.New("JSON: JSON body delivered as text/plain")},
+	{secureResponse(`{"a": 1}`, "Content-Type", "text/plain"),
+		&SecurityHeaders{Ignore: []string{"JSON"}}, nil},
+	{secureResponse(`{no json}`, "Content-Type", "text/plain"), strict, nil},
+
+	// Plain HTTP
+	{Response{Response: &http.Response{Header: http.Header{
+		"X-Content-Type-Options": {"nosniff"},
+		"Referrer-Policy":        {"no-referrer"},
+		"Set-Cookie":             {"a=b"},
+	}}}, basic, nil},
+
+	// Malformed
+	{secureResponse(""), &SecurityHeaders{Level: "paranoid"}, errDuringPrepare},
+	{secureResponse(""), &SecurityHeaders{Ignore: []string{"X-Powered-By"}}, errDuringPrepare},
+}
+
+func TestSecurityHeaders(t *testing.T) {
+	for i, tc := range securityHeadersTests {
+		runTest(t, i, tc)
+	}
+}
@@ -0,0 +1,340 @@
+// Copyright 2018 Volker Dobler.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// sign.go provides signing of requests with AWS Signature Version 4
+// and generic HMAC schemes.
+
+package ht
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ----------------------------------------------------------------------------
+// AWS Signature Version 4
+
+// AWSSigV4 signs requests with AWS Signature Version 4, see
+// https://docs.aws.amazon.com/general/latest/gr/signature-version-4.html
+// The signature covers the method, path, query, the Host, Content-Type
+// and all X-Amz-* headers and the body.
+type AWSSigV4 struct {
+	// AccessKey and SecretKey are the credentials used to sign.
+	AccessKey string
+	SecretKey string
+
+	// SessionToken is the optional token of temporary credentials
+	// sent in the X-Amz-Security-Token header.
+	SessionToken string `json:",omitempty"`
+
+	// Region and Service define the scope of the signature,
+	// e.g. "eu-central-1" and "execute-api".
+	Region  string
+	Service string
+}
+
+func (a *AWSSigV4) validate() error {
+	if a.AccessKey == "" || a.SecretKey == "" {
+		return errors.New("missing AccessKey or SecretKey")
+	}
+	if a.Region == "" || a.Service == "" {
+		return errors.New("missing Region or Service")
+	}
+	return nil
+}
+
+// sign req with the given body at time now.
+func (a *AWSSigV4) sign(req *http.Request, body string, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	payloadHash := sha256Hex(body)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	if a.Service == "s3" {
+		req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	}
+	if a.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", a.SessionToken)
+	}
+
+	// Canonical headers.
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	headers := map[string]string{"host": host}
+	for name, values := range req.Header {
+		name = strings.ToLower(name)
+		if name == "content-type" || strings.HasPrefix(name, "x-amz-") {
+			trimmed := make([]string, len(values))
+			for i, v := range values {
+				trimmed[i] = strings.Join(strings.Fields(v), " ")
+			}
+			headers[name] = strings.Join(trimmed, ",")
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	canonicalHeaders := ""
+	for _, name := range names {
+		canonicalHeaders += name + ":" + headers[name] + "\n"
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		awsCanonicalPath(req.URL.Path, a.Service),
+		canonicalQuery(req.URL.Query(), awsEscape),
+		canonicalHeaders,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + a.Region + "/" + a.Service + "/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" +
+		sha256Hex(canonicalRequest)
+
+	key := []byte("AWS4" + a.SecretKey)
+	for _, part := range []string{date, a.Region, a.Service, "aws4_request"} {
+		key = hmacSum(sha256.New, key, part)
+	}
+	signature := hex.EncodeToString(hmacSum(sha256.New, key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		a.AccessKey, scope, signedHeaders, signature))
+}
+
+// awsCanonicalPath encodes the segments of path once for S3 and twice for
+// all other services.
+func awsCanonicalPath(path string, service string) string {
+	if path == "" {
+		return "/"
+	}
+	segments := strings.Split(path, "/")
+	for i, s := range segments {
+		segments[i] = awsEscape(s)
+		if service != "s3" {
+			segments[i] = awsEscape(segments[i])
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// awsEscape percent-encodes everything except the unreserved characters
+// of RFC 3986.
+func awsEscape(s string) string {
+	buf := &strings.Builder{}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' ||
+			c == '-' || c == '_' || c == '.' || c == '~' {
+			buf.WriteByte(c)
+		} else {
+			fmt.Fprintf(buf, "%%%02X", c)
+		}
+	}
+	return buf.String()
+}
+
+// canonicalQuery returns the query parameters sorted by name and value
+// with names and values escaped.
+func canonicalQuery(query url.Values, escape func(string) string) string {
+	params := []string{}
+	for name, values := range query {
+		for _, v := range values {
+			params = append(params, escape(name)+"="+escape(v))
+		}
+	}
+	sort.Strings(params)
+	return strings.Join(params, "&")
+}
+
+// ----------------------------------------------------------------------------
+// Generic HMAC signatures
+
+// The components of a request which can be signed by HMACSigner.
+var hmacComponents = map[string]bool{
+	"method":      true,
+	"host":        true,
+	"path":        true,
+	"query":       true,
+	"body":        true,
+	"body-sha256": true,
+	"timestamp":   true,
+}
+
+// HMACSigner signs requests with a HMAC computed over a string built from
+// components of the request. The signature is sent in a header.
+type HMACSigner struct {
+	// Key is the secret key. KeyEncoding is the encoding of the Key:
+	// "" (raw bytes), "base64" or "hex".
+	Key         string
+	KeyEncoding string `json:",omitempty"`
+
+	// Algorithm is the hash function used: "SHA256" (the default),
+	// "SHA1" or "SHA512".
+	Algorithm string `json:",omitempty"`
+
+	// Components are the parts of the request which are joined with
+	// Separator (default "\n") to the string to sign:
+	//     method       the HTTP method
+	//     host         the host
+	//     path         the escaped URL path
+	//     query        the query parameters sorted by name and value
+	//     body         the request body
+	//     body-sha256  hex encoded SHA-256 of the request body
+	//     timestamp    the timestamp sent in TimestampHeader
+	//     header:Name  the value of the request header Name
+	// The zero value signs method, path, query and body.
+	Components []string `json:",omitempty"`
+	Separator  string   `json:",omitempty"`
+
+	// TimestampHeader is the header in which the current Unix time (in
+	// seconds) is sent.
+	TimestampHeader string `json:",omitempty"`
+
+	// Header is the header in which the signature is sent, defaults
+	// to "X-Signature". Prefix is prepended to the signature, e.g.
+	// "HMAC-SHA256 ".
+	Header string `json:",omitempty"`
+	Prefix string `json:",omitempty"`
+
+	// Encoding of the signature: "hex" (the default) or "base64".
+	Encoding string `json:",omitempty"`
+}
+
+func (h *HMACSigner) validate() error {
+	if h.Key == "" {
+		return errors.New("missing Key")
+	}
+	if _, err := h.key(); err != nil {
+		return err
+	}
+	if h.hash() == nil {
+		return fmt.Errorf("unknown Algorithm %q", h.Algorithm)
+	}
+	for _, c := range h.Components {
+		if !hmacComponents[c] && !strings.HasPrefix(c, "header:") {
+			return fmt.Errorf("unknown component %q", c)
+		}
+		if c == "timestamp" && h.TimestampHeader == "" {
+			return errors.New("timestamp component needs a TimestampHeader")
+		}
+	}
+	switch h.Encoding {
+	case "", "hex", "base64":
+	default:
+		return fmt.Errorf("unknown Encoding %q", h.Encoding)
+	}
+	return nil
+}
+
+func (h *HMACSigner) key() ([]byte, error) {
+	switch h.KeyEncoding {
+	case "":
+		return []byte(h.Key), nil
+	case "base64":
+		return base64.StdEncoding.DecodeString(h.Key)
+	case "hex":
+		return hex.DecodeString(h.Key)
+	}
+	return nil, fmt.Errorf("unknown KeyEncoding %q", h.KeyEncoding)
+}
+
+func (h *HMACSigner) hash() func() hash.Hash {
+	switch strings.ToUpper(h.Algorithm) {
+	case "", "SHA256":
+		return sha256.New
+	case "SHA1":
+		return sha1.New
+	case "SHA512":
+		return sha512.New
+	}
+	return nil
+}
+
+// sign req with the given body at time now.
+func (h *HMACSigner) sign(req *http.Request, body string, now time.Time) {
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	if h.TimestampHeader != "" {
+		req.Header.Set(h.TimestampHeader, timestamp)
+	}
+
+	components := h.Components
+	if len(components) == 0 {
+		components = []string{"method", "path", "query", "body"}
+	}
+	parts := make([]string, len(components))
+	for i, c := range components {
+		switch c {
+		case "method":
+			parts[i] = req.Method
+		case "host":
+			parts[i] = req.Host
+			if parts[i] == "" {
+				parts[i] = req.URL.Host
+			}
+		case "path":
+			parts[i] = req.URL.EscapedPath()
+		case "query":
+			parts[i] = canonicalQuery(req.URL.Query(), url.QueryEscape)
+		case "body":
+			parts[i] = body
+		case "body-sha256":
+			parts[i] = sha256Hex(body)
+		case "timestamp":
+			parts[i] = timestamp
+		default:
+			parts[i] = req.Header.Get(strings.TrimPrefix(c, "header:"))
+		}
+	}
+	separator := h.Separator
+	if separator == "" {
+		separator = "\n"
+	}
+
+	key, _ := h.key() // Checked in validate.
+	sum := hmacSum(h.hash(), key, strings.Join(parts, separator))
+	signature := hex.EncodeToString(sum)
+	if h.Encoding == "base64" {
+		signature = base64.StdEncoding.EncodeToString(sum)
+	}
+	header := h.Header
+	if header == "" {
+		header = "X-Signature"
+	}
+	req.Header.Set(header, h.Prefix+signature)
+}
+
+// ----------------------------------------------------------------------------
+// Helpers
+
+func sha256Hex(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSum(h func() hash.Hash, key []byte, data string) []byte {
+	mac := hmac.New(h, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,138 @@
+// Copyright 2018 Volker Dobler.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ht
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Test cases from the AWS Signature Version 4 test suite.
+var awsSigV4Tests = []struct {
+	method, url, body string
+	header            http.Header
+	want              string
+}{
+	{"GET", "https://example.amazonaws.com/", "", nil,
+		"AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=host;x-amz-date, Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"},
+	{"GET", "https://example.amazonaws.com/?Param2=value2&Param1=value1", "", nil,
+		"AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=host;x-amz-date, Signature=b97d918cfa904a5beff61c982a1b6f458b799221646efd99d3219ec94cdf2500"},
+	{"POST", "https://example.amazonaws.com/", "Param1=value1",
+		http.Header{"Content-Type": {"application/x-www-form-urlencoded"}},
+		"AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=content-type;host;x-amz-date, Signature=ff11897932ad3f4e8b18135d722051e5ac45fc38421b1da7b9d196a0fe09473a"},
+}
+
+func TestAWSSigV4(t *testing.T) {
+	signer := &AWSSigV4{
+		AccessKey: "AKIDEXAMPLE",
+		SecretKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+		Region:    "us-east-1",
+		Service:   "service",
+	}
+	now := time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC)
+	for i, tc := range awsSigV4Tests {
+		req, err := http.NewRequest(tc.method, tc.url, strings.NewReader(tc.body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for name, values := range tc.header {
+			req.Header[name] = values
+		}
+		signer.sign(req, tc.body, now)
+		if got := req.Header.Get("X-Amz-Date"); got != "20150830T123600Z" {
+			t.Errorf("%d. X-Amz-Date = %q", i, got)
+		}
+		if got := req.Header.Get("Authorization"); got != tc.want {
+			t.Errorf("%d. Got  %s\nwant %s", i, got, tc.want)
+		}
+	}
+}
+
+func TestAWSSigV4Request(t *testing.T) {
+	signer := &AWSSigV4{AccessKey: "AKIDEXAMPLE", SecretKey: "secret",
+		Region: "us-east-1", Service: "service"}
+	req, err := http.NewRequest("GET", "https://example.amazonaws.com/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("X-Amz-Meta", "  a   b ")
+	signer.sign(req, "", time.Now())
+	if got := req.Header.Get("X-Amz-Meta"); got != "  a   b " {
+		t.Errorf("Header modified to %q", got)
+	}
+
+	for i, tc := range []struct {
+		path, service, want string
+	}{
+		{"", "service", "/"},
+		{"/", "service", "/"},
+		{"/documents and settings/", "service", "/documents%2520and%2520settings/"},
+		{"/a$b!/c", "service", "/a%2524b%2521/c"},
+		{"/a$b!/c d", "s3", "/a%24b%21/c%20d"},
+		{"/ä", "s3", "/%C3%A4"},
+	} {
+		if got := awsCanonicalPath(tc.path, tc.service); got != tc.want {
+			t.Errorf("%d. awsCanonicalPath(%q, %q) = %q, want %q",
+				i, tc.path, tc.service, got, tc.want)
+		}
+	}
+}
+
+// hmacHandler verifies the X-Signature over method, path and body.
+func hmacHandler(w http.ResponseWriter, r *http.Request) {
+	body, _ := ioutil.ReadAll(r.Body)
+	mac := hmac.New(sha256.New, []byte("s3cr3t"))
+	mac.Write([]byte(r.Method + "|" + r.URL.Path + "|" + r.Header.Get("X-Time") +
+		"|" + string(body)))
+	want := "HMAC " + hex.EncodeToString(mac.Sum(nil))
+	if r.Header.Get("X-Signature") != want {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	w.Write([]byte("signed"))
+}
+
+func TestHMACSigner(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(hmacHandler))
+	defer ts.Close()
+
+	for i, tc := range []struct {
+		signer HMACSigner
+		want   Status
+	}{
+		{HMACSigner{Key: "s3cr3t", Components: []string{"method", "path",
+			"timestamp", "body"}, Separator: "|", TimestampHeader: "X-Time",
+			Prefix: "HMAC "}, Pass},
+		{HMACSigner{Key: "733363723374", KeyEncoding: "hex",
+			Components: []string{"method", "path", "header:X-Time", "body"},
+			Separator:  "|", TimestampHeader: "X-Time", Prefix: "HMAC "}, Pass},
+		{HMACSigner{Key: "7333637233740a", KeyEncoding: "hex"}, Fail},
+		{HMACSigner{Key: "s3cr3t", Algorithm: "MD4"}, Bogus},
+		{HMACSigner{Key: "s3cr3t", Components: []string{"timestamp"}}, Bogus},
+		{HMACSigner{Key: "s3cr3t", Components: []string{"fragment"}}, Bogus},
+	} {
+		test := &Test{
+			Request: Request{
+				Method: "POST",
+				URL:    ts.URL + "/api/thing",
+				Body:   "Hello World",
+				Auth:   &Auth{HMAC: &tc.signer},
+			},
+			Checks: CheckList{StatusCode{Expect: 200}, &Body{Equals: "signed"}},
+		}
+		test.Run()
+		if test.Result.Status != tc.want {
+			t.Errorf("%d. Got %s %v, want %s", i, test.Result.Status,
+				test.Result.Error, tc.want)
+		}
+	}
+}
@@ -7,12 +7,18 @@
 package ht
 
 import (
+	"crypto/tls"
 	"fmt"
+	"net/http/httptrace"
+	"sync"
 	"time"
+
+	"github.com/vdobler/ht/errorlist"
 )
 
 func init() {
 	RegisterCheck(ResponseTime{})
+	RegisterCheck(&Timing{})
 }
 
 // ----------------------------------------------------------------------------
@@ -48,3 +54,137 @@ func (c ResponseTime) Prepare(*Test) error {
 }
 
 var _ Preparable = ResponseTime{}
+
+// ----------------------------------------------------------------------------
+// Timing
+
+// Timing checks the durations of the individual phases of the request as
+// recorded in the Timings of the response. A zero limit does not restrict
+// the phase. Phases which did not happen (e.g. the DNS lookup on a reused
+// connection) pass every limit.
+type Timing struct {
+	// DNS is the maximal duration of the DNS lookup.
+	DNS time.Duration `json:",omitempty"`
+
+	// Connect is the maximal duration of the TCP connection setup.
+	Connect time.Duration `json:",omitempty"`
+
+	// TLS is the maximal duration of the TLS handshake.
+	TLS time.Duration `json:",omitempty"`
+
+	// TTFB is the maximal time to first byte of the response, measured
+	// from the start of the request.
+	TTFB time.Duration `json:",omitempty"`
+
+	// Transfer is the maximal duration to read the body after the first
+	// byte was received.
+	Transfer time.Duration `json:",omitempty"`
+}
+
+// Prepare implements Check's Prepare method.
+func (c *Timing) Prepare(*Test) error {
+	if c.DNS < 0 || c.Connect < 0 || c.TLS < 0 || c.TTFB < 0 || c.Transfer < 0 {
+		return MalformedCheck{fmt.Errorf("negative limit")}
+	}
+	return nil
+}
+
+var _ Preparable = &Timing{}
+
+// Execute implements Check's Execute method.
+func (c *Timing) Execute(t *Test) error {
+	actual := t.Response.Timings
+	errs := errorlist.List{}
+	for _, phase := range []struct {
+		name       string
+		got, limit time.Duration
+	}{
+		{"DNS lookup", actual.DNS, c.DNS},
+		{"TCP connect", actual.Connect, c.Connect},
+		{"TLS handshake", actual.TLS, c.TLS},
+		{"Time to first byte", actual.TTFB, c.TTFB},
+		{"Content transfer", actual.Transfer, c.Transfer},
+	} {
+		if phase.limit > 0 && phase.got > phase.limit {
+			errs = append(errs, fmt.Errorf("%s took %s (allowed max %s)",
+				phase.name, phase.got, phase.limit))
+		}
+	}
+	return errs.AsError()
+}
+
+// ----------------------------------------------------------------------------
+// Recording the Timings
+
+// requestTracer records the durations of the phases of a request.
+// The hooks of a httptrace.ClientTrace may be called concurrently.
+type requestTracer struct {
+	mu                               sync.Mutex
+	dnsStart, connectStart, tlsStart time.Time
+	firstByte                        time.Time
+	dns, connect, tls                time.Duration
+}
+
+// clientTrace returns the hooks to record the phases.
+func (r *requestTracer) clientTrace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) {
+			r.mu.Lock()
+			r.dnsStart = time.Now()
+			r.mu.Unlock()
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			r.mu.Lock()
+			r.dns += time.Since(r.dnsStart)
+			r.mu.Unlock()
+		},
+		ConnectStart: func(string, string) {
+			r.mu.Lock()
+			// Several addresses may be dialed in parallel: Measure
+			// from the first attempt.
+			if r.connectStart.IsZero() {
+				r.connectStart = time.Now()
+			}
+			r.mu.Unlock()
+		},
+		ConnectDone: func(_, _ string, err error) {
+			r.mu.Lock()
+			if err == nil && !r.connectStart.IsZero() {
+				r.connect += time.Since(r.connectStart)
+				r.connectStart = time.Time{}
+			}
+			r.mu.Unlock()
+		},
+		TLSHandshakeStart: func() {
+			r.mu.Lock()
+			r.tlsStart = time.Now()
+			r.mu.Unlock()
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			r.mu.Lock()
+			r.tls += time.Since(r.tlsStart)
+			r.mu.Unlock()
+		},
+		GotFirstResponseByte: func() {
+			r.mu.Lock()
+			r.firstByte = time.Now()
+			r.mu.Unlock()
+		},
+	}
+}
+
+// timings of the request started at start whose body was read at end.
+func (r *requestTracer) timings(start, end time.Time) Timings {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	timings := Timings{
+		DNS:     r.dns,
+		Connect: r.connect,
+		TLS:     r.tls,
+	}
+	if !r.firstByte.IsZero() {
+		timings.TTFB = r.firstByte.Sub(start)
+		timings.Transfer = end.Sub(r.firstByte)
+	}
+	return timings
+}
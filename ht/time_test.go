@@ -5,7 +5,11 @@
 package ht
 
 import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 )
 
 var responseTimeTests = []TC{
@@ -24,3 +28,53 @@ func TestResponseTime(t *testing.T) {
 		runTest(t, i, tc)
 	}
 }
+
+var timingTests = []TC{
+	{Response{}, &Timing{DNS: ms, TTFB: ms}, nil},
+	{Response{Timings: Timings{DNS: 2 * ms, TTFB: 10 * ms}},
+		&Timing{DNS: 5 * ms, Connect: ms, TTFB: 20 * ms}, nil},
+	{Response{Timings: Timings{DNS: 2 * ms, TLS: 30 * ms, TTFB: 50 * ms}},
+		&Timing{TLS: 20 * ms}, errCheck},
+	{Response{Timings: Timings{TTFB: 10 * ms, Transfer: 30 * ms}},
+		&Timing{Transfer: 20 * ms}, errCheck},
+	{Response{}, &Timing{Connect: -ms}, errDuringPrepare},
+}
+
+func TestTiming(t *testing.T) {
+	for i, tc := range timingTests {
+		runTest(t, i, tc)
+	}
+}
+
+func TestTimingsRecorded(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("Hello"))
+			w.(http.Flusher).Flush()
+			time.Sleep(20 * ms)
+			w.Write([]byte(" World"))
+		}))
+	defer ts.Close()
+	SkipTLSVerify = true
+	defer func() { SkipTLSVerify = false }()
+
+	test := &Test{
+		Request: Request{URL: ts.URL},
+		Checks: CheckList{
+			&Timing{Transfer: 5 * ms},
+		},
+	}
+	test.Run()
+	if test.Result.Status != Fail {
+		t.Fatalf("Got %s %v", test.Result.Status, test.Result.Error)
+	}
+	timings := test.Response.Timings
+	if timings.Connect <= 0 || timings.TLS <= 0 || timings.TTFB < timings.TLS ||
+		timings.Transfer < 20*ms {
+		t.Errorf("Got %+v", timings)
+	}
+	if got := test.Result.Error.Error(); !strings.HasPrefix(got,
+		"Check Timing: Content transfer took ") {
+		t.Errorf("Got error %s", got)
+	}
+}
@@ -0,0 +1,249 @@
+// Copyright 2018 Volker Dobler.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// tls.go provides a check of the TLS certificate and handshake.
+
+package ht
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/vdobler/ht/errorlist"
+)
+
+func init() {
+	RegisterCheck(&TLS{})
+}
+
+var errNoTLS = errors.New("not a TLS connection")
+
+// tlsVersions maps the names used in TLS.MinVersion to the version constants.
+var tlsVersions = map[string]uint16{
+	"TLS1.0": tls.VersionTLS10,
+	"TLS1.1": tls.VersionTLS11,
+	"TLS1.2": tls.VersionTLS12,
+	"TLS1.3": tls.VersionTLS13,
+}
+
+// tlsVersionName returns the name of the TLS version v.
+func tlsVersionName(v uint16) string {
+	for name, version := range tlsVersions {
+		if version == v {
+			return name
+		}
+	}
+	return fmt.Sprintf("0x%04x", v)
+}
+
+// ----------------------------------------------------------------------------
+// TLS
+
+// TLS checks the certificate presented by the server and the parameters
+// of the TLS handshake. The connection state of the response is inspected;
+// if it is not available (e.g. for a test loaded from disk) an own
+// handshake with the host of the request URL is made using the Client
+// configuration of the test. Such a handshake cannot be made through a
+// proxy.
+//
+// The zero value checks that the response was received over TLS only.
+type TLS struct {
+	// MinDaysValid is the minimum number of days the server certificate
+	// must still be valid.
+	MinDaysValid int `json:",omitempty"`
+
+	// Names lists the host names the certificate must be valid for.
+	// Subject alternative names (including wildcards) and the common
+	// name are considered.
+	Names []string `json:",omitempty"`
+
+	// Subject is applied to the subject of the certificate formatted
+	// like "CN=www.example.org,O=Example Corp,C=CH".
+	Subject Condition `json:",omitempty"`
+
+	// Issuer is applied to the issuer of the certificate formatted
+	// like Subject.
+	Issuer Condition `json:",omitempty"`
+
+	// Chain requests that the certificate chain sent by the server is
+	// complete, i.e. that the certificate can be verified from the
+	// intermediates sent by the server up to a trusted root.
+	Chain bool `json:",omitempty"`
+
+	// RootCAs are additional trusted root certificates in PEM format
+	// used when verifying the Chain. They may be read from a file
+	// with the @file: syntax, e.g. "@file:{{TEST_DIR}}/ca.pem".
+	RootCAs string `json:",omitempty"`
+
+	// MinVersion is the minimum negotiated protocol version, one of
+	// "TLS1.0", "TLS1.1", "TLS1.2" and "TLS1.3".
+	MinVersion string `json:",omitempty"`
+
+	// ForbiddenCiphers lists cipher suites which must not be negotiated.
+	// A cipher suite is forbidden if its name (e.g.
+	// "TLS_RSA_WITH_AES_128_CBC_SHA") contains one of the given strings,
+	// e.g. "_CBC_" or "RC4".
+	ForbiddenCiphers []string `json:",omitempty"`
+
+	roots *x509.CertPool
+}
+
+// Prepare implements Check's Prepare method.
+func (c *TLS) Prepare(t *Test) error {
+	if c.MinDaysValid < 0 {
+		return MalformedCheck{errors.New("negative MinDaysValid")}
+	}
+	if c.MinVersion != "" {
+		if _, ok := tlsVersions[c.MinVersion]; !ok {
+			return MalformedCheck{fmt.Errorf("unknown MinVersion %q", c.MinVersion)}
+		}
+	}
+	if err := c.Subject.Compile(); err != nil {
+		return err
+	}
+	if err := c.Issuer.Compile(); err != nil {
+		return err
+	}
+
+	c.roots = nil
+	if c.RootCAs != "" {
+		data, _, err := FileData(c.RootCAs, t.Variables)
+		if err != nil {
+			return err
+		}
+		c.roots, err = x509.SystemCertPool()
+		if err != nil {
+			c.roots = x509.NewCertPool()
+		}
+		if !c.roots.AppendCertsFromPEM([]byte(data)) {
+			return MalformedCheck{errors.New("no certificate found in RootCAs")}
+		}
+	}
+	return nil
+}
+
+var _ Preparable = &TLS{}
+
+// Execute implements Check's Execute method.
+func (c *TLS) Execute(t *Test) error {
+	state, err := c.connectionState(t)
+	if err != nil {
+		return err
+	}
+	if len(state.PeerCertificates) == 0 {
+		return errors.New("no server certificate")
+	}
+	cert := state.PeerCertificates[0]
+
+	errs := errorlist.List{}
+	if c.MinDaysValid > 0 {
+		left := cert.NotAfter.Sub(time.Now())
+		if days := int(left.Hours() / 24); days < c.MinDaysValid {
+			errs = append(errs, fmt.Errorf("certificate expires in %d days on %s",
+				days, cert.NotAfter.Format("2006-01-02")))
+		}
+	}
+	for _, name := range c.Names {
+		if err := cert.VerifyHostname(name); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := c.Subject.Fulfilled(cert.Subject.String()); err != nil {
+		errs = append(errs, fmt.Errorf("subject %s", err))
+	}
+	if err := c.Issuer.Fulfilled(cert.Issuer.String()); err != nil {
+		errs = append(errs, fmt.Errorf("issuer %s", err))
+	}
+	if c.Chain {
+		intermediates := x509.NewCertPool()
+		for _, ic := range state.PeerCertificates[1:] {
+			intermediates.AddCert(ic)
+		}
+		_, err := cert.Verify(x509.VerifyOptions{
+			Roots:         c.roots,
+			Intermediates: intermediates,
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("incomplete chain: %s", err))
+		}
+	}
+	if c.MinVersion != "" && state.Version < tlsVersions[c.MinVersion] {
+		errs = append(errs, fmt.Errorf("negotiated %s, want at least %s",
+			tlsVersionName(state.Version), c.MinVersion))
+	}
+	cipher := tls.CipherSuiteName(state.CipherSuite)
+	for _, forbidden := range c.ForbiddenCiphers {
+		if strings.Contains(cipher, forbidden) {
+			errs = append(errs, fmt.Errorf("forbidden cipher suite %s", cipher))
+			break
+		}
+	}
+
+	return errs.AsError()
+}
+
+// connectionState returns the TLS connection state of the response or
+// performs a handshake with the host of the request if unavailable.
+func (c *TLS) connectionState(t *Test) (*tls.ConnectionState, error) {
+	if t.Response.Response != nil && t.Response.Response.TLS != nil {
+		return t.Response.Response.TLS, nil
+	}
+
+	var u *url.URL
+	if t.Request.Request != nil {
+		u = t.Request.Request.URL
+	} else {
+		var err error
+		if u, err = url.Parse(t.Request.URL); err != nil {
+			return nil, err
+		}
+	}
+	if u == nil || u.Scheme != "https" {
+		return nil, errNoTLS
+	}
+	host := u.Host
+	if u.Port() == "" {
+		host = net.JoinHostPort(u.Hostname(), "443")
+	}
+
+	// Dial like the test's transport to honour Resolve and LocalAddr.
+	tr, err := t.Request.Client.transport(t.Variables)
+	if err != nil {
+		return nil, CantCheck{err}
+	}
+	if tr.Proxy != nil {
+		proxy, err := tr.Proxy(&http.Request{URL: u})
+		if err != nil {
+			return nil, CantCheck{err}
+		}
+		if proxy != nil {
+			return nil, CantCheck{fmt.Errorf("no TLS state and no handshake through proxy %s", proxy.Host)}
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultClientTimeout)
+	defer cancel()
+	raw, err := tr.DialContext(ctx, "tcp", host)
+	if err != nil {
+		return nil, err
+	}
+	conn := tls.Client(raw, &tls.Config{
+		ServerName:         u.Hostname(),
+		Certificates:       tr.TLSClientConfig.Certificates,
+		InsecureSkipVerify: true, // the certificate is checked by c
+	})
+	defer conn.Close()
+	if err := conn.HandshakeContext(ctx); err != nil {
+		return nil, err
+	}
+	state := conn.ConnectionState()
+	return &state, nil
+}
@@ -0,0 +1,106 @@
+// Copyright 2018 Volker Dobler.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ht
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestTLS(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("Hello"))
+	}))
+	defer ts.Close()
+	rootCA := string(pem.EncodeToMemory(&pem.Block{
+		Type: "CERTIFICATE", Bytes: ts.Certificate().Raw}))
+
+	// The certificate of httptest is valid for example.com and 127.0.0.1
+	// until 2084 and issued by itself.
+	for i, tc := range []struct {
+		check *TLS
+		want  string // "" for success, "prepare" for errors during Prepare
+	}{
+		{&TLS{}, ""},
+		{&TLS{MinDaysValid: 365}, ""},
+		{&TLS{MinDaysValid: 36500}, "certificate expires in "},
+		{&TLS{Names: []string{"example.com", "127.0.0.1"}}, ""},
+		{&TLS{Names: []string{"www.example.org"}}, "x509: certificate is valid for example.com"},
+		{&TLS{Subject: Condition{Contains: "O=Acme Co"}}, ""},
+		{&TLS{Issuer: Condition{Contains: "Acme Co"}}, ""},
+		{&TLS{Issuer: Condition{Contains: "Let's Encrypt"}}, "issuer Cannot find "},
+		{&TLS{Chain: true}, "incomplete chain: x509: certificate signed by unknown authority"},
+		{&TLS{Chain: true, RootCAs: rootCA}, ""},
+		{&TLS{MinVersion: "TLS1.2"}, ""},
+		{&TLS{ForbiddenCiphers: []string{"RC4", "_CBC_"}}, ""},
+		{&TLS{ForbiddenCiphers: []string{"TLS_"}}, "forbidden cipher suite TLS_"},
+		{&TLS{MinVersion: "TLS2.0"}, "prepare"},
+		{&TLS{MinDaysValid: -1}, "prepare"},
+		{&TLS{RootCAs: "no PEM here"}, "prepare"},
+	} {
+		test := &Test{Request: Request{URL: ts.URL}}
+		if err := tc.check.Prepare(test); err != nil {
+			if tc.want != "prepare" {
+				t.Errorf("%d. Unexpected error during Prepare: %s", i, err)
+			}
+			continue
+		}
+		err := tc.check.Execute(test)
+		switch {
+		case tc.want == "" && err != nil:
+			t.Errorf("%d. Unexpected error %s", i, err)
+		case tc.want != "" && err == nil:
+			t.Errorf("%d. Missing error, want %s", i, tc.want)
+		case tc.want != "" && !strings.HasPrefix(err.Error(), tc.want):
+			t.Errorf("%d. Got error %q, want %s", i, err, tc.want)
+		}
+	}
+
+	// The connection state of the response is used if available.
+	state := &tls.ConnectionState{
+		Version:          tls.VersionTLS11,
+		PeerCertificates: []*x509.Certificate{ts.Certificate()},
+	}
+	response := Response{Response: &http.Response{TLS: state}}
+	runTest(t, 0, TC{response, &TLS{MinVersion: "TLS1.2"},
+		errors.New("negotiated TLS1.1, want at least TLS1.2")})
+
+	// The own handshake honours the Client configuration of the test.
+	u, _ := url.Parse(ts.URL)
+	for i, tc := range []struct {
+		proxy string
+		want  string
+	}{
+		{"direct", ""},
+		{"http://127.0.0.1:9", "cannot do check: no TLS state and no handshake through proxy 127.0.0.1:9"},
+	} {
+		test := &Test{Request: Request{
+			URL: "https://www.example.org:" + u.Port() + "/",
+			Client: ClientConfig{
+				Resolve: map[string]string{"www.example.org": "127.0.0.1"},
+				Proxy:   tc.proxy,
+			},
+		}}
+		check := &TLS{Names: []string{"example.com"}}
+		if err := check.Prepare(test); err != nil {
+			t.Fatal(err)
+		}
+		err := check.Execute(test)
+		if got := fmt.Sprint(err); err == nil && tc.want != "" || err != nil && got != tc.want {
+			t.Errorf("%d. Got error %v, want %q", i, err, tc.want)
+		}
+	}
+
+	// Plain HTTP is not TLS.
+	runTest(t, 1, TC{Response{Response: &http.Response{}}, &TLS{}, errNoTLS})
+}
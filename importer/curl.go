@@ -0,0 +1,612 @@
+// Copyright 2018 Volker Dobler.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package importer
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/vdobler/ht/ht"
+)
+
+// curlIgnored are curl options without argument which do not influence
+// the request itself.
+var curlIgnored = map[string]bool{
+	"-s": true, "--silent": true, "-S": true, "--show-error": true,
+	"-v": true, "--verbose": true, "-i": true, "--include": true,
+	"-f": true, "--fail": true, "-g": true, "--globoff": true,
+	"--http1.1": true, "--http2": true, "--no-buffer": true, "-N": true,
+}
+
+// curlWithArg are the options taking an argument handled by CurlTest.
+var curlWithArg = map[string]string{
+	"-X": "-X", "--request": "-X",
+	"-H": "-H", "--header": "-H",
+	"-d": "-d", "--data": "-d", "--data-ascii": "-d",
+	"--data-binary": "--data-binary", "--data-raw": "--data-raw",
+	"--data-urlencode": "--data-urlencode", "--url": "--url",
+	"-F": "-F", "--form": "-F", "--form-string": "--form-string",
+	"-u": "-u", "--user": "-u",
+	"-b": "-b", "--cookie": "-b",
+	"-A": "-A", "--user-agent": "-A",
+	"-e": "-e", "--referer": "-e",
+	"-m": "-m", "--max-time": "-m",
+}
+
+// curlBoolean are the options without argument handled by CurlTest.
+var curlBoolean = map[string]string{
+	"-L": "-L", "--location": "-L",
+	"-k": "-k", "--insecure": "-k",
+	"--compressed": "--compressed", "-I": "-I", "--head": "-I",
+	"-G": "-G", "--get": "-G",
+}
+
+// Curl generates one test for each curl command in the shell script
+// fragment data, e.g. as produced by the "Copy as cURL" function of
+// browsers. Commands are separated by newlines, ';' or '&&'; quoting
+// and line continuations are handled like a POSIX shell would do.
+func Curl(data []byte) (*Collection, error) {
+	commands, err := splitShell(string(data))
+	if err != nil {
+		return nil, err
+	}
+	tests := []*Test{}
+	for _, args := range commands {
+		if len(args) == 0 || args[0] != "curl" {
+			continue
+		}
+		test, err := CurlTest(args[1:])
+		if err != nil {
+			return nil, err
+		}
+		tests = append(tests, test)
+	}
+	if len(tests) == 0 {
+		return nil, fmt.Errorf("curl: no curl command found")
+	}
+	return curlCollection(tests), nil
+}
+
+// CurlArgs generates a test from the arguments of a single curl command.
+// A leading "curl" in args is ignored.
+func CurlArgs(args []string) (*Collection, error) {
+	if len(args) > 0 && args[0] == "curl" {
+		args = args[1:]
+	}
+	test, err := CurlTest(args)
+	if err != nil {
+		return nil, err
+	}
+	return curlCollection([]*Test{test}), nil
+}
+
+func curlCollection(tests []*Test) *Collection {
+	c := &Collection{
+		Suite: Suite{
+			Name:        "curl",
+			Description: "Generated from curl command line",
+			Variables:   map[string]string{},
+		},
+	}
+	if u, err := url.Parse(tests[0].Request.URL); err == nil {
+		baseURL := u.Scheme + "://" + u.Host
+		c.Suite.Name = u.Host
+		c.Suite.Variables["BASE_URL"] = baseURL
+		for _, test := range tests {
+			test.Request.URL = relativeURL(test.Request.URL, baseURL)
+		}
+	}
+	for _, test := range tests {
+		c.Add(test)
+	}
+	return c
+}
+
+// CurlTest converts the arguments of a curl command (without the
+// leading "curl") to a test. The following options are understood:
+//
+//	-X, --request         method
+//	-H, --header          request header, "Cookie" headers become Cookies
+//	-d, --data, --data-ascii, --data-binary, --data-raw
+//	                      request body, a single "@file" is read by ht
+//	                      when executing the test, several data
+//	                      arguments are read and joined during import
+//	--data-urlencode      URL-encoded request body
+//	-F, --form, --form-string
+//	                      multipart parameters, "@file" is uploaded
+//	-u, --user            basic authentication
+//	-b, --cookie          cookies (cookie files are not supported)
+//	-A, --user-agent      User-Agent header
+//	-e, --referer         Referer header
+//	-m, --max-time        timeout in seconds
+//	-L, --location        follow redirects
+//	-G, --get             send data as query parameters
+//	-I, --head            HEAD request
+//	--compressed          request a gzip compressed response
+//	-k, --insecure        noted in the description as ht handles
+//	                      this globally with -skiptlsverify
+//
+// Options which do not influence the request like -s or -v are ignored,
+// all other options result in an error.
+func CurlTest(args []string) (*Test, error) {
+	args = append([]string(nil), args...) // expanding options modifies args
+	req := ht.Request{Header: http.Header{}}
+	var notes []string
+	var data []curlData
+	getData, multipart := false, false
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			if req.URL != "" {
+				return nil, fmt.Errorf("curl: multiple URLs %q and %q", req.URL, arg)
+			}
+			req.URL = arg
+			continue
+		}
+
+		opt, value := arg, ""
+		if canonical, ok := curlWithArg[arg]; ok {
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("curl: missing argument to %s", arg)
+			}
+			opt, value = canonical, args[i+1]
+			i++
+		} else if canonical, ok := curlBoolean[arg]; ok {
+			opt = canonical
+		} else if curlIgnored[arg] {
+			continue
+		} else if len(arg) > 2 && arg[1] != '-' && curlShortOptions(arg) {
+			// Combined short options like -sSL or attached values like -XPUT.
+			if _, ok := curlWithArg[arg[:2]]; ok {
+				args = append(args[:i+1], append([]string{arg[2:]}, args[i+1:]...)...)
+				args[i] = arg[:2]
+			} else {
+				expanded := []string{}
+				for j := 1; j < len(arg); j++ {
+					expanded = append(expanded, "-"+arg[j:j+1])
+				}
+				args = append(args[:i], append(expanded, args[i+1:]...)...)
+			}
+			i--
+			continue
+		} else {
+			return nil, fmt.Errorf("curl: unsupported option %s", arg)
+		}
+
+		switch opt {
+		case "-X":
+			req.Method = value
+		case "-H":
+			if err := curlHeader(&req, value); err != nil {
+				return nil, err
+			}
+		case "-A":
+			req.Header.Set("User-Agent", value)
+		case "-e":
+			req.Header.Set("Referer", value)
+		case "-d":
+			data = append(data, curlDataArg(value, true))
+		case "--data-binary":
+			data = append(data, curlDataArg(value, false))
+		case "--data-raw":
+			data = append(data, curlData{value: value})
+		case "--data-urlencode":
+			encoded, err := curlURLEncode(value)
+			if err != nil {
+				return nil, err
+			}
+			data = append(data, curlData{value: encoded})
+		case "-F", "--form-string":
+			if err := curlForm(&req, value, opt == "-F"); err != nil {
+				return nil, err
+			}
+			multipart = true
+		case "-u":
+			parts := strings.SplitN(value, ":", 2)
+			req.BasicAuthUser = parts[0]
+			if len(parts) == 2 {
+				req.BasicAuthPass = parts[1]
+			}
+		case "-b":
+			if !strings.Contains(value, "=") {
+				return nil, fmt.Errorf("curl: cookie file %q not supported", value)
+			}
+			req.Cookies = append(req.Cookies, curlCookies(value)...)
+		case "-m":
+			var seconds float64
+			if _, err := fmt.Sscanf(value, "%g", &seconds); err != nil {
+				return nil, fmt.Errorf("curl: bad max-time %q", value)
+			}
+			req.Timeout = time.Duration(seconds * float64(time.Second))
+		case "--url":
+			if req.URL != "" {
+				return nil, fmt.Errorf("curl: multiple URLs %q and %q", req.URL, value)
+			}
+			req.URL = value
+		case "-L":
+			req.FollowRedirects = true
+		case "-k":
+			notes = append(notes, "curl -k: run with -skiptlsverify")
+		case "--compressed":
+			if req.Header.Get("Accept-Encoding") == "" {
+				req.Header.Set("Accept-Encoding", "gzip")
+			}
+		case "-G":
+			getData = true
+		case "-I":
+			req.Method = http.MethodHead
+		}
+	}
+
+	if req.URL == "" {
+		return nil, fmt.Errorf("curl: no URL given")
+	}
+	if !strings.Contains(req.URL, "://") {
+		req.URL = "http://" + req.URL
+	}
+	if multipart && len(data) > 0 {
+		return nil, fmt.Errorf("curl: cannot combine -F with -d")
+	}
+
+	switch {
+	case multipart:
+		req.ParamsAs = "multipart"
+		if req.Method == "" {
+			req.Method = http.MethodPost
+		}
+	case getData && len(data) > 0:
+		query, err := curlJoinData(data, true)
+		if err != nil {
+			return nil, err
+		}
+		sep := "?"
+		if strings.Contains(req.URL, "?") {
+			sep = "&"
+		}
+		req.URL += sep + query
+	case len(data) > 0:
+		body, err := curlJoinData(data, false)
+		if err != nil {
+			return nil, err
+		}
+		req.Body = body
+		if req.Header.Get("Content-Type") == "" {
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		}
+		if req.Method == "" {
+			req.Method = http.MethodPost
+		}
+	}
+	if req.Method == "" {
+		req.Method = http.MethodGet
+	}
+	if len(req.Header) == 0 {
+		req.Header = nil
+	}
+
+	name := req.Method
+	if u, err := url.Parse(req.URL); err == nil {
+		path := u.EscapedPath()
+		if path == "" {
+			path = "/"
+		}
+		name += " " + path
+	}
+	test := &Test{
+		Name:        name,
+		Description: "Imported from curl command line",
+		Request:     req,
+	}
+	if len(notes) > 0 {
+		test.Description += "\n" + strings.Join(notes, "\n")
+	}
+	return test, nil
+}
+
+// curlHeader adds the -H argument h to req.
+func curlHeader(req *ht.Request, h string) error {
+	if strings.HasSuffix(h, ";") && !strings.Contains(h, ":") {
+		// "Name;" sends an empty header.
+		req.Header.Add(strings.TrimSuffix(h, ";"), "")
+		return nil
+	}
+	i := strings.Index(h, ":")
+	if i <= 0 {
+		return fmt.Errorf("curl: malformed header %q", h)
+	}
+	name, value := strings.TrimSpace(h[:i]), strings.TrimSpace(h[i+1:])
+	if value == "" {
+		req.Header.Del(name) // "Name:" removes a header curl would send.
+		return nil
+	}
+	if http.CanonicalHeaderKey(name) == "Cookie" {
+		req.Cookies = append(req.Cookies, curlCookies(value)...)
+		return nil
+	}
+	req.Header.Add(name, value)
+	return nil
+}
+
+// curlCookies parses a Cookie header value like "a=1; b=2".
+func curlCookies(s string) []ht.Cookie {
+	cookies := []ht.Cookie{}
+	for _, nv := range strings.Split(s, ";") {
+		nv = strings.TrimSpace(nv)
+		if nv == "" {
+			continue
+		}
+		parts := strings.SplitN(nv, "=", 2)
+		c := ht.Cookie{Name: parts[0]}
+		if len(parts) == 2 {
+			c.Value = parts[1]
+		}
+		cookies = append(cookies, c)
+	}
+	return cookies
+}
+
+// curlShortOptions reports whether arg is a combination of known short
+// options like -sSL or a short option with an attached value like -XPUT.
+func curlShortOptions(arg string) bool {
+	if arg[1] >= utf8.RuneSelf {
+		return false
+	}
+	if _, ok := curlWithArg[arg[:2]]; ok {
+		return true
+	}
+	for j := 1; j < len(arg); j++ {
+		if arg[j] >= utf8.RuneSelf {
+			return false
+		}
+		opt := "-" + arg[j:j+1]
+		_, withArg := curlWithArg[opt]
+		_, boolean := curlBoolean[opt]
+		if !withArg && !boolean && !curlIgnored[opt] {
+			return false
+		}
+	}
+	return true
+}
+
+// curlData is a data argument of -d or --data-binary. Data read from
+// a file (curl's "@filename" syntax) has a non-empty file.
+type curlData struct {
+	value string
+	file  string
+	strip bool // strip newlines from the file content like -d does
+}
+
+// curlDataArg parses the data argument s.
+func curlDataArg(s string, strip bool) curlData {
+	if strings.HasPrefix(s, "@") {
+		return curlData{file: s[1:], strip: strip}
+	}
+	if strip {
+		s = strings.Replace(s, "\n", "", -1)
+	}
+	return curlData{value: s}
+}
+
+// curlJoinData joins the data arguments with "&". A single file is
+// translated to ht's "@file:" syntax and read when executing the test,
+// several data arguments or files sent as query parameters (inline) are
+// read during import.
+func curlJoinData(data []curlData, inline bool) (string, error) {
+	if len(data) == 1 && data[0].file != "" && !inline {
+		return "@file:" + data[0].file, nil
+	}
+	parts := make([]string, len(data))
+	for i, d := range data {
+		if d.file == "" {
+			parts[i] = d.value
+			continue
+		}
+		content, err := ioutil.ReadFile(d.file)
+		if err != nil {
+			return "", fmt.Errorf("curl: %s", err)
+		}
+		parts[i] = string(content)
+		if d.strip {
+			parts[i] = strings.NewReplacer("\r", "", "\n", "").Replace(parts[i])
+		}
+	}
+	return strings.Join(parts, "&"), nil
+}
+
+// curlURLEncode handles the forms "content", "=content", "name=content",
+// "@filename" and "name@filename" of --data-urlencode.
+func curlURLEncode(s string) (string, error) {
+	name, content := "", s
+	if i := strings.IndexAny(s, "=@"); i != -1 {
+		name, content = s[:i], s[i+1:]
+		if s[i] == '@' {
+			data, err := ioutil.ReadFile(content)
+			if err != nil {
+				return "", fmt.Errorf("curl: %s", err)
+			}
+			content = string(data)
+		}
+	}
+	encoded := url.QueryEscape(content)
+	if name != "" {
+		encoded = name + "=" + encoded
+	}
+	return encoded, nil
+}
+
+// curlForm adds the -F argument s as a multipart parameter to req.
+// Files to upload ("@file") are translated to ht's "@file:" syntax,
+// file content as value ("<file") is read in.
+func curlForm(req *ht.Request, s string, special bool) error {
+	i := strings.Index(s, "=")
+	if i <= 0 {
+		return fmt.Errorf("curl: malformed form field %q", s)
+	}
+	name, value := s[:i], s[i+1:]
+	if special {
+		if j := strings.Index(value, ";type="); j != -1 {
+			value = value[:j]
+		}
+		switch {
+		case strings.HasPrefix(value, "@"):
+			value = "@file:" + value[1:]
+		case strings.HasPrefix(value, "<"):
+			data, err := ioutil.ReadFile(value[1:])
+			if err != nil {
+				return fmt.Errorf("curl: %s", err)
+			}
+			value = string(data)
+		}
+	}
+	if req.Params == nil {
+		req.Params = make(url.Values)
+	}
+	req.Params.Add(name, value)
+	return nil
+}
+
+// splitShell splits the shell script fragment s into commands and
+// these into words. Single, double and $'...' quotes, backslash escapes
+// and line continuations are handled; variables and other expansions
+// are not performed.
+func splitShell(s string) ([][]string, error) {
+	commands := [][]string{}
+	words := []string{}
+	word := &bytes.Buffer{}
+	inWord := false
+
+	endWord := func() {
+		if inWord {
+			words = append(words, word.String())
+			word.Reset()
+			inWord = false
+		}
+	}
+	endCommand := func() {
+		endWord()
+		if len(words) > 0 {
+			commands = append(commands, words)
+			words = []string{}
+		}
+	}
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '\\':
+			if i+1 < len(s) {
+				i++
+				if s[i] == '\r' && i+1 < len(s) && s[i+1] == '\n' {
+					i++
+				}
+				if s[i] != '\n' {
+					word.WriteByte(s[i])
+					inWord = true
+				}
+			}
+		case c == '\'':
+			end := strings.IndexByte(s[i+1:], '\'')
+			if end == -1 {
+				return nil, fmt.Errorf("curl: unterminated single quote")
+			}
+			word.WriteString(s[i+1 : i+1+end])
+			inWord = true
+			i += end + 1
+		case c == '$' && i+1 < len(s) && s[i+1] == '\'':
+			n, err := ansiCQuote(s[i+2:], word)
+			if err != nil {
+				return nil, err
+			}
+			inWord = true
+			i += n + 2
+		case c == '"':
+			i++
+			for ; i < len(s) && s[i] != '"'; i++ {
+				if s[i] == '\\' && i+1 < len(s) && strings.IndexByte("$`\"\\\n", s[i+1]) != -1 {
+					i++
+					if s[i] == '\n' {
+						continue
+					}
+				}
+				word.WriteByte(s[i])
+			}
+			if i >= len(s) {
+				return nil, fmt.Errorf("curl: unterminated double quote")
+			}
+			inWord = true
+		case c == ' ' || c == '\t' || c == '\r':
+			endWord()
+		case c == '\n' || c == ';':
+			endCommand()
+		case c == '&' && i+1 < len(s) && s[i+1] == '&':
+			endCommand()
+			i++
+		case c == '#' && !inWord:
+			for i < len(s) && s[i] != '\n' {
+				i++
+			}
+			endCommand()
+		default:
+			word.WriteByte(c)
+			inWord = true
+		}
+	}
+	endCommand()
+	return commands, nil
+}
+
+// ansiCQuote decodes the body of a $'...' string from s to buf and
+// returns the number of bytes consumed including the closing quote.
+func ansiCQuote(s string, buf *bytes.Buffer) (int, error) {
+	escapes := map[byte]byte{
+		'n': '\n', 't': '\t', 'r': '\r', '\\': '\\', '\'': '\'', '"': '"',
+		'a': '\a', 'b': '\b', 'e': 0x1b, 'E': 0x1b, 'f': '\f', 'v': '\v',
+		'?': '?',
+	}
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '\'':
+			return i + 1, nil
+		case c == '\\' && i+1 < len(s):
+			i++
+			if e, ok := escapes[s[i]]; ok {
+				buf.WriteByte(e)
+				continue
+			}
+			if s[i] == 'x' || s[i] == 'u' || s[i] == 'U' {
+				max := map[byte]int{'x': 2, 'u': 4, 'U': 8}[s[i]]
+				j := i + 1
+				for j < len(s) && j < i+1+max && isHex(s[j]) {
+					j++
+				}
+				var r rune
+				fmt.Sscanf(s[i+1:j], "%x", &r)
+				if s[i] == 'x' {
+					buf.WriteByte(byte(r))
+				} else {
+					buf.WriteRune(r)
+				}
+				i = j - 1
+				continue
+			}
+			buf.WriteByte('\\')
+			buf.WriteByte(s[i])
+		default:
+			buf.WriteByte(c)
+		}
+	}
+	return 0, fmt.Errorf("curl: unterminated $' quote")
+}
+
+func isHex(c byte) bool {
+	return ('0' <= c && c <= '9') || ('a' <= c && c <= 'f') || ('A' <= c && c <= 'F')
+}
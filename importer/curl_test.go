@@ -0,0 +1,173 @@
+// Copyright 2018 Volker Dobler.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package importer
+
+import (
+	"net/http"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/vdobler/ht/ht"
+)
+
+var curlTests = []struct {
+	args []string
+	want ht.Request
+}{
+	{
+		[]string{"http://example.org/"},
+		ht.Request{Method: "GET", URL: "http://example.org/"},
+	},
+	{
+		[]string{"-X", "PUT", "example.org/foo", "-H", "Accept: application/json",
+			"-H", "X-Empty;", "-H", "Cookie: a=1; b=2", "-b", "c=3"},
+		ht.Request{Method: "PUT", URL: "http://example.org/foo",
+			Header: http.Header{"Accept": {"application/json"}, "X-Empty": {""}},
+			Cookies: []ht.Cookie{{Name: "a", Value: "1"}, {Name: "b", Value: "2"},
+				{Name: "c", Value: "3"}}},
+	},
+	{
+		[]string{"-d", "a=1", "--data-urlencode", "q=x y&z", "--data-urlencode", "=ä",
+			"http://example.org/search"},
+		ht.Request{Method: "POST", URL: "http://example.org/search",
+			Body:   "a=1&q=x+y%26z&%C3%A4",
+			Header: http.Header{"Content-Type": {"application/x-www-form-urlencoded"}}},
+	},
+	{
+		[]string{"http://example.org/api", "-H", "content-type: application/json",
+			"--data-raw", `{"a":1}`},
+		ht.Request{Method: "POST", URL: "http://example.org/api", Body: `{"a":1}`,
+			Header: http.Header{"Content-Type": {"application/json"}}},
+	},
+	{
+		[]string{"--data-binary", "@body.bin", "http://example.org/upload"},
+		ht.Request{Method: "POST", URL: "http://example.org/upload", Body: "@file:body.bin",
+			Header: http.Header{"Content-Type": {"application/x-www-form-urlencoded"}}},
+	},
+	{
+		[]string{"-G", "-d", "q=go", "-d", "n=2", "http://example.org/s?x=1"},
+		ht.Request{Method: "GET", URL: "http://example.org/s?x=1&q=go&n=2"},
+	},
+	{
+		[]string{"-d", "a=1", "-d", "@testdata/query.txt", "http://example.org/s"},
+		ht.Request{Method: "POST", URL: "http://example.org/s", Body: "a=1&q=go&n=2",
+			Header: http.Header{"Content-Type": {"application/x-www-form-urlencoded"}}},
+	},
+	{
+		[]string{"-G", "-d", "@testdata/query.txt", "http://example.org/s"},
+		ht.Request{Method: "GET", URL: "http://example.org/s?q=go&n=2"},
+	},
+	{
+		[]string{"-F", "name=Joe", "-F", "pic=@me.png;type=image/png",
+			"--form-string", "raw=@literal", "http://example.org/profile"},
+		ht.Request{Method: "POST", URL: "http://example.org/profile",
+			ParamsAs: "multipart",
+			Params: url.Values{"name": {"Joe"}, "pic": {"@file:me.png"},
+				"raw": {"@literal"}}},
+	},
+	{
+		[]string{"-sSL", "-u", "joe:se:cret", "--compressed", "-XDELETE", "-m", "2.5",
+			"http://example.org/x"},
+		ht.Request{Method: "DELETE", URL: "http://example.org/x",
+			FollowRedirects: true, BasicAuthUser: "joe", BasicAuthPass: "se:cret",
+			Header:  http.Header{"Accept-Encoding": {"gzip"}},
+			Timeout: 2500 * time.Millisecond},
+	},
+	{
+		[]string{"-I", "--url", "https://example.org"},
+		ht.Request{Method: "HEAD", URL: "https://example.org"},
+	},
+}
+
+func TestCurlTest(t *testing.T) {
+	for i, tc := range curlTests {
+		test, err := CurlTest(tc.args)
+		if err != nil {
+			t.Errorf("%d. %q: unexpected error %s", i, tc.args, err)
+			continue
+		}
+		if !reflect.DeepEqual(test.Request, tc.want) {
+			t.Errorf("%d. %q:\ngot  %+v\nwant %+v", i, tc.args, test.Request, tc.want)
+		}
+	}
+}
+
+func TestCurlTestErrors(t *testing.T) {
+	for i, tc := range []struct {
+		args []string
+		err  string
+	}{
+		{[]string{"-X", "GET"}, "curl: no URL given"},
+		{[]string{"-H"}, "curl: missing argument to -H"},
+		{[]string{"http://a", "http://b"}, `curl: multiple URLs "http://a" and "http://b"`},
+		{[]string{"--proxy", "p:8080", "http://a"}, "curl: unsupported option --proxy"},
+		{[]string{"-sZ", "http://a"}, "curl: unsupported option -sZ"},
+		{[]string{"-é", "http://a"}, "curl: unsupported option -é"},
+		{[]string{"-sé", "http://a"}, "curl: unsupported option -sé"},
+		{[]string{"-b", "cookies.txt", "http://a"}, `curl: cookie file "cookies.txt" not supported`},
+		{[]string{"-F", "a=b", "-d", "c=d", "http://a"}, "curl: cannot combine -F with -d"},
+		{[]string{"-H", "NoColon", "http://a"}, `curl: malformed header "NoColon"`},
+		{[]string{"-G", "-d", "@testdata/missing.txt", "http://a"},
+			"curl: open testdata/missing.txt: no such file or directory"},
+	} {
+		_, err := CurlTest(tc.args)
+		if err == nil || err.Error() != tc.err {
+			t.Errorf("%d. %q: got error %v, want %s", i, tc.args, err, tc.err)
+		}
+	}
+}
+
+func TestCurlInsecure(t *testing.T) {
+	test, err := CurlTest([]string{"-k", "https://localhost:8443/"})
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	if test.Description != "Imported from curl command line\ncurl -k: run with -skiptlsverify" {
+		t.Errorf("Got description %q", test.Description)
+	}
+}
+
+func TestCurl(t *testing.T) {
+	script := `# Copied from the browser
+curl 'https://shop.example.org/cart?id=7' \
+  -H 'accept: text/html' \
+  -H $'x-msg: it\'s\x20ok\u00e4' \
+  --compressed ;
+curl "https://shop.example.org/cart" -H "X-Q: \"q\"" --data-raw $'{"n":1}\n' && curl https://cdn.example.org/a.js
+`
+	c, err := Curl([]byte(script))
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	if c.Suite.Name != "shop.example.org" ||
+		c.Suite.Variables["BASE_URL"] != "https://shop.example.org" {
+		t.Errorf("Bad suite %+v", c.Suite)
+	}
+	if len(c.Tests) != 3 {
+		t.Fatalf("Got %d tests, want 3", len(c.Tests))
+	}
+
+	first := c.Tests[0].Request
+	if first.URL != "{{BASE_URL}}/cart?id=7" || first.Header.Get("X-Msg") != "it's okä" ||
+		first.Header.Get("Accept-Encoding") != "gzip" {
+		t.Errorf("Bad first request %+v", first)
+	}
+	second := c.Tests[1].Request
+	if second.Method != "POST" || second.Body != "{\"n\":1}\n" ||
+		second.Header.Get("X-Q") != `"q"` {
+		t.Errorf("Bad second request %+v", second)
+	}
+	if third := c.Tests[2].Request; third.URL != "https://cdn.example.org/a.js" {
+		t.Errorf("Bad third request %+v", third)
+	}
+
+	for _, bad := range []string{"ls -l", "curl 'unterminated", "curl $'x"} {
+		if _, err := Curl([]byte(bad)); err == nil {
+			t.Errorf("Missing error for %q", bad)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+// Copyright 2018 Volker Dobler.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package importer
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/vdobler/ht/ht"
+	"github.com/vdobler/ht/internal/har"
+)
+
+// harDroppedHeaders are request headers recorded by browsers which
+// are set automatically by ht or net/http or which would be wrong if
+// replayed.
+var harDroppedHeaders = []string{
+	"Accept-Encoding", "Connection", "Content-Length", "Cookie", "Host",
+	"Keep-Alive", "Te", "Transfer-Encoding", "Upgrade",
+}
+
+// HAR generates one test for each entry in the HTTP Archive (HAR 1.2)
+// data har, e.g. as exported from the network panel of a browser.
+//
+// Entries to the origin of the first entry get URLs starting with
+// "{{BASE_URL}}" which becomes a suite variable. Redirections are
+// not followed as each hop is an entry of its own. Cookies set by a
+// response of an earlier entry are not sent explicitly but kept by
+// the suite's cookie jar. Each test checks the recorded status code
+// and the content type of the response.
+func HAR(data []byte) (*Collection, error) {
+	archive, err := har.Decode(data)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Collection{
+		Suite: Suite{
+			Name:        "HAR",
+			Description: "Generated from HTTP Archive",
+			KeepCookies: true,
+			Variables:   map[string]string{},
+		},
+	}
+	if creator := archive.Log.Creator.Name; creator != "" {
+		c.Suite.Description += " created by " + creator
+	}
+
+	baseURL := ""
+	jarCookies := map[string]bool{}
+	for i, entry := range archive.Log.Entries {
+		u, err := url.Parse(entry.Request.URL)
+		if err != nil {
+			return nil, fmt.Errorf("entry %d: %s", i, err)
+		}
+		if u.Scheme != "http" && u.Scheme != "https" {
+			continue // data:, blob:, chrome-extension: and the like
+		}
+		if entry.Response.Status == 0 {
+			continue // blocked or aborted
+		}
+		if baseURL == "" {
+			baseURL = u.Scheme + "://" + u.Host
+			c.Suite.Name = u.Host
+			c.Suite.Variables["BASE_URL"] = baseURL
+		}
+
+		c.Add(harTest(entry, u, baseURL, jarCookies))
+
+		for _, cookie := range entry.Response.Cookies {
+			jarCookies[cookie.Name] = true
+		}
+	}
+	if len(c.Tests) == 0 {
+		return nil, fmt.Errorf("har: no HTTP entries")
+	}
+	return c, nil
+}
+
+func harTest(entry har.Entry, u *url.URL, baseURL string, jarCookies map[string]bool) *Test {
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	test := &Test{
+		Name:        entry.Request.Method + " " + path,
+		Description: "Imported from HAR entry started " + entry.StartedDateTime,
+		Request: ht.Request{
+			Method: entry.Request.Method,
+			URL:    entry.Request.URL,
+			Header: har.Header(entry.Request.Headers),
+		},
+	}
+	test.Request.URL = relativeURL(test.Request.URL, baseURL)
+	for h := range test.Request.Header {
+		if strings.HasPrefix(h, ":") {
+			delete(test.Request.Header, h) // HTTP/2 pseudo headers
+		}
+	}
+	for _, h := range harDroppedHeaders {
+		test.Request.Header.Del(h)
+	}
+	for _, cookie := range entry.Request.Cookies {
+		if !jarCookies[cookie.Name] {
+			test.Request.Cookies = append(test.Request.Cookies,
+				ht.Cookie{Name: cookie.Name, Value: cookie.Value})
+		}
+	}
+
+	if pd := entry.Request.PostData; pd != nil {
+		harPostData(pd, test)
+	}
+	if len(test.Request.Header) == 0 {
+		test.Request.Header = nil
+	}
+
+	test.Checks = ht.CheckList{ht.StatusCode{Expect: entry.Response.Status}}
+	isRedirect := entry.Response.Status/100 == 3
+	mt := strings.TrimSpace(strings.Split(entry.Response.Content.MimeType, ";")[0])
+	if mt != "" && !isRedirect && entry.Response.Status != http.StatusNoContent {
+		test.Checks = append(test.Checks, ht.ContentType{Is: mt})
+	}
+	return test
+}
+
+// harPostData sets the body or the parameters of test from pd.
+func harPostData(pd *har.PostData, test *Test) {
+	mt := strings.TrimSpace(strings.Split(pd.MimeType, ";")[0])
+	switch {
+	case mt == "application/x-www-form-urlencoded" && len(pd.Params) > 0:
+		test.Request.ParamsAs = "body"
+	case mt == "multipart/form-data" && len(pd.Params) > 0:
+		test.Request.ParamsAs = "multipart"
+	default:
+		test.Request.Body = pd.Text
+		return
+	}
+
+	// The Content-Type is generated when sending the parameters.
+	test.Request.Header.Del("Content-Type")
+	test.Request.Params = make(url.Values)
+	for _, p := range pd.Params {
+		value := p.Value
+		if p.FileName != "" {
+			value = "@file:@" + p.FileName + ":" + p.Value
+		}
+		test.Request.Params.Add(p.Name, value)
+	}
+}
@@ -0,0 +1,78 @@
+// Copyright 2018 Volker Dobler.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package importer
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/vdobler/ht/ht"
+)
+
+func TestHAR(t *testing.T) {
+	data, err := ioutil.ReadFile("testdata/session.har")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := HAR(data)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if c.Suite.Name != "shop.example.org" || !c.Suite.KeepCookies ||
+		c.Suite.Variables["BASE_URL"] != "http://shop.example.org" {
+		t.Errorf("Bad suite %+v", c.Suite)
+	}
+	wantFiles := []string{"GET_.ht", "POST_cart.ht", "PUT_v1_cart.ht"}
+	if !reflect.DeepEqual(c.Files, wantFiles) {
+		t.Fatalf("Got files %v, want %v", c.Files, wantFiles)
+	}
+
+	home := c.Tests[0].Request
+	if home.URL != "{{BASE_URL}}/" ||
+		!reflect.DeepEqual(home.Header, http.Header{"Accept": {"text/html"}}) ||
+		!reflect.DeepEqual(home.Cookies, []ht.Cookie{{Name: "consent", Value: "yes"}}) {
+		t.Errorf("Bad request %+v", home)
+	}
+	if !reflect.DeepEqual(c.Tests[0].Checks, ht.CheckList{
+		ht.StatusCode{Expect: 200}, ht.ContentType{Is: "text/html"}}) {
+		t.Errorf("Bad checks %#v", c.Tests[0].Checks)
+	}
+
+	cart := c.Tests[1].Request
+	if cart.Method != "POST" || cart.URL != "{{BASE_URL}}/cart?lang=de" ||
+		cart.ParamsAs != "body" || cart.Body != "" || cart.Header != nil ||
+		!reflect.DeepEqual(cart.Params, url.Values{"item": {"42"}, "qty": {"2"}}) ||
+		!reflect.DeepEqual(cart.Cookies, []ht.Cookie{{Name: "consent", Value: "yes"}}) {
+		t.Errorf("Bad request %+v", cart)
+	}
+	if !reflect.DeepEqual(c.Tests[1].Checks, ht.CheckList{ht.StatusCode{Expect: 303}}) {
+		t.Errorf("Bad checks %#v", c.Tests[1].Checks)
+	}
+
+	api := c.Tests[2].Request
+	if api.URL != "https://api.example.org/v1/cart" || api.Body != `{"item":42}` ||
+		!reflect.DeepEqual(api.Header, http.Header{"Content-Type": {"application/json"}}) {
+		t.Errorf("Bad request %+v", api)
+	}
+}
+
+func TestHARErrors(t *testing.T) {
+	for i, tc := range []struct {
+		har, err string
+	}{
+		{`{"log": {}}`, "har: not a HAR file"},
+		{`not json`, "har: invalid character 'o' in literal null (expecting 'u')"},
+		{`{"log": {"version": "1.2", "entries": []}}`, "har: no HTTP entries"},
+	} {
+		_, err := HAR([]byte(tc.har))
+		if err == nil || err.Error() != tc.err {
+			t.Errorf("%d. got error %v, want %s", i, err, tc.err)
+		}
+	}
+}
@@ -0,0 +1,201 @@
+// Copyright 2018 Volker Dobler.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package importer generates tests and suites from descriptions of HTTP
+// requests in foreign formats like OpenAPI specifications.
+package importer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/vdobler/ht/ht"
+	"github.com/vdobler/ht/sanitize"
+)
+
+// Test is a reduced version of ht.Test suitable for serialization to JSON.
+type Test struct {
+	Name        string
+	Description string `json:",omitempty"`
+	Request     ht.Request
+	Checks      ht.CheckList      `json:",omitempty"`
+	Variables   map[string]string `json:",omitempty"`
+}
+
+// Suite is a reduced version of suite.RawSuite suitable for
+// serialization to JSON.
+type Suite struct {
+	Name        string
+	Description string `json:",omitempty"`
+	Main        []struct {
+		File string
+	}
+	KeepCookies bool              `json:",omitempty"`
+	Variables   map[string]string `json:",omitempty"`
+}
+
+// Collection is a set of generated tests and a suite executing them.
+type Collection struct {
+	Suite Suite
+	Tests []*Test
+
+	// Files contains the filename of each test in Tests.
+	Files []string
+}
+
+// Add appends test to c under a filename derived from its name.
+func (c *Collection) Add(test *Test) {
+	base := sanitize.Filename(test.Name)
+	if base == "" {
+		base = "test"
+	}
+	name := base + ".ht"
+	for n := 2; c.hasFile(name); n++ {
+		name = fmt.Sprintf("%s_%d.ht", base, n)
+	}
+	c.Tests = append(c.Tests, test)
+	c.Files = append(c.Files, name)
+	c.Suite.Main = append(c.Suite.Main, struct{ File string }{name})
+}
+
+func (c *Collection) hasFile(name string) bool {
+	for _, f := range c.Files {
+		if f == name {
+			return true
+		}
+	}
+	return false
+}
+
+// SuiteFilename is the filename of the suite in c.
+func (c *Collection) SuiteFilename() string {
+	name := sanitize.Filename(strings.ToLower(c.Suite.Name))
+	if name == "" {
+		name = "imported"
+	}
+	return name + ".suite"
+}
+
+// Write the tests and the suite in c to directory dir.
+func (c *Collection) Write(dir string) error {
+	err := os.MkdirAll(dir, 0777)
+	if err != nil {
+		return err
+	}
+	for i, test := range c.Tests {
+		err := writeJSON(test, filepath.Join(dir, c.Files[i]))
+		if err != nil {
+			return err
+		}
+	}
+	return writeJSON(c.Suite, filepath.Join(dir, c.SuiteFilename()))
+}
+
+// relativeURL makes u relative to the variable BASE_URL if u starts
+// with baseURL.
+func relativeURL(u, baseURL string) string {
+	if u == baseURL || strings.HasPrefix(u, baseURL+"/") ||
+		strings.HasPrefix(u, baseURL+"?") {
+		return "{{BASE_URL}}" + u[len(baseURL):]
+	}
+	return u
+}
+
+// writeJSON writes v as indented JSON to filename. Members which are null
+// or empty objects are dropped.
+func writeJSON(v interface{}, filename string) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	reduced, err := dropZero(dec)
+	if err != nil {
+		return err
+	}
+	data, err = json.MarshalIndent(reduced, "", "    ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filename, data, 0666)
+}
+
+// object is a JSON object which keeps the order of its members.
+type object []member
+
+type member struct {
+	name  string
+	value interface{}
+}
+
+// MarshalJSON implements json.Marshaler.
+func (o object) MarshalJSON() ([]byte, error) {
+	buf := &bytes.Buffer{}
+	buf.WriteByte('{')
+	for i, m := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		name, err := json.Marshal(m.name)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(m.value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// dropZero decodes the next JSON value from dec and drops all object
+// members which are null or an empty object.
+func dropZero(dec *json.Decoder) (interface{}, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch tok {
+	case json.Delim('{'):
+		obj := object{}
+		for dec.More() {
+			name, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := dropZero(dec)
+			if err != nil {
+				return nil, err
+			}
+			if o, ok := value.(object); value == nil || ok && len(o) == 0 {
+				continue
+			}
+			obj = append(obj, member{name.(string), value})
+		}
+		_, err := dec.Token() // the closing }
+		return obj, err
+	case json.Delim('['):
+		list := []interface{}{}
+		for dec.More() {
+			value, err := dropZero(dec)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, value)
+		}
+		_, err := dec.Token() // the closing ]
+		return list, err
+	}
+	return tok, nil
+}
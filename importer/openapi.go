@@ -0,0 +1,242 @@
+// Copyright 2018 Volker Dobler.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/vdobler/ht/ht"
+	"github.com/vdobler/ht/internal/openapi"
+)
+
+// OpenAPI generates one test for each operation described in the
+// OpenAPI 3 document spec which may be given in JSON or YAML.
+//
+// The URL of each test is "{{BASE_URL}}" followed by the path of the
+// operation. Path parameters become test variables which default to
+// the example value from the spec (or are empty if there is none).
+// Required query parameters and header parameters with examples are
+// sent, an example of the request body is used as body.
+// The checks are derived from the lowest documented 2xx response:
+// Its StatusCode, its ContentType and, for JSON content, a JSONSchema
+// check validating against the schema of the response.
+func OpenAPI(spec []byte) (*Collection, error) {
+	s, err := openapi.Parse(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Collection{
+		Suite: Suite{
+			Name: s.Title(),
+			Variables: map[string]string{
+				"BASE_URL": s.ServerURL(),
+			},
+		},
+	}
+	if c.Suite.Name == "" {
+		c.Suite.Name = "OpenAPI"
+	}
+	c.Suite.Description = fmt.Sprintf("Generated from OpenAPI spec of %s", c.Suite.Name)
+	if v := s.Version(); v != "" {
+		c.Suite.Description += " version " + v
+	}
+
+	for _, op := range s.Operations() {
+		test, err := openAPITest(s, op)
+		if err != nil {
+			return nil, fmt.Errorf("%s %s: %s", op.Method, op.Path, err)
+		}
+		c.Add(test)
+	}
+	return c, nil
+}
+
+func openAPITest(s *openapi.Spec, op *openapi.Operation) (*Test, error) {
+	name := op.ID()
+	if name == "" {
+		name = op.Method + " " + op.Path
+	}
+	test := &Test{
+		Name:        name,
+		Description: op.Summary(),
+		Request: ht.Request{
+			Method: op.Method,
+			URL:    "{{BASE_URL}}" + templatePath(op.Path),
+		},
+	}
+
+	for _, param := range op.Parameters {
+		pname, _ := param["name"].(string)
+		required, _ := param["required"].(bool)
+		ex, hasExample := s.Example(param)
+		value := ""
+		if hasExample {
+			value = exampleString(ex)
+		}
+		switch param["in"] {
+		case "path":
+			if test.Variables == nil {
+				test.Variables = make(map[string]string)
+			}
+			test.Variables[pname] = value
+		case "query":
+			if required || hasExample {
+				if test.Request.Params == nil {
+					test.Request.Params = make(url.Values)
+				}
+				test.Request.Params.Add(pname, value)
+			}
+		case "header":
+			if required || hasExample {
+				if test.Request.Header == nil {
+					test.Request.Header = make(http.Header)
+				}
+				test.Request.Header.Add(pname, value)
+			}
+		case "cookie":
+			if required || hasExample {
+				test.Request.Cookies = append(test.Request.Cookies,
+					ht.Cookie{Name: pname, Value: value})
+			}
+		}
+	}
+
+	if err := openAPIRequestBody(s, op, test); err != nil {
+		return nil, err
+	}
+
+	checks, err := openAPIChecks(s, op)
+	if err != nil {
+		return nil, err
+	}
+	test.Checks = checks
+
+	return test, nil
+}
+
+// templatePath turns the path template p like "/pets/{petId}" into
+// a path with variables like "/pets/{{petId}}".
+func templatePath(p string) string {
+	p = strings.Replace(p, "{", "{{", -1)
+	return strings.Replace(p, "}", "}}", -1)
+}
+
+// exampleString formats the example value ex for use as a parameter
+// value or as a request body.
+func exampleString(ex interface{}) string {
+	switch ex := ex.(type) {
+	case string:
+		return ex
+	case map[string]interface{}, []interface{}:
+		data, err := json.MarshalIndent(ex, "", "    ")
+		if err != nil {
+			return fmt.Sprint(ex)
+		}
+		return string(data)
+	case nil:
+		return ""
+	}
+	return fmt.Sprint(ex)
+}
+
+// openAPIRequestBody sets the body of test from the example of the
+// preferred media type of the request body of op.
+func openAPIRequestBody(s *openapi.Spec, op *openapi.Operation, test *Test) error {
+	body := op.RequestBody()
+	if body == nil {
+		return nil
+	}
+	content, _ := body["content"].(map[string]interface{})
+	mt := openapi.PreferredMediaType(content)
+	if mt == "" {
+		return nil
+	}
+	if test.Request.Header == nil {
+		test.Request.Header = make(http.Header)
+	}
+	if !strings.Contains(mt, "*") {
+		test.Request.Header.Set("Content-Type", mt)
+	}
+
+	ex, ok := s.Example(s.Resolve(content[mt]))
+	if !ok {
+		return nil
+	}
+	if mt == "application/x-www-form-urlencoded" {
+		if form, ok := ex.(map[string]interface{}); ok {
+			if test.Request.Params == nil {
+				test.Request.Params = make(url.Values)
+			}
+			for k, v := range form {
+				test.Request.Params.Add(k, exampleString(v))
+			}
+			test.Request.ParamsAs = "body"
+			test.Request.Header.Del("Content-Type")
+			return nil
+		}
+	}
+	test.Request.Body = exampleString(ex)
+	return nil
+}
+
+// expectedResponse selects the response code of op a plain request is
+// expected to produce: The lowest explicit 2xx code, then "2XX" and
+// finally "default".
+func expectedResponse(op *openapi.Operation) string {
+	codes := op.Responses()
+	for _, code := range codes {
+		if len(code) == 3 && code[0] == '2' && code != "2XX" {
+			return code
+		}
+	}
+	for _, code := range codes {
+		if strings.ToUpper(code) == "2XX" || code == "default" {
+			return code
+		}
+	}
+	return ""
+}
+
+// openAPIChecks produces the checks for the expected response of op.
+func openAPIChecks(s *openapi.Spec, op *openapi.Operation) (ht.CheckList, error) {
+	checks := ht.CheckList{}
+	code := expectedResponse(op)
+	switch {
+	case code == "":
+		return checks, nil
+	case code == "default" || strings.ToUpper(code) == "2XX":
+		checks = append(checks, ht.StatusCode{Expect: 2})
+	default:
+		var expect int
+		fmt.Sscanf(code, "%d", &expect)
+		checks = append(checks, ht.StatusCode{Expect: expect})
+	}
+
+	response := op.Response(code)
+	content, _ := response["content"].(map[string]interface{})
+	mt := openapi.PreferredMediaType(content)
+	if mt == "" || strings.Contains(mt, "*") {
+		return checks, nil
+	}
+	checks = append(checks, ht.ContentType{Is: mt})
+
+	media := s.Resolve(content[mt])
+	schema, ok := media["schema"]
+	if !ok || !openapi.IsJSON(mt) {
+		return checks, nil
+	}
+	doc, err := s.SchemaDocument(schema)
+	if err != nil {
+		return nil, err
+	}
+	checks = append(checks, &ht.JSONSchema{Schema: string(doc)})
+
+	return checks, nil
+}
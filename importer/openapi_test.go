@@ -0,0 +1,201 @@
+// Copyright 2018 Volker Dobler.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package importer
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/vdobler/ht/ht"
+	"github.com/vdobler/ht/suite"
+)
+
+func TestOpenAPI(t *testing.T) {
+	spec, err := ioutil.ReadFile("testdata/petstore.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := OpenAPI(spec)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if c.Suite.Name != "Petstore" ||
+		c.Suite.Variables["BASE_URL"] != "http://petstore.example.org/v1" {
+		t.Errorf("Bad suite %+v", c.Suite)
+	}
+	wantFiles := []string{"listPets.ht", "createPets.ht", "showPetById.ht"}
+	if !reflect.DeepEqual(c.Files, wantFiles) {
+		t.Fatalf("Got files %v, want %v", c.Files, wantFiles)
+	}
+	if got := c.SuiteFilename(); got != "petstore.suite" {
+		t.Errorf("Got suite filename %q", got)
+	}
+
+	list := c.Tests[0]
+	if list.Request.Method != "GET" || list.Request.URL != "{{BASE_URL}}/pets" ||
+		list.Request.Params.Get("limit") != "10" || len(list.Request.Params) != 1 {
+		t.Errorf("Bad request %+v", list.Request)
+	}
+	if len(list.Checks) != 3 {
+		t.Fatalf("Got %d checks for listPets, want 3", len(list.Checks))
+	}
+	if sc, ok := list.Checks[0].(ht.StatusCode); !ok || sc.Expect != 200 {
+		t.Errorf("Got %#v, want StatusCode 200", list.Checks[0])
+	}
+	if ct, ok := list.Checks[1].(ht.ContentType); !ok || ct.Is != "application/json" {
+		t.Errorf("Got %#v, want ContentType application/json", list.Checks[1])
+	}
+	schema, ok := list.Checks[2].(*ht.JSONSchema)
+	if !ok || !strings.Contains(schema.Schema, `"schemas":{"Pet":{`) {
+		t.Errorf("Got %#v, want JSONSchema with Pet component", list.Checks[2])
+	}
+
+	create := c.Tests[1]
+	if create.Request.Method != "POST" ||
+		create.Request.Header.Get("Content-Type") != "application/json" ||
+		!strings.Contains(create.Request.Body, `"name": "Tom"`) {
+		t.Errorf("Bad request %+v", create.Request)
+	}
+	if len(create.Checks) != 1 || create.Checks[0] != (ht.StatusCode{Expect: 201}) {
+		t.Errorf("Bad checks %#v", create.Checks)
+	}
+
+	show := c.Tests[2]
+	if show.Request.URL != "{{BASE_URL}}/pets/{{petId}}" ||
+		show.Variables["petId"] != "17" ||
+		len(show.Request.Header["X-Request-Id"]) != 1 {
+		t.Errorf("Bad test %+v", show)
+	}
+}
+
+func TestOpenAPIErrors(t *testing.T) {
+	for i, tc := range []struct {
+		spec, err string
+	}{
+		{`swagger: "2.0"`, `openapi: unsupported version "<nil>", need 3.x`},
+		{`openapi: 3.0.1`, `openapi: missing paths`},
+		{`[1, 2]`, `openapi: document is not an object`},
+		{`openapi: 3.0.1
+paths:
+  /foo:
+    get:
+      responses:
+        "200":
+          content:
+            application/json:
+              schema:
+                $ref: "#/components/schemas/Foo"`,
+			`GET /foo: openapi: cannot resolve $ref "#/components/schemas/Foo"`},
+	} {
+		_, err := OpenAPI([]byte(tc.spec))
+		if err == nil || err.Error() != tc.err {
+			t.Errorf("%d. got error %v, want %s", i, err, tc.err)
+		}
+	}
+}
+
+func petstoreHandler(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.Method == "GET" && r.URL.Path == "/v1/pets":
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintln(w, `[{"id": 1, "name": "Tom", "tag": null}, {"id": 2, "name": "Jerry"}]`)
+	case r.Method == "POST" && r.URL.Path == "/v1/pets":
+		w.WriteHeader(http.StatusCreated)
+	case r.Method == "GET" && r.URL.Path == "/v1/pets/17":
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintln(w, `{"id": 17, "name": "Garfield", "tag": "cat"}`)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestOpenAPIGeneratedSuite(t *testing.T) {
+	spec, err := ioutil.ReadFile("testdata/petstore.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := OpenAPI(spec)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	dir, err := ioutil.TempDir("", "openapi-import")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := c.Write(dir); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(petstoreHandler))
+	defer ts.Close()
+
+	raw, err := suite.LoadRawSuite(filepath.Join(dir, c.SuiteFilename()), nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	s := raw.Execute(map[string]string{"BASE_URL": ts.URL + "/v1"}, nil, nil)
+	if s.Status != ht.Pass {
+		for _, test := range s.Tests {
+			t.Errorf("%s: %s %v", test.Name, test.Result.Status, test.Result.Error)
+		}
+	}
+}
+
+var updateGolden = flag.Bool("update-golden", false, "update golden records")
+
+func TestOpenAPIGolden(t *testing.T) {
+	spec, err := ioutil.ReadFile("testdata/petstore.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := OpenAPI(spec)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	dir, err := ioutil.TempDir("", "openapi-golden")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := c.Write(dir); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	// The golden record contains all written files, each preceded by
+	// a line "# <filename>".
+	written := &bytes.Buffer{}
+	for _, name := range append([]string{c.SuiteFilename()}, c.Files...) {
+		data, err := ioutil.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		fmt.Fprintf(written, "# %s\n%s\n\n", name, data)
+	}
+
+	if *updateGolden {
+		t.Log("Updating golden record testdata/petstore.golden")
+		if err := ioutil.WriteFile("testdata/petstore.golden", written.Bytes(), 0640); err != nil {
+			t.Fatal(err)
+		}
+	}
+	golden, err := ioutil.ReadFile("testdata/petstore.golden")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(written.Bytes(), golden) {
+		t.Errorf("Generated suite differs from golden record:\n%s", written)
+	}
+}
@@ -0,0 +1,332 @@
+// Copyright 2018 Volker Dobler.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package expr implements the small boolean expression language used for
+// conditions in suites and for selecting tests by tags.
+//
+// An expression combines operands with the operators ! (not), && (and),
+// || (or), == (equal) and != (not equal); parentheses group. Operands are
+// quoted strings ("..." with Go escapes or '...' verbatim), bare words and
+// function calls like status("Login"). The meaning of bare words and
+// functions is provided by an Env. All values are strings; a value is
+// considered false if it is empty, "0" or "false" and true otherwise.
+//
+// Precedence from highest to lowest: !, == and !=, &&, ||.
+package expr
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Env provides the values of bare words and function calls.
+type Env interface {
+	// Word returns the value of the bare word w.
+	Word(w string) (string, error)
+
+	// Call returns the value of the function fn called with args.
+	Call(fn string, args []string) (string, error)
+}
+
+// Expr is a parsed expression.
+type Expr struct {
+	src  string
+	root node
+}
+
+// Parse the expression in s.
+func Parse(s string) (*Expr, error) {
+	tokens, err := lex(s)
+	if err != nil {
+		return nil, fmt.Errorf("expression %q: %s", s, err)
+	}
+	p := &parser{tokens: tokens}
+	root, err := p.or()
+	if err == nil && p.pos < len(p.tokens) {
+		err = fmt.Errorf("unexpected %s", p.tokens[p.pos])
+	}
+	if err != nil {
+		return nil, fmt.Errorf("expression %q: %s", s, err)
+	}
+	return &Expr{src: s, root: root}, nil
+}
+
+// String returns the source of e.
+func (e *Expr) String() string { return e.src }
+
+// Eval evaluates e in env.
+func (e *Expr) Eval(env Env) (bool, error) {
+	v, err := e.root.eval(env)
+	if err != nil {
+		return false, fmt.Errorf("expression %q: %s", e.src, err)
+	}
+	return Truthy(v), nil
+}
+
+// Truthy reports whether the value v is considered true.
+func Truthy(v string) bool {
+	return v != "" && v != "0" && v != "false"
+}
+
+func boolean(b bool) string {
+	if b {
+		return "true"
+	}
+	return "false"
+}
+
+// ----------------------------------------------------------------------------
+// Syntax tree
+
+type node interface {
+	eval(env Env) (string, error)
+}
+
+type literal string
+
+func (l literal) eval(env Env) (string, error) { return string(l), nil }
+
+type word string
+
+func (w word) eval(env Env) (string, error) { return env.Word(string(w)) }
+
+type call struct {
+	fn   string
+	args []node
+}
+
+func (c call) eval(env Env) (string, error) {
+	args := make([]string, len(c.args))
+	for i, arg := range c.args {
+		v, err := arg.eval(env)
+		if err != nil {
+			return "", err
+		}
+		args[i] = v
+	}
+	return env.Call(c.fn, args)
+}
+
+type not struct{ x node }
+
+func (n not) eval(env Env) (string, error) {
+	v, err := n.x.eval(env)
+	if err != nil {
+		return "", err
+	}
+	return boolean(!Truthy(v)), nil
+}
+
+type binary struct {
+	op   string
+	x, y node
+}
+
+func (b binary) eval(env Env) (string, error) {
+	x, err := b.x.eval(env)
+	if err != nil {
+		return "", err
+	}
+	// Short circuit evaluation of && and ||.
+	switch {
+	case b.op == "&&" && !Truthy(x):
+		return "false", nil
+	case b.op == "||" && Truthy(x):
+		return "true", nil
+	}
+	y, err := b.y.eval(env)
+	if err != nil {
+		return "", err
+	}
+	switch b.op {
+	case "==":
+		return boolean(x == y), nil
+	case "!=":
+		return boolean(x != y), nil
+	}
+	return boolean(Truthy(y)), nil
+}
+
+// ----------------------------------------------------------------------------
+// Parser
+
+type tokenKind int
+
+const (
+	tokOp tokenKind = iota
+	tokString
+	tokWord
+)
+
+type token struct {
+	kind tokenKind
+	text string
+}
+
+func (t token) String() string {
+	if t.kind == tokString {
+		return strconv.Quote(t.text)
+	}
+	return fmt.Sprintf("%q", t.text)
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+// accept consumes the next token if it is the operator op.
+func (p *parser) accept(op string) bool {
+	if p.pos < len(p.tokens) && p.tokens[p.pos].kind == tokOp &&
+		p.tokens[p.pos].text == op {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) or() (node, error) {
+	x, err := p.and()
+	for err == nil && p.accept("||") {
+		var y node
+		y, err = p.and()
+		x = binary{op: "||", x: x, y: y}
+	}
+	return x, err
+}
+
+func (p *parser) and() (node, error) {
+	x, err := p.comparison()
+	for err == nil && p.accept("&&") {
+		var y node
+		y, err = p.comparison()
+		x = binary{op: "&&", x: x, y: y}
+	}
+	return x, err
+}
+
+func (p *parser) comparison() (node, error) {
+	x, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for _, op := range []string{"==", "!="} {
+		if p.accept(op) {
+			y, err := p.unary()
+			return binary{op: op, x: x, y: y}, err
+		}
+	}
+	return x, nil
+}
+
+func (p *parser) unary() (node, error) {
+	if p.accept("!") {
+		x, err := p.unary()
+		return not{x}, err
+	}
+	if p.accept("(") {
+		x, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if !p.accept(")") {
+			return nil, fmt.Errorf("missing )")
+		}
+		return x, nil
+	}
+	if p.pos >= len(p.tokens) {
+		return nil, fmt.Errorf("unexpected end")
+	}
+
+	t := p.tokens[p.pos]
+	p.pos++
+	switch t.kind {
+	case tokString:
+		return literal(t.text), nil
+	case tokWord:
+		if !p.accept("(") {
+			return word(t.text), nil
+		}
+		c := call{fn: t.text}
+		for !p.accept(")") {
+			if len(c.args) > 0 && !p.accept(",") {
+				return nil, fmt.Errorf("missing , or ) in call of %s", t.text)
+			}
+			arg, err := p.or()
+			if err != nil {
+				return nil, err
+			}
+			c.args = append(c.args, arg)
+		}
+		return c, nil
+	}
+	return nil, fmt.Errorf("unexpected %s", t)
+}
+
+// ----------------------------------------------------------------------------
+// Lexer
+
+var operators = []string{"&&", "||", "==", "!=", "!", "(", ")", ","}
+
+func lex(s string) ([]token, error) {
+	tokens := []token{}
+	for s = strings.TrimLeftFunc(s, unicode.IsSpace); s != ""; s = strings.TrimLeftFunc(s, unicode.IsSpace) {
+		if op := operator(s); op != "" {
+			tokens = append(tokens, token{tokOp, op})
+			s = s[len(op):]
+			continue
+		}
+
+		switch s[0] {
+		case '"':
+			end := 1
+			for end < len(s) && s[end] != '"' {
+				if s[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(s) {
+				return nil, fmt.Errorf("unterminated string %s", s)
+			}
+			text, err := strconv.Unquote(s[:end+1])
+			if err != nil {
+				return nil, fmt.Errorf("bad string %s", s[:end+1])
+			}
+			tokens = append(tokens, token{tokString, text})
+			s = s[end+1:]
+		case '\'':
+			end := strings.IndexByte(s[1:], '\'')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated string %s", s)
+			}
+			tokens = append(tokens, token{tokString, s[1 : end+1]})
+			s = s[end+2:]
+		case '&', '|', '=':
+			return nil, fmt.Errorf("bad operator at %s", s)
+		default:
+			end := strings.IndexFunc(s, func(r rune) bool {
+				return unicode.IsSpace(r) || strings.ContainsRune("!&|=(),\"'", r)
+			})
+			if end < 0 {
+				end = len(s)
+			}
+			tokens = append(tokens, token{tokWord, s[:end]})
+			s = s[end:]
+		}
+	}
+	return tokens, nil
+}
+
+// operator returns the operator s starts with or "".
+func operator(s string) string {
+	for _, op := range operators {
+		if strings.HasPrefix(s, op) {
+			return op
+		}
+	}
+	return ""
+}
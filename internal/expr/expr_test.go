@@ -0,0 +1,107 @@
+// Copyright 2018 Volker Dobler.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package expr
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// testEnv treats words starting with a lowercase letter as tags which are
+// set if listed in the map; other words are literals.
+type testEnv map[string]bool
+
+func (e testEnv) Word(w string) (string, error) {
+	if w[0] >= 'a' && w[0] <= 'z' {
+		return boolean(e[w]), nil
+	}
+	return w, nil
+}
+
+func (e testEnv) Call(fn string, args []string) (string, error) {
+	if fn == "upper" && len(args) == 1 {
+		return strings.ToUpper(args[0]), nil
+	}
+	return "", fmt.Errorf("unknown function %s", fn)
+}
+
+var exprTests = []struct {
+	expr string
+	want bool
+}{
+	{`smoke`, true},
+	{`slow`, false},
+	{`!slow`, true},
+	{`!!smoke`, true},
+	{`smoke && !slow`, true},
+	{`smoke && slow`, false},
+	{`slow || smoke`, true},
+	{`slow || fast && smoke`, false},
+	{`(slow || smoke) && !fast`, true},
+	{`ON == "ON"`, true},
+	{`"off" != 'off'`, false},
+	{`off == "off"`, false}, // off is a tag
+	{`OFF`, true},
+	{`0`, false},
+	{`""`, false},
+	{`"false"`, false},
+	{`"a\"b" == 'a"b'`, true},
+	{`upper("ok") == OK && smoke`, true},
+	{`upper(X) != X`, false},
+	{`slow && unknown(1)`, false}, // short circuit
+	{`smoke || unknown(1)`, true}, // short circuit
+	{`Main-01 == "Main-01"`, true},
+}
+
+func TestEval(t *testing.T) {
+	env := testEnv{"smoke": true}
+	for i, tc := range exprTests {
+		e, err := Parse(tc.expr)
+		if err != nil {
+			t.Errorf("%d. %s: unexpected error %s", i, tc.expr, err)
+			continue
+		}
+		got, err := e.Eval(env)
+		if err != nil {
+			t.Errorf("%d. %s: unexpected error %s", i, tc.expr, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("%d. %s: got %t, want %t", i, tc.expr, got, tc.want)
+		}
+	}
+}
+
+func TestErrors(t *testing.T) {
+	for i, tc := range []struct {
+		expr, err string
+	}{
+		{``, `expression "": unexpected end`},
+		{`a &&`, `expression "a &&": unexpected end`},
+		{`(a || b`, `expression "(a || b": missing )`},
+		{`a b`, `expression "a b": unexpected "b"`},
+		{`a & b`, `expression "a & b": bad operator at & b`},
+		{`a = b`, `expression "a = b": bad operator at = b`},
+		{`"abc`, `expression "\"abc": unterminated string "abc`},
+		{`'abc`, `expression "'abc": unterminated string 'abc`},
+		{`f(a b)`, `expression "f(a b)": missing , or ) in call of f`},
+		{`)`, `expression ")": unexpected ")"`},
+	} {
+		_, err := Parse(tc.expr)
+		if err == nil || err.Error() != tc.err {
+			t.Errorf("%d. %s: got error %v, want %s", i, tc.expr, err, tc.err)
+		}
+	}
+
+	e, err := Parse(`unknown(1)`)
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	_, err = e.Eval(testEnv{})
+	if err == nil || err.Error() != `expression "unknown(1)": unknown function unknown` {
+		t.Errorf("Got error %v", err)
+	}
+}
@@ -0,0 +1,164 @@
+// Copyright 2018 Volker Dobler.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package har contains the data model of HTTP Archive (HAR) 1.2 files
+// as described in http://www.softwareishard.com/blog/har-12-spec/.
+//
+// Only the parts used by ht are modeled, custom fields and comments
+// are dropped while decoding.
+package har
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+)
+
+// HAR is the top level object of a HAR file.
+type HAR struct {
+	Log Log `json:"log"`
+}
+
+// Log is the root of the exported data.
+type Log struct {
+	Version string  `json:"version"`
+	Creator Creator `json:"creator"`
+	Entries []Entry `json:"entries"`
+}
+
+// Creator describes the application which created the log.
+type Creator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// Entry is one request/response pair.
+type Entry struct {
+	StartedDateTime string   `json:"startedDateTime"` // ISO 8601
+	Time            float64  `json:"time"`            // total time in ms
+	Request         Request  `json:"request"`
+	Response        Response `json:"response"`
+	Cache           struct{} `json:"cache"`
+	Timings         Timings  `json:"timings"`
+	ServerIPAddress string   `json:"serverIPAddress,omitempty"`
+	Comment         string   `json:"comment,omitempty"`
+}
+
+// Request is a performed request.
+type Request struct {
+	Method      string      `json:"method"`
+	URL         string      `json:"url"`
+	HTTPVersion string      `json:"httpVersion"`
+	Cookies     []Cookie    `json:"cookies"`
+	Headers     []NameValue `json:"headers"`
+	QueryString []NameValue `json:"queryString"`
+	PostData    *PostData   `json:"postData,omitempty"`
+	HeadersSize int         `json:"headersSize"`
+	BodySize    int         `json:"bodySize"`
+}
+
+// Response is a received response.
+type Response struct {
+	Status      int         `json:"status"`
+	StatusText  string      `json:"statusText"`
+	HTTPVersion string      `json:"httpVersion"`
+	Cookies     []Cookie    `json:"cookies"`
+	Headers     []NameValue `json:"headers"`
+	Content     Content     `json:"content"`
+	RedirectURL string      `json:"redirectURL"`
+	HeadersSize int         `json:"headersSize"`
+	BodySize    int         `json:"bodySize"`
+}
+
+// Cookie is a cookie sent or received.
+type Cookie struct {
+	Name     string `json:"name"`
+	Value    string `json:"value"`
+	Path     string `json:"path,omitempty"`
+	Domain   string `json:"domain,omitempty"`
+	Expires  string `json:"expires,omitempty"`
+	HTTPOnly bool   `json:"httpOnly,omitempty"`
+	Secure   bool   `json:"secure,omitempty"`
+}
+
+// NameValue is used for headers and query parameters.
+type NameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// PostData is the body of a request.
+type PostData struct {
+	MimeType string  `json:"mimeType"`
+	Params   []Param `json:"params,omitempty"`
+	Text     string  `json:"text"`
+}
+
+// Param is a posted parameter.
+type Param struct {
+	Name        string `json:"name"`
+	Value       string `json:"value,omitempty"`
+	FileName    string `json:"fileName,omitempty"`
+	ContentType string `json:"contentType,omitempty"`
+}
+
+// Content is the body of a response.
+type Content struct {
+	Size        int    `json:"size"`
+	Compression int    `json:"compression,omitempty"`
+	MimeType    string `json:"mimeType"`
+	Text        string `json:"text,omitempty"`
+	Encoding    string `json:"encoding,omitempty"`
+}
+
+// Timings of the phases of a request in ms. Values of -1 denote
+// phases which do not apply.
+type Timings struct {
+	Blocked float64 `json:"blocked"`
+	DNS     float64 `json:"dns"`
+	Connect float64 `json:"connect"`
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+	SSL     float64 `json:"ssl"`
+}
+
+// Decode parses the HAR file data.
+func Decode(data []byte) (*HAR, error) {
+	h := &HAR{}
+	if err := json.Unmarshal(data, h); err != nil {
+		return nil, fmt.Errorf("har: %s", err)
+	}
+	if h.Log.Version == "" && len(h.Log.Entries) == 0 {
+		return nil, fmt.Errorf("har: not a HAR file")
+	}
+	return h, nil
+}
+
+// Header converts the list of headers to a http.Header.
+func Header(list []NameValue) http.Header {
+	h := make(http.Header, len(list))
+	for _, nv := range list {
+		h.Add(nv.Name, nv.Value)
+	}
+	return h
+}
+
+// Pairs converts the headers or query parameters m to a list sorted
+// by name.
+func Pairs(m map[string][]string) []NameValue {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	list := []NameValue{}
+	for _, name := range names {
+		for _, v := range m[name] {
+			list = append(list, NameValue{Name: name, Value: v})
+		}
+	}
+	return list
+}
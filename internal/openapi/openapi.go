@@ -0,0 +1,514 @@
+// Copyright 2018 Volker Dobler.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package openapi provides the minimal access to OpenAPI 3 documents
+// needed to generate tests from a spec and to check responses against it.
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Methods lists the HTTP methods an OpenAPI path item may describe in
+// the order in which operations are reported.
+var Methods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+// Spec is a parsed OpenAPI 3 document.
+type Spec struct {
+	// Doc is the whole document as decoded from JSON.
+	Doc map[string]interface{}
+
+	templates []pathTemplate
+}
+
+// pathTemplate is a path of the spec compiled to a regular expression.
+type pathTemplate struct {
+	path   string
+	re     *regexp.Regexp
+	params int // number of template expressions in path
+}
+
+var templateExpr = regexp.MustCompile(`\{[^}/]*\}`)
+
+// Parse parses the OpenAPI 3 document data which may be JSON or YAML.
+func Parse(data []byte) (*Spec, error) {
+	var raw interface{}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	doc, ok := normalize(raw).(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("openapi: document is not an object")
+	}
+	version := fmt.Sprint(doc["openapi"])
+	if !strings.HasPrefix(version, "3.") {
+		return nil, fmt.Errorf("openapi: unsupported version %q, need 3.x", version)
+	}
+	paths, ok := doc["paths"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("openapi: missing paths")
+	}
+
+	spec := &Spec{Doc: doc}
+	for p := range paths {
+		literals := templateExpr.Split(p, -1)
+		for i, lit := range literals {
+			literals[i] = regexp.QuoteMeta(lit)
+		}
+		spec.templates = append(spec.templates, pathTemplate{
+			path:   p,
+			re:     regexp.MustCompile("^" + strings.Join(literals, "[^/]+") + "$"),
+			params: len(literals) - 1,
+		})
+	}
+	// Concrete paths must be matched before templated ones.
+	sort.Slice(spec.templates, func(i, j int) bool {
+		ti, tj := spec.templates[i], spec.templates[j]
+		if ti.params != tj.params {
+			return ti.params < tj.params
+		}
+		return ti.path < tj.path
+	})
+	return spec, nil
+}
+
+// normalize converts the map[interface{}]interface{} produced by the YAML
+// decoder to map[string]interface{} as produced by encoding/json.
+func normalize(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, val := range v {
+			m[fmt.Sprint(key)] = normalize(val)
+		}
+		return m
+	case map[string]interface{}:
+		for key, val := range v {
+			v[key] = normalize(val)
+		}
+		return v
+	case []interface{}:
+		for i, val := range v {
+			v[i] = normalize(val)
+		}
+		return v
+	}
+	return v
+}
+
+// Title returns the title of the API.
+func (s *Spec) Title() string {
+	info, _ := s.Doc["info"].(map[string]interface{})
+	title, _ := info["title"].(string)
+	return title
+}
+
+// Version returns the version of the API.
+func (s *Spec) Version() string {
+	info, _ := s.Doc["info"].(map[string]interface{})
+	if v, ok := info["version"]; ok {
+		return fmt.Sprint(v)
+	}
+	return ""
+}
+
+// ServerURL returns the URL of the first server with its variables
+// replaced by their default values.
+func (s *Spec) ServerURL() string {
+	servers, _ := s.Doc["servers"].([]interface{})
+	if len(servers) == 0 {
+		return ""
+	}
+	server, _ := servers[0].(map[string]interface{})
+	u, _ := server["url"].(string)
+	vars, _ := server["variables"].(map[string]interface{})
+	for name, v := range vars {
+		def, _ := v.(map[string]interface{})
+		if d, ok := def["default"]; ok {
+			u = strings.Replace(u, "{"+name+"}", fmt.Sprint(d), -1)
+		}
+	}
+	return strings.TrimSuffix(u, "/")
+}
+
+// Lookup returns the node the JSON pointer ref (e.g.
+// "#/components/schemas/Pet") points to.
+func (s *Spec) Lookup(ref string) (interface{}, error) {
+	if !strings.HasPrefix(ref, "#") {
+		return nil, fmt.Errorf("openapi: unsupported non-local $ref %q", ref)
+	}
+	var node interface{} = s.Doc
+	for _, tok := range splitPointer(ref[1:]) {
+		switch n := node.(type) {
+		case map[string]interface{}:
+			v, ok := n[tok]
+			if !ok {
+				return nil, fmt.Errorf("openapi: cannot resolve $ref %q", ref)
+			}
+			node = v
+		case []interface{}:
+			i, err := strconv.Atoi(tok)
+			if err != nil || i < 0 || i >= len(n) {
+				return nil, fmt.Errorf("openapi: cannot resolve $ref %q", ref)
+			}
+			node = n[i]
+		default:
+			return nil, fmt.Errorf("openapi: cannot resolve $ref %q", ref)
+		}
+	}
+	return node, nil
+}
+
+func splitPointer(ptr string) []string {
+	if ptr == "" || ptr == "/" {
+		return nil
+	}
+	toks := strings.Split(strings.TrimPrefix(ptr, "/"), "/")
+	for i, t := range toks {
+		t = strings.Replace(t, "~1", "/", -1)
+		toks[i] = strings.Replace(t, "~0", "~", -1)
+	}
+	return toks
+}
+
+// Resolve follows $ref in node (and the nodes referenced) and returns
+// the referenced object. Nil is returned if node is not an object or
+// cannot be resolved.
+func (s *Spec) Resolve(node interface{}) map[string]interface{} {
+	for depth := 0; depth < 32; depth++ {
+		obj, ok := node.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		ref, ok := obj["$ref"].(string)
+		if !ok {
+			return obj
+		}
+		target, err := s.Lookup(ref)
+		if err != nil {
+			return nil
+		}
+		node = target
+	}
+	return nil
+}
+
+// Operation is one operation of a path in the spec.
+type Operation struct {
+	Method string // upper case, e.g. "GET"
+	Path   string // the path template, e.g. "/pets/{petId}"
+
+	// Node is the operation object itself.
+	Node map[string]interface{}
+
+	// Parameters are the resolved parameters of the path item
+	// overwritten by the ones of the operation.
+	Parameters []map[string]interface{}
+
+	spec *Spec
+}
+
+// ID returns the operationId of op.
+func (op *Operation) ID() string {
+	id, _ := op.Node["operationId"].(string)
+	return id
+}
+
+// Summary returns the summary (or if empty the description) of op.
+func (op *Operation) Summary() string {
+	if s, _ := op.Node["summary"].(string); s != "" {
+		return s
+	}
+	s, _ := op.Node["description"].(string)
+	return s
+}
+
+// RequestBody returns the resolved request body object of op or nil.
+func (op *Operation) RequestBody() map[string]interface{} {
+	return op.spec.Resolve(op.Node["requestBody"])
+}
+
+// Responses returns the codes of all responses of op in sorted order.
+// Codes are e.g. "200", "4XX" or "default".
+func (op *Operation) Responses() []string {
+	responses, _ := op.Node["responses"].(map[string]interface{})
+	codes := make([]string, 0, len(responses))
+	for code := range responses {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+// Response returns the resolved response object for code which must be
+// one of the keys listed by Responses.
+func (op *Operation) Response(code string) map[string]interface{} {
+	responses, _ := op.Node["responses"].(map[string]interface{})
+	return op.spec.Resolve(responses[code])
+}
+
+// Operations returns all operations in s ordered by path and method.
+func (s *Spec) Operations() []*Operation {
+	paths, _ := s.Doc["paths"].(map[string]interface{})
+	keys := make([]string, 0, len(paths))
+	for p := range paths {
+		keys = append(keys, p)
+	}
+	sort.Strings(keys)
+
+	ops := []*Operation{}
+	for _, p := range keys {
+		item := s.Resolve(paths[p])
+		if item == nil {
+			continue
+		}
+		common := s.parameters(item["parameters"], nil)
+		for _, method := range Methods {
+			node, ok := item[method].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			ops = append(ops, &Operation{
+				Method:     strings.ToUpper(method),
+				Path:       p,
+				Node:       node,
+				Parameters: s.parameters(node["parameters"], common),
+				spec:       s,
+			})
+		}
+	}
+	return ops
+}
+
+// Find looks up the operation for a request with the given method and
+// path. The second return value reports whether the path matched any
+// path template, i.e. whether only the method is undocumented.
+func (s *Spec) Find(method, path string) (*Operation, bool) {
+	method = strings.ToUpper(method)
+	pathFound := false
+	for _, tmpl := range s.templates {
+		if !tmpl.re.MatchString(path) {
+			continue
+		}
+		pathFound = true
+		for _, op := range s.Operations() {
+			if op.Path == tmpl.path && op.Method == method {
+				return op, true
+			}
+		}
+	}
+	return nil, pathFound
+}
+
+// ResponseFor returns the response of op documented for the given
+// status code: An explicit code is preferred over a range like "2XX"
+// which is preferred over "default". The code under which the response
+// is documented is returned too; an empty code means no documented
+// response.
+func (op *Operation) ResponseFor(status int) (map[string]interface{}, string) {
+	responses, _ := op.Node["responses"].(map[string]interface{})
+	exact := strconv.Itoa(status)
+	class := exact[:1] + "XX"
+	for _, code := range []string{exact, class, strings.ToLower(class), "default"} {
+		if _, ok := responses[code]; ok {
+			return op.spec.Resolve(responses[code]), code
+		}
+	}
+	return nil, ""
+}
+
+// parameters resolves the parameter list params and merges it into
+// the list inherited: Parameters with the same name and location
+// replace the inherited ones.
+func (s *Spec) parameters(params interface{}, inherited []map[string]interface{}) []map[string]interface{} {
+	list, _ := params.([]interface{})
+	result := append([]map[string]interface{}(nil), inherited...)
+outer:
+	for _, p := range list {
+		param := s.Resolve(p)
+		if param == nil {
+			continue
+		}
+		for i, q := range result {
+			if q["name"] == param["name"] && q["in"] == param["in"] {
+				result[i] = param
+				continue outer
+			}
+		}
+		result = append(result, param)
+	}
+	return result
+}
+
+// PreferredMediaType selects a media type from the content map of a
+// request body or response: JSON is preferred, otherwise the
+// lexicographically first media type is returned.
+func PreferredMediaType(content map[string]interface{}) string {
+	types := make([]string, 0, len(content))
+	for mt := range content {
+		types = append(types, mt)
+	}
+	sort.Strings(types)
+	for _, mt := range types {
+		if IsJSON(mt) {
+			return mt
+		}
+	}
+	if len(types) > 0 {
+		return types[0]
+	}
+	return ""
+}
+
+// MatchMediaType finds the media type in content which matches the
+// Content-Type header value ct: An exact match is preferred over
+// "type/*" which is preferred over "*/*".
+func MatchMediaType(content map[string]interface{}, ct string) (string, bool) {
+	mt := strings.ToLower(strings.TrimSpace(strings.Split(ct, ";")[0]))
+	candidates := []string{mt}
+	if i := strings.Index(mt, "/"); i != -1 {
+		candidates = append(candidates, mt[:i]+"/*")
+	}
+	candidates = append(candidates, "*/*")
+	for _, c := range candidates {
+		for key := range content {
+			if strings.ToLower(strings.TrimSpace(strings.Split(key, ";")[0])) == c {
+				return key, true
+			}
+		}
+	}
+	return "", false
+}
+
+// IsJSON reports whether the media type mt denotes JSON content,
+// e.g. "application/json" or "application/problem+json".
+func IsJSON(mt string) bool {
+	mt = strings.ToLower(strings.TrimSpace(strings.Split(mt, ";")[0]))
+	return mt == "application/json" || strings.HasSuffix(mt, "+json")
+}
+
+// SchemaDocument produces a standalone JSON schema document from the
+// schema node. All local references reachable from schema are copied
+// into the document at their original location so that references
+// like "#/components/schemas/Pet" resolve inside the document.
+func (s *Spec) SchemaDocument(schema interface{}) ([]byte, error) {
+	refs := map[string]interface{}{}
+	if err := s.collectRefs(schema, refs); err != nil {
+		return nil, err
+	}
+
+	doc := map[string]interface{}{}
+	if obj, ok := schema.(map[string]interface{}); ok {
+		for k, v := range obj {
+			doc[k] = v
+		}
+	} else {
+		doc["allOf"] = []interface{}{schema}
+	}
+	for ref, target := range refs {
+		toks := splitPointer(ref[1:])
+		if len(toks) == 0 {
+			return nil, fmt.Errorf("openapi: cannot embed $ref %q", ref)
+		}
+		if _, clash := asObject(schema)[toks[0]]; clash {
+			return nil, fmt.Errorf("openapi: $ref %q clashes with schema keyword", ref)
+		}
+		node := doc
+		for _, tok := range toks[:len(toks)-1] {
+			next, ok := node[tok].(map[string]interface{})
+			if !ok {
+				next = map[string]interface{}{}
+				node[tok] = next
+			}
+			node = next
+		}
+		node[toks[len(toks)-1]] = target
+	}
+	return json.Marshal(doc)
+}
+
+func asObject(v interface{}) map[string]interface{} {
+	m, _ := v.(map[string]interface{})
+	return m
+}
+
+// collectRefs records in refs all local references reachable from node.
+func (s *Spec) collectRefs(node interface{}, refs map[string]interface{}) error {
+	switch n := node.(type) {
+	case map[string]interface{}:
+		if ref, ok := n["$ref"].(string); ok {
+			if _, seen := refs[ref]; !seen {
+				target, err := s.Lookup(ref)
+				if err != nil {
+					return err
+				}
+				refs[ref] = target
+				if err := s.collectRefs(target, refs); err != nil {
+					return err
+				}
+			}
+		}
+		for k, v := range n {
+			if k == "$ref" {
+				continue
+			}
+			if err := s.collectRefs(v, refs); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for _, v := range n {
+			if err := s.collectRefs(v, refs); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Example returns an example value for a parameter, media type or
+// schema object: The first of example, examples, schema.example,
+// default and enum found is used. The second return value reports
+// whether an example was found.
+func (s *Spec) Example(node map[string]interface{}) (interface{}, bool) {
+	if node == nil {
+		return nil, false
+	}
+	if ex, ok := node["example"]; ok {
+		return ex, true
+	}
+	if examples, ok := node["examples"].(map[string]interface{}); ok {
+		names := make([]string, 0, len(examples))
+		for name := range examples {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if ex := s.Resolve(examples[name]); ex != nil {
+				if v, ok := ex["value"]; ok {
+					return v, true
+				}
+			}
+		}
+	}
+	if schema := s.Resolve(node["schema"]); schema != nil {
+		if ex, ok := s.Example(schema); ok {
+			return ex, true
+		}
+	}
+	if def, ok := node["default"]; ok {
+		return def, true
+	}
+	if enum, ok := node["enum"].([]interface{}); ok && len(enum) > 0 {
+		return enum[0], true
+	}
+	return nil, false
+}
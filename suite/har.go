@@ -0,0 +1,161 @@
+// Copyright 2018 Volker Dobler.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package suite
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"time"
+	"unicode/utf8"
+
+	"github.com/vdobler/ht/ht"
+	"github.com/vdobler/ht/internal/har"
+)
+
+// HAR produces a HTTP Archive (HAR 1.2) of the executed tests of s.
+// Tests which did not make a HTTP request (e.g. tests which where
+// not run or bash or SQL pseudo requests) are omitted. Tests which
+// followed redirects contribute one entry per redirect response.
+func (s *Suite) HAR() ([]byte, error) {
+	archive := har.HAR{
+		Log: har.Log{
+			Version: "1.2",
+			Creator: har.Creator{Name: "ht"},
+			Entries: []har.Entry{},
+		},
+	}
+	for _, test := range s.Tests {
+		archive.Log.Entries = append(archive.Log.Entries, harEntries(test)...)
+	}
+	return json.MarshalIndent(archive, "", "  ")
+}
+
+// harEntries returns the entries of test: One for each redirect response
+// in test.Response.Hops followed by one for the final response.
+func harEntries(test *ht.Test) []har.Entry {
+	req, resp := test.Request.Request, test.Response.Response
+	if req == nil || resp == nil || req.URL == nil ||
+		(req.URL.Scheme != "http" && req.URL.Scheme != "https") {
+		return nil
+	}
+	started := test.Result.Started.Format(time.RFC3339Nano)
+
+	// The final response belongs to resp.Request which differs from req
+	// after followed redirects.
+	final := req
+	if resp.Request != nil && resp.Request.URL != nil {
+		final = resp.Request
+	}
+
+	// Only the first request carries the body: The http.Client follows
+	// only redirects which drop it.
+	entries := []har.Entry{}
+	header, body := req.Header, test.Request.SentBody
+	for _, hop := range test.Response.Hops {
+		u, err := url.Parse(hop.URL)
+		if err != nil {
+			continue
+		}
+		entries = append(entries, har.Entry{
+			StartedDateTime: started,
+			Request:         harRequest(hop.Method, u, req.Proto, header, body),
+			Response: harResponse(hop.StatusCode, req.Proto, hop.Header,
+				hop.BodyStr, len(hop.BodyStr)),
+			Timings: har.Timings{Blocked: -1, DNS: -1, Connect: -1, SSL: -1},
+			Comment: test.Name,
+		})
+		header, body = final.Header, ""
+	}
+
+	ms := float64(test.Response.Duration) / float64(time.Millisecond)
+	bodySize := int(test.Response.WireSize) // size before content decoding
+	if bodySize == 0 {
+		bodySize = len(test.Response.BodyStr)
+	}
+	entry := har.Entry{
+		StartedDateTime: started,
+		Time:            ms,
+		Request:         harRequest(final.Method, final.URL, final.Proto, final.Header, body),
+		Response: harResponse(resp.StatusCode, resp.Proto, resp.Header,
+			test.Response.BodyStr, bodySize),
+		Timings: har.Timings{
+			Blocked: -1, DNS: -1, Connect: -1, SSL: -1,
+			Send: 0, Wait: ms, Receive: 0,
+		},
+		Comment: test.Name,
+	}
+	return append(entries, entry)
+}
+
+func harRequest(method string, u *url.URL, proto string, header http.Header, body string) har.Request {
+	if proto == "" {
+		proto = "HTTP/1.1"
+	}
+	hr := har.Request{
+		Method:      method,
+		URL:         u.String(),
+		HTTPVersion: proto,
+		Cookies:     harCookies((&http.Request{Header: header}).Cookies()),
+		Headers:     har.Pairs(header),
+		QueryString: har.Pairs(u.Query()),
+		HeadersSize: -1,
+		BodySize:    len(body),
+	}
+	if body != "" {
+		hr.PostData = &har.PostData{
+			MimeType: header.Get("Content-Type"),
+			Text:     body,
+		}
+	}
+	return hr
+}
+
+func harResponse(status int, proto string, header http.Header, body string, bodySize int) har.Response {
+	if proto == "" {
+		proto = "HTTP/1.1"
+	}
+	hr := har.Response{
+		Status:      status,
+		StatusText:  http.StatusText(status),
+		HTTPVersion: proto,
+		Cookies:     harCookies((&http.Response{Header: header}).Cookies()),
+		Headers:     har.Pairs(header),
+		Content: har.Content{
+			Size:     len(body),
+			MimeType: header.Get("Content-Type"),
+		},
+		RedirectURL: header.Get("Location"),
+		HeadersSize: -1,
+		BodySize:    bodySize,
+	}
+	if utf8.ValidString(body) {
+		hr.Content.Text = body
+	} else {
+		hr.Content.Text = base64.StdEncoding.EncodeToString([]byte(body))
+		hr.Content.Encoding = "base64"
+	}
+	return hr
+}
+
+func harCookies(cookies []*http.Cookie) []har.Cookie {
+	list := []har.Cookie{}
+	for _, c := range cookies {
+		hc := har.Cookie{
+			Name:     c.Name,
+			Value:    c.Value,
+			Path:     c.Path,
+			Domain:   c.Domain,
+			HTTPOnly: c.HttpOnly,
+			Secure:   c.Secure,
+		}
+		if !c.Expires.IsZero() {
+			hc.Expires = c.Expires.Format(time.RFC3339)
+		}
+		list = append(list, hc)
+	}
+	return list
+}
@@ -0,0 +1,114 @@
+// Copyright 2018 Volker Dobler.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package suite
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/vdobler/ht/ht"
+	"github.com/vdobler/ht/internal/har"
+)
+
+func TestHAR(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/old" {
+			http.Redirect(w, r, "/logo.png?size=3", http.StatusMovedPermanently)
+			return
+		}
+		if r.Method == "POST" {
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "abc"})
+			w.WriteHeader(http.StatusCreated)
+			return
+		}
+		w.Header().Set("Content-Type", "image/png")
+		fmt.Fprint(w, "\x89PNG\r\n")
+	}))
+	defer ts.Close()
+
+	s := &Suite{
+		Tests: []*ht.Test{
+			{
+				Name: "Login",
+				Request: ht.Request{
+					Method: "POST",
+					URL:    ts.URL + "/login",
+					Body:   `{"user": "joe"}`,
+					Header: http.Header{"Content-Type": {"application/json"}},
+				},
+			},
+			{
+				Name:    "Logo",
+				Request: ht.Request{URL: ts.URL + "/logo.png?size=2"},
+			},
+			{
+				Name: "Moved",
+				Request: ht.Request{URL: ts.URL + "/old", FollowRedirects: true,
+					Header: http.Header{"Accept": {"image/*"}}},
+			},
+			{
+				Name:    "Bash",
+				Request: ht.Request{URL: "bash://localhost/tmp"},
+			},
+		},
+	}
+	for _, test := range s.Tests[:3] {
+		test.Run()
+		if test.Result.Status != ht.Pass {
+			t.Fatalf("Test %s: %s %v", test.Name, test.Result.Status, test.Result.Error)
+		}
+	}
+
+	data, err := s.HAR()
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	archive, err := har.Decode(data)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if archive.Log.Version != "1.2" || len(archive.Log.Entries) != 4 {
+		t.Fatalf("Got version %q and %d entries", archive.Log.Version,
+			len(archive.Log.Entries))
+	}
+
+	login := archive.Log.Entries[0]
+	if login.Request.Method != "POST" || login.Request.PostData == nil ||
+		login.Request.PostData.Text != `{"user": "joe"}` ||
+		login.Request.PostData.MimeType != "application/json" {
+		t.Errorf("Bad request %+v", login.Request)
+	}
+	if login.Response.Status != 201 || len(login.Response.Cookies) != 1 ||
+		login.Response.Cookies[0].Value != "abc" {
+		t.Errorf("Bad response %+v", login.Response)
+	}
+
+	logo := archive.Log.Entries[1]
+	if len(logo.Request.QueryString) != 1 || logo.Request.QueryString[0].Value != "2" {
+		t.Errorf("Bad query string %+v", logo.Request.QueryString)
+	}
+	if c := logo.Response.Content; c.Encoding != "base64" || c.Text != "iVBORw0K" ||
+		c.MimeType != "image/png" || c.Size != 6 {
+		t.Errorf("Bad content %+v", c)
+	}
+
+	moved, final := archive.Log.Entries[2], archive.Log.Entries[3]
+	if moved.Request.URL != ts.URL+"/old" || moved.Response.Status != 301 ||
+		moved.Response.RedirectURL != "/logo.png?size=3" {
+		t.Errorf("Bad redirect entry %+v", moved)
+	}
+	if final.Request.URL != ts.URL+"/logo.png?size=3" ||
+		final.Request.QueryString[0].Value != "3" || final.Response.Status != 200 {
+		t.Errorf("Bad final entry %+v", final)
+	}
+	for _, e := range []har.Entry{moved, final} {
+		if e.Request.Method != "GET" || len(e.Request.Headers) == 0 ||
+			e.Comment != "Moved" {
+			t.Errorf("Bad request %+v", e.Request)
+		}
+	}
+}
@@ -5,16 +5,19 @@
 package suite
 
 import (
+	"encoding/csv"
 	"fmt"
 	"io/ioutil"
 	"log"
 	"path"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/vdobler/ht/cookiejar"
 	"github.com/vdobler/ht/errorlist"
 	"github.com/vdobler/ht/ht"
+	"github.com/vdobler/ht/internal/expr"
 	"github.com/vdobler/ht/internal/hjson"
 	"github.com/vdobler/ht/mock"
 	"github.com/vdobler/ht/populate"
@@ -151,9 +154,12 @@ type RawTest struct {
 	*File
 	Mixins      []*Mixin          // Mixins of this test.
 	Variables   map[string]string // Variables are the defaults of the variables.
+	Tags        []string          // Tags of the test and its suite element.
 	contextVars map[string]string
 	mocks       []*RawMock
 	disabled    bool
+	condIf      string // If condition of the suite element
+	condUnless  string // Unless condition of the suite element
 }
 
 func (rt *RawTest) String() string {
@@ -169,6 +175,16 @@ func (rt *RawTest) Enable() { rt.disabled = false }
 // IsEnabled reports if rt is enabled.
 func (rt *RawTest) IsEnabled() bool { return !rt.disabled }
 
+// HasTag reports whether rt is tagged with tag.
+func (rt *RawTest) HasTag(tag string) bool {
+	for _, t := range rt.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
 // LoadRawTest reads filename and produces a new RawTest.
 func LoadRawTest(filename string, fs FileSystem) (*RawTest, error) {
 	raw, err := fs.Load(filename)
@@ -176,10 +192,11 @@ func LoadRawTest(filename string, fs FileSystem) (*RawTest, error) {
 		return nil, err
 	}
 
-	// Unmarshal to find the Mixins and Variables
+	// Unmarshal to find the Mixins, Variables and Tags
 	x := &struct {
 		Mixin     []string
 		Variables map[string]string
+		Tags      []string
 	}{}
 	err = raw.decodeLaxTo(x)
 	if err != nil {
@@ -197,6 +214,7 @@ func LoadRawTest(filename string, fs FileSystem) (*RawTest, error) {
 		File:      raw,
 		Mixins:    mixins,
 		Variables: x.Variables,
+		Tags:      x.Tags,
 	}, nil
 }
 
@@ -214,6 +232,47 @@ func loadMixins(mixs []string, dir string, fs FileSystem) ([]*Mixin, error) {
 	return mixins, nil
 }
 
+// extracts returns the names of the variables extracted by rt or its mixins.
+func (rt *RawTest) extracts() []string {
+	names := []string{}
+	files := []*File{rt.File}
+	for _, mixin := range rt.Mixins {
+		files = append(files, mixin.File)
+	}
+	for _, file := range files {
+		x := &struct {
+			DataExtraction map[string]interface{}
+		}{}
+		if err := file.decodeLaxTo(x); err != nil {
+			continue // reported once rt is converted to a test
+		}
+		for name := range x.DataExtraction {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// uses reports whether rt, its mixins or the call variables of rt
+// reference one of the given variables.
+func (rt *RawTest) uses(variables map[string]bool) bool {
+	texts := []string{rt.File.Data}
+	for _, mixin := range rt.Mixins {
+		texts = append(texts, mixin.File.Data)
+	}
+	for _, value := range rt.contextVars {
+		texts = append(texts, value)
+	}
+	for name := range variables {
+		for _, text := range texts {
+			if strings.Contains(text, "{{"+name+"}}") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // ToTest produces a ht.Test from a raw test rt.
 func (rt *RawTest) ToTest(variables scope.Variables) (*ht.Test, error) {
 	bogus := &ht.Test{Result: ht.Result{Status: ht.Bogus}}
@@ -283,6 +342,7 @@ func (rt *RawTest) toTest(variables map[string]string) (*ht.Test, error) {
 	}
 
 	delete(m, "Mixin")
+	delete(m, "Tags")
 	// delete(m, "Variables")
 	test := &ht.Test{}
 
@@ -302,10 +362,27 @@ func (rt *RawTest) toTest(variables map[string]string) (*ht.Test, error) {
 //   RawSuite
 
 // RawElement represents one test in a RawSuite.
+//
+// If Data names a CSV or JSON file the test is called once for each row
+// of this table with the columns as additional call Variables, see
+// LoadData for the format.
+//
+// The test is skipped if the If condition is false or the Unless condition
+// is true. Conditions are expressions like
+//     "{{FEATURE_X}}" == "off" || status("Login") != "Pass"
+// which are evaluated after variable substitution when the test is due.
+// The function status returns the status of an earlier test of the suite
+// given by name or sequence number (e.g. "Setup-02").
+//
+// Tags are added to the tags of the test.
 type RawElement struct {
 	File      string
 	Variables map[string]string
 	Mocks     []string
+	Data      string
+	If        string
+	Unless    string
+	Tags      []string
 
 	Test map[string]interface{}
 }
@@ -317,6 +394,7 @@ type RawSuite struct {
 	Setup, Main, Teardown []RawElement
 	KeepCookies           bool
 	OmitChecks            bool
+	Parallel              bool
 	Variables             map[string]string
 	Verbosity             int
 
@@ -328,6 +406,16 @@ func (rs *RawSuite) RawTests() []*RawTest {
 	return rs.tests
 }
 
+// HasMocks reports whether a test of rs uses mocks.
+func (rs *RawSuite) HasMocks() bool {
+	for _, rt := range rs.tests {
+		if len(rt.mocks) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
 // AddRawTests adds ts to the tests in rs.
 func (rs *RawSuite) AddRawTests(ts ...*RawTest) {
 	rs.tests = append(rs.tests, ts...)
@@ -356,6 +444,15 @@ func LoadRawSuite(filename string, fs FileSystem) (*RawSuite, error) {
 	}
 	rs.File = raw // re-set as decodeStritTo clears rs
 	dir := rs.File.Dirname()
+	for _, elems := range []struct {
+		elems *[]RawElement
+		which string
+	}{{&rs.Setup, "Setup"}, {&rs.Main, "Main"}, {&rs.Teardown, "Teardown"}} {
+		*elems.elems, err = expandData(*elems.elems, elems.which, dir, fs)
+		if err != nil {
+			return nil, err
+		}
+	}
 	load := func(elems []RawElement, which string) error {
 		for i, elem := range elems {
 			var err error
@@ -381,6 +478,8 @@ func LoadRawSuite(filename string, fs FileSystem) (*RawSuite, error) {
 				return fmt.Errorf("File and Test must not both be empty in %d. %s", i+1, which)
 			}
 			rt.contextVars = elem.Variables
+			rt.condIf, rt.condUnless = elem.If, elem.Unless
+			rt.Tags = append(rt.Tags, elem.Tags...)
 			for _, mockname := range elem.Mocks {
 				mf, err := LoadRawMock(path.Join(dir, mockname), fs)
 				if err != nil {
@@ -409,7 +508,151 @@ func LoadRawSuite(filename string, fs FileSystem) (*RawSuite, error) {
 	return rs, nil
 }
 
+// expandData replaces each element with Data by one element per row of
+// the data table. The columns of a row dominate the element's Variables.
+func expandData(elems []RawElement, which string, dir string, fs FileSystem) ([]RawElement, error) {
+	expanded := make([]RawElement, 0, len(elems))
+	for i, elem := range elems {
+		if elem.Data == "" {
+			expanded = append(expanded, elem)
+			continue
+		}
+		rows, err := LoadData(path.Join(dir, elem.Data), fs)
+		if err != nil {
+			return nil, fmt.Errorf("cannot load data for %d. %s: %s",
+				i+1, which, err)
+		}
+		for _, row := range rows {
+			call := elem
+			call.Data = ""
+			call.Variables = make(map[string]string, len(elem.Variables)+len(row))
+			for n, v := range elem.Variables {
+				call.Variables[n] = v
+			}
+			for n, v := range row {
+				call.Variables[n] = v
+			}
+			if elem.Test != nil {
+				// rawTestFromInline modifies the inline test.
+				call.Test = make(map[string]interface{}, len(elem.Test))
+				for n, v := range elem.Test {
+					call.Test[n] = v
+				}
+			}
+			expanded = append(expanded, call)
+		}
+	}
+	return expanded, nil
+}
+
+// LoadData reads the table of variables from filename. Files with
+// extension ".csv" are read as comma separated values where the first
+// record contains the variable names and each following record is one
+// row. All other files must contain a JSON (or HJSON) array of objects,
+// each object being one row. A table without rows is an error.
+func LoadData(filename string, fs FileSystem) ([]map[string]string, error) {
+	var data string
+	if len(fs) == 0 {
+		buf, err := ioutil.ReadFile(filename)
+		if err != nil {
+			return nil, err
+		}
+		data = string(buf)
+	} else if f, ok := fs[filename]; ok {
+		data = f.Data
+	} else {
+		return nil, fmt.Errorf("file %s not found", filename)
+	}
+
+	var rows []map[string]string
+	var err error
+	if strings.ToLower(path.Ext(filename)) == ".csv" {
+		rows, err = csvData(data)
+	} else {
+		rows, err = jsonData(data)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("file %s: %s", filename, err)
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("file %s contains no rows", filename)
+	}
+	return rows, nil
+}
+
+func csvData(data string) ([]map[string]string, error) {
+	records, err := csv.NewReader(strings.NewReader(data)).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+	header := records[0]
+	for i, name := range header {
+		header[i] = strings.TrimSpace(name)
+		if header[i] == "" {
+			return nil, fmt.Errorf("empty variable name in column %d", i+1)
+		}
+	}
+	rows := make([]map[string]string, 0, len(records)-1)
+	for _, record := range records[1:] {
+		row := make(map[string]string, len(header))
+		for i, value := range record {
+			row[header[i]] = value
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+func jsonData(data string) ([]map[string]string, error) {
+	var soup interface{}
+	err := hjson.Unmarshal([]byte(data), &soup)
+	if err != nil {
+		return nil, err
+	}
+	list, ok := soup.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("not an array of objects (got %T)", soup)
+	}
+	rows := make([]map[string]string, len(list))
+	for i, elem := range list {
+		obj, ok := elem.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("row %d is not an object (got %T)", i+1, elem)
+		}
+		rows[i] = make(map[string]string, len(obj))
+		for name, value := range obj {
+			switch v := value.(type) {
+			case string:
+				rows[i][name] = v
+			case int64, uint64:
+				rows[i][name] = fmt.Sprint(v)
+			case float64:
+				rows[i][name] = strconv.FormatFloat(v, 'f', -1, 64)
+			case bool:
+				rows[i][name] = strconv.FormatBool(v)
+			case nil:
+				rows[i][name] = ""
+			default:
+				return nil, fmt.Errorf("row %d: variable %s is not a simple value",
+					i+1, name)
+			}
+		}
+	}
+	return rows, nil
+}
+
 func rawTestFromInline(name, dir string, fs FileSystem, inline map[string]interface{}) (*RawTest, error) {
+	var tags []string
+	if t, ok := inline["Tags"]; ok {
+		err := populate.Strict(&tags, t)
+		if err != nil {
+			return nil, err
+		}
+		delete(inline, "Tags")
+	}
 	mixins := []*Mixin{}
 	if m, ok := inline["Mixins"]; ok {
 		mixs := []string{}
@@ -438,9 +681,82 @@ func rawTestFromInline(name, dir string, fs FileSystem, inline map[string]interf
 	return &RawTest{
 		File:   raw,
 		Mixins: mixins,
+		Tags:   tags,
 	}, nil
 }
 
+// SelectByTags disables the Main tests of rs which are not selected by
+// the tag expression include or which are selected by the tag expression
+// exclude. Empty expressions do not restrict the selection. A tag
+// expression like "smoke && !slow" combines tags with &&, || and !; a tag
+// is true if the test has this tag. If no Main test is selected all tests,
+// including Setup and Teardown, are disabled.
+func (rs *RawSuite) SelectByTags(include, exclude string) error {
+	if include == "" && exclude == "" {
+		return nil
+	}
+	var incl, excl *expr.Expr
+	var err error
+	if include != "" {
+		if incl, err = expr.Parse(include); err != nil {
+			return err
+		}
+	}
+	if exclude != "" {
+		if excl, err = expr.Parse(exclude); err != nil {
+			return err
+		}
+	}
+
+	selected := 0
+	main := rs.tests[len(rs.Setup) : len(rs.tests)-len(rs.Teardown)]
+	for _, rt := range main {
+		if !rt.IsEnabled() {
+			continue
+		}
+		env := tagEnv{rt}
+		if incl != nil {
+			ok, err := incl.Eval(env)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				rt.Disable()
+				continue
+			}
+		}
+		if excl != nil {
+			ok, err := excl.Eval(env)
+			if err != nil {
+				return err
+			}
+			if ok {
+				rt.Disable()
+				continue
+			}
+		}
+		selected++
+	}
+
+	if selected == 0 {
+		for _, rt := range rs.tests {
+			rt.Disable()
+		}
+	}
+	return nil
+}
+
+// tagEnv evaluates tag expressions for a test.
+type tagEnv struct{ rt *RawTest }
+
+func (env tagEnv) Word(tag string) (string, error) {
+	return strconv.FormatBool(env.rt.HasTag(tag)), nil
+}
+
+func (env tagEnv) Call(fn string, args []string) (string, error) {
+	return "", fmt.Errorf("unknown function %s in tag expression", fn)
+}
+
 // Validate rs to make sure it can be decoded into welformed ht.Tests.
 func (rs *RawSuite) Validate(global map[string]string) error {
 	suiteScope := scope.New(global, rs.Variables, true)
@@ -484,21 +800,23 @@ func (rs *RawSuite) Validate(global map[string]string) error {
 //      Teardown-1    Pass     Pass
 //      Teardown-2    Fail     Error
 //      Teardown-3    Pass     Pass
+//
+// If rs is Parallel Main tests which do not depend on each other are
+// executed concurrently, see Suite.Iterate.
 func (rs *RawSuite) Execute(global map[string]string, jar *cookiejar.Jar, logger *log.Logger) *Suite {
 	suite := NewFromRaw(rs, global, jar, logger)
 	N := len(rs.tests)
 	setup, main, teardown := len(rs.Setup), len(rs.Main), len(rs.Teardown)
-	i := 0
-	isSetup := func() bool { return i <= setup }
-	isMain := func() bool { return i > setup && i <= setup+main }
-	isSetupOrMain := func() bool { return i <= setup+main }
+	isSetup := func(i int) bool { return i <= setup }
+	isMain := func(i int) bool { return i > setup && i <= setup+main }
+	isSetupOrMain := func(i int) bool { return i <= setup+main }
 	setupfailures := false
 
 	executor := func(test *ht.Test) error {
-		i++
-		if isSetup() {
+		i := suite.position[test] + 1
+		if isSetup(i) {
 			test.SetMetadata("SeqNo", fmt.Sprintf("Setup-%02d", i))
-		} else if isMain() {
+		} else if isMain(i) {
 			test.SetMetadata("SeqNo", fmt.Sprintf("Main-%02d", i-setup))
 		} else {
 			test.SetMetadata("SeqNo", fmt.Sprintf("Teardown-%02d", i-setup-main))
@@ -509,7 +827,7 @@ func (rs *RawSuite) Execute(global map[string]string, jar *cookiejar.Jar, logger
 			fallthrough
 		case !rs.tests[i-1].IsEnabled():
 			fallthrough
-		case setupfailures && isSetupOrMain():
+		case setupfailures && isSetupOrMain(i):
 			test.Result.Status = ht.Skipped
 			return nil
 		}
@@ -519,7 +837,7 @@ func (rs *RawSuite) Execute(global map[string]string, jar *cookiejar.Jar, logger
 			test.Execution.Verbosity = rs.Verbosity
 			test.Run()
 		}
-		if test.Result.Status > ht.Pass && isSetup() {
+		if test.Result.Status > ht.Pass && isSetup(i) {
 			setupfailures = true
 		}
 
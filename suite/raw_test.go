@@ -582,3 +582,169 @@ func TestDecodeErrors(t *testing.T) {
 		}
 	}
 }
+
+func TestDataExpansion(t *testing.T) {
+	txt := `
+# data.suite
+{
+    Name: "Data driven Suite"
+    Main: [
+        {File: "test.ht", Data: "users.csv", Variables: {LOCALE: "en", USER: "nobody"}}
+        {Test: {
+            Name: "Inline {{N}}"
+            Mixins: [ "stdheaders.mix" ]
+            Request: { URL: "file:///etc/passwd" }
+        }, Data: "numbers.json"}
+        {File: "test.ht", Variables: {USER: "ann", LOCALE: "de"}}
+    ]
+}
+
+# test.ht
+{
+    Name: "Test {{USER}} {{LOCALE}}"
+    Request: { URL: "file:///etc/passwd" }
+}
+
+# stdheaders.mix
+{
+    Request: { Header: { "X-Foo": "bar" } }
+}
+
+# users.csv
+USER, PASS
+joe,secret
+"sue, jr",other
+
+# numbers.json
+[ {N: 1}, {N: 2.5}, {N: "three", FLAG: true} ]
+`
+	rs, err := parseRawSuite("data.suite", txt)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if len(rs.Main) != 6 || len(rs.RawTests()) != 6 {
+		t.Fatalf("Got %d elements and %d tests, want 6", len(rs.Main), len(rs.RawTests()))
+	}
+
+	s := rs.Execute(nil, nil, logger())
+	want := []string{"Test joe en", "Test sue, jr en", "Inline 1", "Inline 2.5",
+		"Inline three", "Test ann de"}
+	for i, test := range s.Tests {
+		if test.Name != want[i] {
+			t.Errorf("%d. test: got %q, want %q", i, test.Name, want[i])
+		}
+		if i >= 2 && i <= 4 && test.Request.Header.Get("X-Foo") != "bar" {
+			t.Errorf("%d. test: mixin not applied", i)
+		}
+	}
+	if got := rs.RawTests()[4].contextVars["FLAG"]; got != "true" {
+		t.Errorf("Got FLAG=%q", got)
+	}
+}
+
+func TestLoadDataErrors(t *testing.T) {
+	fs := FileSystem{
+		"empty.csv":   &File{Name: "empty.csv", Data: "A,B\n"},
+		"ragged.csv":  &File{Name: "ragged.csv", Data: "A,B\n1,2,3\n"},
+		"noname.csv":  &File{Name: "noname.csv", Data: "A,,C\n1,2,3\n"},
+		"object.json": &File{Name: "object.json", Data: `{A: 1}`},
+		"nested.json": &File{Name: "nested.json", Data: `[{A: [1, 2]}]`},
+	}
+	for _, tc := range []struct {
+		name, err string
+	}{
+		{"empty.csv", "file empty.csv contains no rows"},
+		{"ragged.csv", "file ragged.csv: record on line 2: wrong number of fields"},
+		{"noname.csv", "file noname.csv: empty variable name in column 2"},
+		{"object.json", "file object.json: not an array of objects (got map[string]interface {})"},
+		{"nested.json", "file nested.json: row 1: variable A is not a simple value"},
+		{"missing.csv", "file missing.csv not found"},
+	} {
+		_, err := LoadData(tc.name, fs)
+		if err == nil || err.Error() != tc.err {
+			t.Errorf("%s: got %v, want %s", tc.name, err, tc.err)
+		}
+	}
+}
+
+func TestSelectByTags(t *testing.T) {
+	txt := `
+# tags.suite
+{
+    Name: "Tagged Suite"
+    Setup: [ {File: "plain.ht"} ]
+    Main: [
+        {File: "smoke.ht"}
+        {File: "smoke.ht", Tags: ["slow"]}
+        {File: "plain.ht", Tags: ["smoke", "db"]}
+        {Test: {Name: "Inline", Tags: ["slow"], Request: {URL: "file:///etc/passwd"}}}
+    ]
+    Teardown: [ {File: "plain.ht"} ]
+}
+
+# smoke.ht
+{
+    Name: "Smoke"
+    Tags: [ "smoke" ]
+    Request: { URL: "file:///etc/passwd" }
+}
+
+# plain.ht
+{
+    Name: "Plain"
+    Request: { URL: "file:///etc/passwd" }
+}
+`
+	for i, tc := range []struct {
+		include, exclude string
+		want             string
+	}{
+		{"", "", "111111"},
+		{"smoke", "", "111101"},
+		{"smoke && !slow", "", "110101"},
+		{"", "slow", "110101"},
+		{"smoke", "db", "111001"},
+		{"slow || db", "smoke && slow", "100111"},
+		{"nothing", "", "000000"},
+	} {
+		rs, err := parseRawSuite("tags.suite", txt)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		err = rs.SelectByTags(tc.include, tc.exclude)
+		if err != nil {
+			t.Errorf("%d. Unexpected error: %s", i, err)
+			continue
+		}
+		got := ""
+		for _, rt := range rs.RawTests() {
+			if rt.IsEnabled() {
+				got += "1"
+			} else {
+				got += "0"
+			}
+		}
+		if got != tc.want {
+			t.Errorf("%d. %q / %q: got %s, want %s", i, tc.include, tc.exclude,
+				got, tc.want)
+		}
+	}
+
+	rs, err := parseRawSuite("tags.suite", txt)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if err := rs.SelectByTags("smoke &&", ""); err == nil {
+		t.Errorf("Missing error for bad expression")
+	}
+	if err := rs.SelectByTags("", "has(smoke)"); err == nil ||
+		err.Error() != `expression "has(smoke)": unknown function has in tag expression` {
+		t.Errorf("Got error %v", err)
+	}
+
+	// The tags are not part of the test.
+	s := rs.Execute(nil, nil, logger())
+	if s.Status != ht.Pass {
+		t.Errorf("Got status %s: %v", s.Status, s.Error)
+	}
+}
@@ -60,7 +60,8 @@ var htmlTestTmpl = `{{define "TEST"}}
   <div class="toggle-content">
     <div class="testDetails">
       <div class="reqresp"><code>
-        {{if .Request.Request}}
+        {{if .Request.Request}}{{with .Response.Challenge}}
+          <strong>{{$.Request.Request.Method}}</strong> {{.URL}} ({{.StatusCode}} Digest challenge)<br/>{{end}}
           <strong>{{.Request.Request.Method}}</strong> {{.Request.Request.URL.String}}<br/>
           {{range .Response.Redirections}}
             <strong>GET</strong> {{.}}<br/>
@@ -75,7 +76,8 @@ var htmlTestTmpl = `{{define "TEST"}}
 	Started: {{nicetime .Result.Started}}<br/>
 	Full Duration: {{niceduration .Result.FullDuration}} <br/>
         Number of tries: {{.Result.Tries}} <br/>
-        Request Duration: {{niceduration .Result.Duration}} <br/>
+        Request Duration: {{niceduration .Result.Duration}} <br/>{{with .Response.Timings}}{{if .TTFB}}
+        Timing: DNS {{niceduration .DNS}}, Connect {{niceduration .Connect}}, TLS {{niceduration .TLS}}, First Byte {{niceduration .TTFB}}, Transfer {{niceduration .Transfer}} <br/>{{end}}{{end}}
         {{if .Result.Error}}<br/><strong>Error:</strong> {{errlist .Result.Error}}<br/>{{end}}
       </div>
       {{if .Request.Request}}{{template "REQUEST" .}}{{end}}
@@ -664,6 +666,8 @@ func augmentMetadataAndDumpBody(s *Suite, dir string, prefix string) error {
 }
 
 // HTMLReport generates a report of the outcome of s to directory dir.
+// The requests and responses are written as a HTTP Archive to the file
+// _Report_.har which can be loaded into browser devtools or HAR viewers.
 func HTMLReport(dir string, s *Suite) error {
 	errs := errorlist.List{}
 
@@ -675,6 +679,14 @@ func HTMLReport(dir string, s *Suite) error {
 	if err == nil {
 		err = HtmlSuiteTmpl.Execute(report, s)
 		errs = errs.Append(err)
+		errs = errs.Append(report.Close())
+	}
+
+	archive, err := s.HAR()
+	errs = errs.Append(err)
+	if err == nil {
+		err = ioutil.WriteFile(path.Join(dir, "_Report_.har"), archive, 0666)
+		errs = errs.Append(err)
 	}
 
 	return errs.AsError()
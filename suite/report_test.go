@@ -93,6 +93,12 @@ func TestHTMLReport(t *testing.T) {
 				"http://www.example.org/login",
 				"http://www.example.org/auth",
 			},
+			Timings: ht.Timings{
+				DNS:      5 * time.Millisecond,
+				Connect:  10 * time.Millisecond,
+				TTFB:     150 * time.Millisecond,
+				Transfer: 50 * time.Millisecond,
+			},
 		},
 		Result: ht.Result{
 			Status:       ht.Pass,
@@ -13,11 +13,13 @@ import (
 	"fmt"
 	"io/ioutil"
 	"log"
+	"sync"
 	"time"
 
 	"github.com/vdobler/ht/cookiejar"
 	"github.com/vdobler/ht/errorlist"
 	"github.com/vdobler/ht/ht"
+	"github.com/vdobler/ht/internal/expr"
 	"github.com/vdobler/ht/mock"
 	"github.com/vdobler/ht/scope"
 )
@@ -28,6 +30,7 @@ type Suite struct {
 	Name        string // Name of the Suite.
 	Description string // Description of what's going on here.
 	KeepCookies bool   // KeepCookies in a cookie jar common to all Tests.
+	Parallel    bool   // Parallel execution of independent Main tests.
 
 	Status   ht.Status     // Status is the overall status of the whole suite.
 	Error    error         // Error encountered during execution of the suite.
@@ -39,6 +42,7 @@ type Suite struct {
 	Variables      scope.Variables // The initial variable assignment
 	FinalVariables scope.Variables // The final set of variables.
 	Jar            *cookiejar.Jar  // The cookie jar used
+	Tokens         *ht.TokenCache  // The OAuth2 tokens acquired
 
 	Verbosity int
 	Log       interface {
@@ -47,7 +51,9 @@ type Suite struct {
 
 	globals          scope.Variables
 	tests            []*RawTest
+	setupTest        int
 	noneTeardownTest int
+	position         map[*ht.Test]int // index of the test in tests
 }
 
 // NewFromRaw sets up a new Suite from rs, read to be Iterated.
@@ -68,6 +74,7 @@ func NewFromRaw(rs *RawSuite, global map[string]string, jar *cookiejar.Jar, logg
 
 	suite := &Suite{
 		KeepCookies: rs.KeepCookies,
+		Parallel:    rs.Parallel,
 
 		Status: ht.NotRun,
 		Error:  nil,
@@ -77,10 +84,13 @@ func NewFromRaw(rs *RawSuite, global map[string]string, jar *cookiejar.Jar, logg
 		Variables:        make(map[string]string),
 		FinalVariables:   make(map[string]string),
 		Jar:              jar,
+		Tokens:           ht.NewTokenCache(),
 		Log:              logger,
 		Verbosity:        rs.Verbosity,
 		tests:            rs.tests,
+		setupTest:        len(rs.Setup),
 		noneTeardownTest: len(rs.Setup) + len(rs.Main),
+		position:         make(map[*ht.Test]int, len(rs.tests)),
 	}
 
 	suite.globals = scope.New(global, rs.Variables, true)
@@ -101,6 +111,8 @@ func NewFromRaw(rs *RawSuite, global map[string]string, jar *cookiejar.Jar, logg
 // A Executor is responsible for executing the given test during the
 // Iterate'ion of a Suite. It should return nil if execution should continue
 // and ErrAbortExecution to stop further iteration.
+// Executors of a Parallel suite are called concurrently for independent
+// Main tests.
 type Executor func(test *ht.Test) error
 
 var (
@@ -109,6 +121,13 @@ var (
 )
 
 // Iterate the suite through the given executor.
+//
+// Tests are iterated in order. If suite is Parallel consecutive Main tests
+// which do not depend on variables extracted by one of the others are
+// executed concurrently; the outcome is processed in order as if the tests
+// were executed sequentially. Note that dependencies via cookies are not
+// detected. Once the executor aborts the iteration, tests of the batch
+// following the aborted one are not started anymore.
 func (suite *Suite) Iterate(executor Executor) {
 	now := time.Now()
 	now = now.Add(-time.Duration(now.Nanosecond()))
@@ -117,64 +136,72 @@ func (suite *Suite) Iterate(executor Executor) {
 	overall := ht.NotRun
 	errors := errorlist.List{}
 
-	for _, rt := range suite.tests {
-		// suite.Log.Printf("Executing Test %q\n", rt.File.Name)
-		callScope := scope.New(suite.globals, rt.contextVars, true)
-		testScope := scope.New(callScope, rt.Variables, false)
-		testScope["TEST_DIR"] = rt.File.Dirname()
-		testScope["TEST_NAME"] = rt.File.Basename()
-		test, err := rt.ToTest(testScope)
-		test.SetMetadata("Filename", rt.File.Name)
-		if err != nil {
-			test.Result.Status = ht.Bogus
-			test.Result.Error = err
-		}
-		test.Jar = suite.Jar
-		test.Log = suite.Log
-
-		// Mocks requested for this test: We expect each mock to be
-		// called exactly once (and this call should pass).
-		mocks := make([]*mock.Mock, 0, len(rt.mocks))
-		for _, m := range rt.mocks {
-			mockScope := scope.New(testScope, rt.Variables, false)
-			mockScope["MOCK_DIR"] = m.Dirname()
-			mockScope["MOCK_NAME"] = m.Basename()
-			mk, err := m.ToMock(mockScope, true)
-			if err != nil {
-				test.Result.Status = ht.Bogus
-				test.Result.Error = err
-				break
-			}
-			mocks = append(mocks, mk)
+	for n := 0; n < len(suite.tests); {
+		batch := suite.batch(n)
+		runs := make([]*testRun, len(batch))
+		for k := range batch {
+			runs[k] = suite.prepare(n + k)
 		}
 
-		ctrl, merr := mock.Provide(mocks, suite.Log)
-		if merr != nil {
-			test.Result.Status = ht.Bogus
-			test.Result.Error = merr
+		// Execute the tests (if not bogus). Once a test aborts the
+		// later tests of the batch which have not started yet are
+		// not executed.
+		if len(runs) == 1 {
+			runs[0].exstat = executor(runs[0].test)
+		} else {
+			var wg sync.WaitGroup
+			var mu sync.Mutex
+			abortAt := len(runs)
+			for k, run := range runs {
+				wg.Add(1)
+				go func(k int, run *testRun) {
+					defer wg.Done()
+					mu.Lock()
+					stop := abortAt < k
+					mu.Unlock()
+					if stop {
+						return
+					}
+					run.exstat = executor(run.test)
+					if run.exstat == ErrAbortExecution {
+						mu.Lock()
+						if k < abortAt {
+							abortAt = k
+						}
+						mu.Unlock()
+					}
+				}(k, run)
+			}
+			wg.Wait()
 		}
 
-		// Execute the test (if not bogus).
-		exstat := executor(test)
+		abort := false
+		for _, run := range runs {
+			test := run.test
+			if run.merr == nil {
+				analyseMocks(test, run.ctrl)
+			}
+			if test.Result.Status == ht.Pass {
+				suite.updateVariables(test)
+			}
 
-		if merr == nil {
-			analyseMocks(test, ctrl)
-		}
-		if test.Result.Status == ht.Pass {
-			suite.updateVariables(test)
-		}
+			suite.Tests = append(suite.Tests, test)
+			if test.Result.Status > overall {
+				overall = test.Result.Status
+			}
+			if err := test.Result.Error; err != nil {
+				errors = append(errors, err)
+			}
 
-		suite.Tests = append(suite.Tests, test)
-		if test.Result.Status > overall {
-			overall = test.Result.Status
-		}
-		if err := test.Result.Error; err != nil {
-			errors = append(errors, err)
+			if run.exstat == ErrAbortExecution {
+				abort = true
+				break
+			}
 		}
-
-		if exstat == ErrAbortExecution {
+		if abort {
 			break
 		}
+		n += len(batch)
 	}
 	suite.Duration = time.Since(suite.Started)
 	clip := suite.Duration.Nanoseconds() % 1000000
@@ -191,6 +218,152 @@ func (suite *Suite) Iterate(executor Executor) {
 	}
 }
 
+// testRun captures a test to execute with its mocks and the outcome of the
+// executor.
+type testRun struct {
+	test   *ht.Test
+	ctrl   mock.Control
+	merr   error
+	exstat error
+}
+
+// prepare the n'th test for execution in the current suite scope and
+// provide its mocks.
+func (suite *Suite) prepare(n int) *testRun {
+	rt := suite.tests[n]
+	// suite.Log.Printf("Executing Test %q\n", rt.File.Name)
+	callScope := scope.New(suite.globals, rt.contextVars, true)
+	testScope := scope.New(callScope, rt.Variables, false)
+	testScope["TEST_DIR"] = rt.File.Dirname()
+	testScope["TEST_NAME"] = rt.File.Basename()
+	test, err := rt.ToTest(testScope)
+	test.SetMetadata("Filename", rt.File.Name)
+	if err != nil {
+		test.Result.Status = ht.Bogus
+		test.Result.Error = err
+	}
+	test.Jar = suite.Jar
+	test.Tokens = suite.Tokens
+	test.Log = suite.Log
+	if suite.position != nil {
+		suite.position[test] = n
+	}
+
+	if err == nil {
+		met, err := suite.conditionsMet(rt, testScope)
+		if err != nil {
+			test.Result.Status = ht.Bogus
+			test.Result.Error = err
+		} else if !met {
+			if suite.Verbosity >= 1 {
+				suite.Log.Printf("Skipping test %q: condition not met\n", test.Name)
+			}
+			test.Result.Status = ht.Skipped
+		}
+	}
+
+	// Mocks requested for this test: We expect each mock to be
+	// called exactly once (and this call should pass).
+	mocks := make([]*mock.Mock, 0, len(rt.mocks))
+	for _, m := range rt.mocks {
+		if test.Result.Status == ht.Skipped {
+			break // a skipped test won't call any mock
+		}
+		mockScope := scope.New(testScope, rt.Variables, false)
+		mockScope["MOCK_DIR"] = m.Dirname()
+		mockScope["MOCK_NAME"] = m.Basename()
+		mk, err := m.ToMock(mockScope, true)
+		if err != nil {
+			test.Result.Status = ht.Bogus
+			test.Result.Error = err
+			break
+		}
+		mocks = append(mocks, mk)
+	}
+
+	ctrl, merr := mock.Provide(mocks, suite.Log)
+	if merr != nil {
+		test.Result.Status = ht.Bogus
+		test.Result.Error = merr
+	}
+
+	return &testRun{test: test, ctrl: ctrl, merr: merr}
+}
+
+// conditionsMet evaluates the If and Unless conditions of rt with the
+// variables of the test and the status of the tests executed so far.
+func (suite *Suite) conditionsMet(rt *RawTest, testScope scope.Variables) (bool, error) {
+	replacer := testScope.Replacer()
+	for _, cond := range []struct {
+		name, src string
+		want      bool
+	}{{"If", rt.condIf, true}, {"Unless", rt.condUnless, false}} {
+		if cond.src == "" {
+			continue
+		}
+		e, err := expr.Parse(replacer.Replace(cond.src))
+		if err != nil {
+			return false, fmt.Errorf("bad %s condition: %s", cond.name, err)
+		}
+		value, err := e.Eval(conditionEnv(suite.Tests))
+		if err != nil {
+			return false, fmt.Errorf("bad %s condition: %s", cond.name, err)
+		}
+		if value != cond.want {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// conditionEnv is the environment to evaluate conditions of suite elements
+// in: Bare words are literals and status(test) is the status of the latest
+// of the tests with the given name or sequence number.
+type conditionEnv []*ht.Test
+
+func (env conditionEnv) Word(w string) (string, error) { return w, nil }
+
+func (env conditionEnv) Call(fn string, args []string) (string, error) {
+	if fn != "status" || len(args) != 1 {
+		return "", fmt.Errorf("unknown function %s with %d arguments", fn, len(args))
+	}
+	for i := len(env) - 1; i >= 0; i-- {
+		if env[i].Name == args[0] || env[i].GetMetadata("SeqNo") == args[0] {
+			return env[i].Result.Status.String(), nil
+		}
+	}
+	return "", fmt.Errorf("no test %q executed so far", args[0])
+}
+
+// batch returns the tests starting at index n which can be executed
+// concurrently: Consecutive Main tests of a Parallel suite without mocks
+// which do not use a variable extracted by a previous test of the batch.
+// A test with a condition starts a new batch.
+func (suite *Suite) batch(n int) []*RawTest {
+	if !suite.Parallel || n < suite.setupTest || n >= suite.noneTeardownTest {
+		return suite.tests[n : n+1]
+	}
+
+	extracted := make(map[string]bool)
+	end := n
+	for ; end < suite.noneTeardownTest; end++ {
+		rt := suite.tests[end]
+		if len(rt.mocks) > 0 || rt.uses(extracted) {
+			break
+		}
+		if end > n && (rt.condIf != "" || rt.condUnless != "") {
+			break
+		}
+		for _, name := range rt.extracts() {
+			extracted[name] = true
+		}
+	}
+	if end == n {
+		end++
+	}
+	return suite.tests[n:end]
+}
+
 // The following cases can happen
 //   - Mock executed and okay  --> Pass,  recorde in mockResults
 //   - Mock executed and fail  --> Fail,  recorde in mockResults
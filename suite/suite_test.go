@@ -9,10 +9,15 @@ import (
 	"io/ioutil"
 	"log"
 	"math/rand"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/vdobler/ht/ht"
 	"github.com/vdobler/ht/scope"
 )
 
@@ -257,3 +262,131 @@ func matchVars(got map[string]string, want string) string {
 
 	return ""
 }
+
+func TestParallelMain(t *testing.T) {
+	var mu sync.Mutex
+	running, maxRunning := 0, 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		running++
+		if running > maxRunning {
+			maxRunning = running
+		}
+		mu.Unlock()
+		time.Sleep(100 * time.Millisecond)
+		mu.Lock()
+		running--
+		mu.Unlock()
+		fmt.Fprint(w, r.URL.Path)
+	}))
+	defer ts.Close()
+
+	txt := `
+# parallel.suite
+{
+    Name: Testsuite for parallel execution
+    Parallel: true
+    Setup: [
+        { File: "test.ht", Variables: { PATH: "setup" } }
+    ]
+    Main: [
+        { File: "test.ht", Variables: { PATH: "a" } }
+        { File: "test.ht", Variables: { PATH: "b" } }
+        { File: "extract.ht" }
+        { File: "test.ht", Variables: { PATH: "{{TOKEN}}" } }
+    ]
+    Teardown: [
+        { File: "test.ht", Variables: { PATH: "teardown" } }
+    ]
+}
+
+# test.ht
+{
+    Name: "Test {{PATH}}"
+    Request: { URL: "{{URL}}/{{PATH}}" }
+    Checks: [ {Check: "Body", Equals: "/{{PATH}}"} ]
+}
+
+# extract.ht
+{
+    Name: "Extract"
+    Request: { URL: "{{URL}}/c" }
+    DataExtraction: {
+        TOKEN: {Extractor: "SetVariable", To: "d" }
+    }
+}`
+
+	rs, err := parseRawSuite("parallel.suite", txt)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	s := rs.Execute(map[string]string{"URL": ts.URL}, nil, logger())
+
+	if s.Status != ht.Pass {
+		s.PrintReport(os.Stdout)
+		t.Fatalf("Got status %s", s.Status)
+	}
+	if maxRunning != 3 {
+		t.Errorf("Got %d concurrent requests, want 3", maxRunning)
+	}
+	want := []string{"Test setup", "Test a", "Test b", "Extract", "Test d", "Test teardown"}
+	for i, test := range s.Tests {
+		if test.Name != want[i] {
+			t.Errorf("%d. test: got %q, want %q", i, test.Name, want[i])
+		}
+	}
+	if seqno := s.Tests[4].GetStringMetadata("SeqNo"); seqno != "Main-04" {
+		t.Errorf("Got SeqNo %q", seqno)
+	}
+}
+
+func TestConditions(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/login" {
+			http.Error(w, "Database down", http.StatusInternalServerError)
+			return
+		}
+		fmt.Fprint(w, "ok")
+	}))
+	defer ts.Close()
+
+	txt := `
+# conditions.suite
+{
+    Name: Testsuite for conditional execution
+    Variables: { FEATURE_X: "off" }
+    Main: [
+        { File: "test.ht", Variables: { PATH: "login" } }
+        { File: "test.ht", Variables: { PATH: "profile" }, If: "status(\"Test login\") == Pass" }
+        { File: "test.ht", Variables: { PATH: "feature" }, Unless: "'{{FEATURE_X}}' == off" }
+        { File: "test.ht", Variables: { PATH: "fallback" }, If: "status(Main-01) == Fail && {{FEATURE_X}} != on" }
+        { File: "test.ht", Variables: { PATH: "both" }, If: "true", Unless: "status(Main-04) != Pass" }
+        { File: "test.ht", Variables: { PATH: "bogus" }, If: "status(Main-09)" }
+    ]
+}
+
+# test.ht
+{
+    Name: "Test {{PATH}}"
+    Request: { URL: "{{URL}}/{{PATH}}" }
+    Checks: [ {Check: "StatusCode", Expect: 200} ]
+}`
+
+	rs, err := parseRawSuite("conditions.suite", txt)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	s := rs.Execute(map[string]string{"URL": ts.URL}, nil, logger())
+
+	want := []ht.Status{ht.Fail, ht.Skipped, ht.Skipped, ht.Pass, ht.Pass, ht.Bogus}
+	for i, test := range s.Tests {
+		if test.Result.Status != want[i] {
+			t.Errorf("%d. %s: got %s, want %s (%v)", i, test.Name,
+				test.Result.Status, want[i], test.Result.Error)
+		}
+	}
+	if err := s.Tests[5].Result.Error; err == nil ||
+		err.Error() != `bad If condition: expression "status(Main-09)": no test "Main-09" executed so far` {
+		t.Errorf("Got error %v", err)
+	}
+}
@@ -81,6 +81,7 @@ type Scenario struct {
 // setup runs the Setup tests of sc.
 func (sc *Scenario) setup(logger *log.Logger) *Suite {
 	suite := NewFromRaw(sc.RawSuite, sc.globals, sc.jar, logger)
+	suite.Parallel = false // executor relies on sequential iteration
 	// Cap tests to setup-tests.
 	suite.tests = suite.tests[:len(sc.RawSuite.Setup)]
 	i := 0
@@ -110,6 +111,7 @@ func (sc *Scenario) setup(logger *log.Logger) *Suite {
 // teardown runs the Teardown tests of sc.
 func (sc *Scenario) teardown(logger *log.Logger) *Suite {
 	suite := NewFromRaw(sc.RawSuite, sc.globals, sc.jar, logger)
+	suite.Parallel = false // executor relies on sequential iteration
 	// Cap tests to setup-tests.
 	suite.tests = suite.tests[len(suite.tests)-len(sc.RawSuite.Teardown):]
 	i := 0
@@ -223,6 +225,7 @@ func (p *pool) newThread(stop chan bool, logger *log.Logger) {
 				return nil
 			}
 			suite := NewFromRaw(p.Scenario.RawSuite, thglobals, nil, logger)
+			suite.Parallel = false // concurrency is controlled by the load test
 
 			nSetup, nMain := len(p.Scenario.RawSuite.Setup), len(p.Scenario.RawSuite.Main)
 			suite.tests = suite.tests[nSetup : nSetup+nMain]